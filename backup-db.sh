@@ -1,3 +1,5 @@
 #!/bin/bash
-source /var/www/dawhub/.env
-docker exec dawhub_db_1 pg_dump -U "$DB_USER" "$DB_NAME" > /var/www/dawhub/backups/db-$(date +%Y%m%d).sql
\ No newline at end of file
+# Snapshots the database together with every stored file it references, then
+# applies the BACKUP_KEEP_* retention policy. Backups land in BACKUP_TARGET:
+# ./backups by default, or s3://bucket/prefix.
+docker exec dawhub_app_1 ./dawhubctl backup create -prune
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"dawhub/internal/backup"
+	"dawhub/internal/domain"
+	"dawhub/internal/storage"
+)
+
+// errBackupIntegrity makes backup verify exit non-zero after printing its report
+var errBackupIntegrity = errors.New("backup integrity check failed")
+
+// snapshotRow is a snapshot as printed, without its object list
+type snapshotRow struct {
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int64     `json:"schema_version"`
+	Objects       int       `json:"objects"`
+	Size          int64     `json:"size"`
+	Copied        int       `json:"copied"`
+	CopiedBytes   int64     `json:"copied_bytes"`
+}
+
+func createBackup(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("backup create", flag.ContinueOnError)
+	prune := fs.Bool("prune", false, "apply the retention policy afterwards")
+	allowPartial := fs.Bool("allow-partial", false, "keep the snapshot even if some objects can't be backed up")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	archive, err := ctl.archive()
+	if err != nil {
+		return err
+	}
+	db, err := ctl.database(true)
+	if err != nil {
+		return err
+	}
+	store, err := ctl.storage()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := archive.Backup(db, store, *allowPartial)
+	if errors.Is(err, backup.ErrIncomplete) {
+		for _, skipped := range manifest.Skipped {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", skipped.Key, skipped.Problem)
+		}
+		return fmt.Errorf("backup failed: %w (see dawhubctl storage verify -deep, or pass -allow-partial)", err)
+	}
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	if err := ctl.printSnapshots([]backup.Manifest{*manifest}); err != nil {
+		return err
+	}
+	for _, skipped := range manifest.Skipped {
+		fmt.Fprintf(os.Stderr, "warning: %s was not backed up: %s\n", skipped.Key, skipped.Problem)
+	}
+	if *prune {
+		report, err := archive.Prune(ctl.retention(), false)
+		if err != nil {
+			return fmt.Errorf("prune failed: %w", err)
+		}
+		if !ctl.json {
+			fmt.Fprintf(os.Stderr, "Pruned %d snapshots, deleted %d files (%s)\n",
+				len(report.Removed), report.DeletedObjects, domain.FormatFileSize(report.ReclaimedBytes))
+		}
+	}
+	return nil
+}
+
+func listBackups(ctl *ctl, args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("backup list", flag.ContinueOnError), args, 0, 0); err != nil {
+		return err
+	}
+	archive, err := ctl.archive()
+	if err != nil {
+		return err
+	}
+
+	manifests, err := archive.Snapshots()
+	if err != nil {
+		return err
+	}
+	return ctl.printSnapshots(manifests)
+}
+
+func verifyBackup(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("backup verify", flag.ContinueOnError)
+	deep := fs.Bool("deep", false, "download everything and check hashes")
+	ids, err := parseFlags(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	archive, err := ctl.archive()
+	if err != nil {
+		return err
+	}
+
+	id := ""
+	if len(ids) > 0 {
+		id = ids[0]
+	}
+	report, err := archive.Verify(id, *deep)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(report.Problems))
+	for i, problem := range report.Problems {
+		rows[i] = []string{problem.Key, problem.Problem}
+	}
+	if err := ctl.print(report, []string{"KEY", "PROBLEM"}, rows); err != nil {
+		return err
+	}
+	if !ctl.json {
+		fmt.Fprintf(os.Stderr, "Checked %d snapshots and %d objects, %d problems\n",
+			report.Snapshots, report.Objects, len(report.Problems))
+	}
+	if len(report.Problems) > 0 {
+		return errBackupIntegrity
+	}
+	return nil
+}
+
+func pruneBackups(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("backup prune", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be removed without removing it")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	archive, err := ctl.archive()
+	if err != nil {
+		return err
+	}
+
+	report, err := archive.Prune(ctl.retention(), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(report.Kept)+len(report.Removed))
+	for _, id := range report.Kept {
+		rows = append(rows, []string{id, "keep"})
+	}
+	for _, id := range report.Removed {
+		rows = append(rows, []string{id, "remove"})
+	}
+	if err := ctl.print(report, []string{"SNAPSHOT", "ACTION"}, rows); err != nil {
+		return err
+	}
+	if !ctl.json {
+		verb := "Deleted"
+		if *dryRun {
+			verb = "Would delete"
+		}
+		fmt.Fprintf(os.Stderr, "%s %d unneeded files (%s)\n",
+			verb, report.DeletedObjects, domain.FormatFileSize(report.ReclaimedBytes))
+	}
+	return nil
+}
+
+// restoreBackup loads a snapshot into an empty database, which must already
+// be migrated (dawhubctl migrate up)
+func restoreBackup(ctl *ctl, args []string) error {
+	ids, err := parseFlags(flag.NewFlagSet("backup restore", flag.ContinueOnError), args, 0, 1)
+	if err != nil {
+		return err
+	}
+	archive, err := ctl.archive()
+	if err != nil {
+		return err
+	}
+	db, err := ctl.database(true)
+	if err != nil {
+		return err
+	}
+	store, err := ctl.storage()
+	if err != nil {
+		return err
+	}
+
+	id := "latest"
+	if len(ids) > 0 {
+		id = ids[0]
+	}
+	report, err := archive.Restore(id, db, store)
+	if err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+
+	tables := make([]string, 0, len(report.Rows))
+	for table := range report.Rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	rows := make([][]string, len(tables))
+	for i, table := range tables {
+		rows[i] = []string{table, strconv.FormatInt(report.Rows[table], 10)}
+	}
+	if err := ctl.print(report, []string{"TABLE", "ROWS"}, rows); err != nil {
+		return err
+	}
+	if !ctl.json {
+		fmt.Fprintf(os.Stderr, "Restored snapshot %s: %d objects copied, %d already in storage\n",
+			report.Snapshot, report.Objects, report.Existing)
+	}
+	return nil
+}
+
+func (c *ctl) archive() (*backup.Archive, error) {
+	target, err := backup.OpenTarget(c.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup target: %w", err)
+	}
+	return backup.NewArchive(target), nil
+}
+
+func (c *ctl) storage() (domain.StorageService, error) {
+	store, _, err := storage.New(c.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return store, nil
+}
+
+func (c *ctl) retention() backup.Policy {
+	return backup.Policy{
+		KeepLast:    c.cfg.Backup.KeepLast,
+		KeepDaily:   c.cfg.Backup.KeepDaily,
+		KeepWeekly:  c.cfg.Backup.KeepWeekly,
+		KeepMonthly: c.cfg.Backup.KeepMonthly,
+	}
+}
+
+func (c *ctl) printSnapshots(manifests []backup.Manifest) error {
+	snapshots := make([]snapshotRow, len(manifests))
+	rows := make([][]string, len(manifests))
+	for i, manifest := range manifests {
+		snapshots[i] = snapshotRow{
+			ID:            manifest.ID,
+			CreatedAt:     manifest.CreatedAt,
+			SchemaVersion: manifest.SchemaVersion,
+			Objects:       len(manifest.Objects),
+			Size:          manifest.Size(),
+			Copied:        manifest.Copied,
+			CopiedBytes:   manifest.CopiedBytes,
+		}
+		rows[i] = []string{
+			manifest.ID,
+			manifest.CreatedAt.Format(timeFormat),
+			strconv.FormatInt(manifest.SchemaVersion, 10),
+			strconv.Itoa(len(manifest.Objects)),
+			domain.FormatFileSize(manifest.Size()),
+			fmt.Sprintf("%d (%s)", manifest.Copied, domain.FormatFileSize(manifest.CopiedBytes)),
+		}
+	}
+	return c.print(snapshots, []string{"ID", "CREATED", "SCHEMA", "OBJECTS", "SIZE", "COPIED"}, rows)
+}
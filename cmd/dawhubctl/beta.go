@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dawhub/internal/domain"
+	"dawhub/internal/email"
+)
+
+// betaInvite is the outcome of inviting one beta user
+type betaInvite struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Link   string `json:"link,omitempty"` // Only with -no-email, for sending by hand
+}
+
+func listBetaUsers(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("beta list", flag.ContinueOnError)
+	uninvited := fs.Bool("uninvited", false, "only list beta users who haven't been invited")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	repo, err := ctl.users()
+	if err != nil {
+		return err
+	}
+
+	var betaUsers []domain.BetaUser
+	if *uninvited {
+		betaUsers, err = repo.FindUninvitedBetaUsers()
+	} else {
+		var count int64
+		if err := repo.CountBetaUsers(&count); err != nil {
+			return err
+		}
+		betaUsers, err = repo.GetAllBetaUsers(1, int(count))
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(betaUsers))
+	for i, betaUser := range betaUsers {
+		invited := ""
+		if betaUser.InvitedAt != nil {
+			invited = betaUser.InvitedAt.Format(timeFormat)
+		}
+		rows[i] = []string{
+			strconv.FormatUint(uint64(betaUser.ID), 10),
+			betaUser.Email,
+			strconv.FormatBool(betaUser.IsSubscribed),
+			betaUser.CreatedAt.Format(timeFormat),
+			invited,
+		}
+	}
+	if betaUsers == nil {
+		betaUsers = []domain.BetaUser{}
+	}
+	return ctl.print(betaUsers, []string{"ID", "EMAIL", "SUBSCRIBED", "SIGNED UP", "INVITED"}, rows)
+}
+
+// inviteBetaUsers turns beta signups into signup invites, for everyone not
+// yet invited or for the given addresses
+func inviteBetaUsers(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("beta invite", flag.ContinueOnError)
+	noEmail := fs.Bool("no-email", false, "print the signup links instead of emailing them")
+	emails, err := parseFlags(fs, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	repo, err := ctl.users()
+	if err != nil {
+		return err
+	}
+
+	var betaUsers []domain.BetaUser
+	if len(emails) == 0 {
+		if betaUsers, err = repo.FindUninvitedBetaUsers(); err != nil {
+			return err
+		}
+	}
+	for _, address := range emails {
+		betaUser, err := repo.GetBetaUserByEmail(address)
+		if err != nil {
+			return fmt.Errorf("%s is not a beta user", address)
+		}
+		betaUsers = append(betaUsers, *betaUser)
+	}
+
+	emailService := email.NewResendService(ctl.cfg.Email)
+	baseURL := strings.TrimSuffix(ctl.cfg.Email.BaseURL, "/")
+
+	invites := make([]betaInvite, 0, len(betaUsers))
+	failed := 0
+	for _, betaUser := range betaUsers {
+		result := betaInvite{Email: betaUser.Email}
+		if _, err := repo.GetByEmail(betaUser.Email); err == nil {
+			result.Status = "already has an account"
+			invites = append(invites, result)
+			continue
+		}
+
+		invite, token, err := domain.NewSignupInvite(betaUser.Email)
+		if err == nil {
+			err = repo.CreateSignupInvite(invite)
+		}
+		if err == nil && !*noEmail {
+			err = emailService.SendSignupInviteEmail(betaUser.Email, token)
+		}
+		if err == nil {
+			err = repo.MarkBetaUserInvited(betaUser.ID)
+		}
+
+		switch {
+		case err != nil:
+			result.Status = "failed: " + err.Error()
+			failed++
+		case *noEmail:
+			result.Status = "invited"
+			result.Link = fmt.Sprintf("%s/signup/%s", baseURL, token)
+		default:
+			result.Status = "emailed"
+		}
+		invites = append(invites, result)
+	}
+
+	rows := make([][]string, len(invites))
+	for i, invite := range invites {
+		rows[i] = []string{invite.Email, invite.Status, invite.Link}
+	}
+	if err := ctl.print(invites, []string{"EMAIL", "STATUS", "LINK"}, rows); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d invites failed", failed, len(invites))
+	}
+	return nil
+}
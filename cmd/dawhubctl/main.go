@@ -0,0 +1,206 @@
+// Command dawhubctl administers a DawHub installation from the command line.
+// It reads the same configuration as the server.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"dawhub/internal/config"
+	"dawhub/internal/domain"
+	"dawhub/internal/migrate"
+	"dawhub/internal/repository"
+)
+
+const usage = `Usage: dawhubctl [-json] <command> [arguments]
+
+Commands:
+  users list
+  users create [-admin] <username> <email> [password]
+  users disable|enable <username>
+  users promote|demote <username>
+  users reset-password <username> [password]
+  projects list [-user username]
+  projects transfer <project id> <username>
+  projects recompute-sizes [project id...]
+  storage verify [-deep]
+  backup create [-prune] [-allow-partial]
+  backup list
+  backup verify [-deep] [snapshot]
+  backup prune [-dry-run]
+  backup restore [snapshot]
+  migrate status
+  migrate up
+  migrate down [-steps n]
+  beta list [-uninvited]
+  beta invite [-no-email] [email...]
+
+Passwords left out are generated and printed. Backups go to BACKUP_TARGET,
+a directory or s3://bucket/prefix. Restore needs an empty, migrated database
+and defaults to the latest snapshot.
+`
+
+// command runs a subcommand with the arguments following its name
+type command func(ctl *ctl, args []string) error
+
+var commands = map[string]map[string]command{
+	"users": {
+		"list":           listUsers,
+		"create":         createUser,
+		"disable":        setUserDisabled(true),
+		"enable":         setUserDisabled(false),
+		"promote":        setUserAdmin(true),
+		"demote":         setUserAdmin(false),
+		"reset-password": resetPassword,
+	},
+	"projects": {
+		"list":            listProjects,
+		"transfer":        transferProject,
+		"recompute-sizes": recomputeSizes,
+	},
+	"storage": {
+		"verify": verifyStorage,
+	},
+	"backup": {
+		"create":  createBackup,
+		"list":    listBackups,
+		"verify":  verifyBackup,
+		"prune":   pruneBackups,
+		"restore": restoreBackup,
+	},
+	"migrate": {
+		"status": migrationStatus,
+		"up":     migrateUp,
+		"down":   migrateDown,
+	},
+	"beta": {
+		"list":   listBetaUsers,
+		"invite": inviteBetaUsers,
+	},
+}
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	asJSON := flag.Bool("json", false, "print JSON instead of tables")
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	run, ok := commands[flag.Arg(0)][flag.Arg(1)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "dawhubctl: unknown command %q\n\n", strings.Join(flag.Args()[:2], " "))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fatal(fmt.Errorf("failed to load config: %w", err))
+	}
+
+	ctl := &ctl{cfg: cfg, out: os.Stdout, json: *asJSON}
+	if err := run(ctl, flag.Args()[2:]); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "dawhubctl:", err)
+	os.Exit(1)
+}
+
+// ctl carries what every command needs
+type ctl struct {
+	cfg  *config.Config
+	out  io.Writer
+	json bool
+	db   *gorm.DB
+}
+
+// database connects on first use. Everything but the migrate commands needs
+// the schema to be current, since they'd otherwise read columns that aren't
+// there yet.
+func (c *ctl) database(requireCurrent bool) (*gorm.DB, error) {
+	if c.db == nil {
+		db, err := repository.Open(c.cfg.DB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		// The server's query log would end up mixed into the output
+		c.db = db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)})
+	}
+	if !requireCurrent {
+		return c.db, nil
+	}
+
+	migrator, err := migrate.New(c.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.Verify(); err != nil {
+		if errors.Is(err, migrate.ErrPending) {
+			return nil, fmt.Errorf("%w (run dawhubctl migrate up)", err)
+		}
+		return nil, err
+	}
+	return c.db, nil
+}
+
+func (c *ctl) users() (*repository.UserRepository, error) {
+	db, err := c.database(true)
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewUserRepository(db), nil
+}
+
+func (c *ctl) projects() (domain.ProjectRepository, error) {
+	db, err := c.database(true)
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewProjectRepository(db), nil
+}
+
+// timeFormat is how times appear in tables
+const timeFormat = "2006-01-02 15:04"
+
+// print writes v as JSON with -json, otherwise the header and rows as an
+// aligned table
+func (c *ctl) print(v interface{}, header []string, rows [][]string) error {
+	if c.json {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// parseFlags parses a subcommand's flags, which come before its arguments
+func parseFlags(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() < min || (max >= 0 && fs.NArg() > max) {
+		return nil, fmt.Errorf("wrong number of arguments\n\n%s", usage)
+	}
+	return fs.Args(), nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"dawhub/internal/migrate"
+)
+
+// migrationRow is a migration as printed, without its SQL
+type migrationRow struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+func migrationStatus(ctl *ctl, args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("migrate status", flag.ContinueOnError), args, 0, 0); err != nil {
+		return err
+	}
+	migrator, err := ctl.migrator()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	migrations := make([]migrationRow, len(statuses))
+	for i, status := range statuses {
+		migrations[i] = migrationRow{Version: status.Version, Name: status.Name, AppliedAt: status.AppliedAt}
+	}
+	return ctl.printMigrations(migrations)
+}
+
+func migrateUp(ctl *ctl, args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("migrate up", flag.ContinueOnError), args, 0, 0); err != nil {
+		return err
+	}
+	migrator, err := ctl.migrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	// Print what did get applied even if a later migration failed
+	migrations := make([]migrationRow, len(applied))
+	now := time.Now()
+	for i, migration := range applied {
+		migrations[i] = migrationRow{Version: migration.Version, Name: migration.Name, AppliedAt: &now}
+	}
+	if printErr := ctl.printMigrations(migrations); printErr != nil && err == nil {
+		err = printErr
+	}
+	return err
+}
+
+func migrateDown(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return fmt.Errorf("-steps must be at least 1")
+	}
+	migrator, err := ctl.migrator()
+	if err != nil {
+		return err
+	}
+
+	reverted, err := migrator.Down(*steps)
+	migrations := make([]migrationRow, len(reverted))
+	for i, migration := range reverted {
+		migrations[i] = migrationRow{Version: migration.Version, Name: migration.Name}
+	}
+	if printErr := ctl.printMigrations(migrations); printErr != nil && err == nil {
+		err = printErr
+	}
+	return err
+}
+
+// migrator works on databases whatever their schema version
+func (c *ctl) migrator() (*migrate.Migrator, error) {
+	db, err := c.database(false)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db)
+}
+
+func (c *ctl) printMigrations(migrations []migrationRow) error {
+	rows := make([][]string, len(migrations))
+	for i, migration := range migrations {
+		applied := "pending"
+		if migration.AppliedAt != nil {
+			applied = migration.AppliedAt.Format(timeFormat)
+		}
+		rows[i] = []string{strconv.FormatInt(migration.Version, 10), migration.Name, applied}
+	}
+	return c.print(migrations, []string{"VERSION", "NAME", "APPLIED"}, rows)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+)
+
+// sizeChange is a project's stored total size before and after recomputing it
+type sizeChange struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+}
+
+func listProjects(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("projects list", flag.ContinueOnError)
+	owner := fs.String("user", "", "only list projects owned by this username")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	repo, err := ctl.projects()
+	if err != nil {
+		return err
+	}
+
+	filters := []func(*gorm.DB) *gorm.DB{func(db *gorm.DB) *gorm.DB {
+		return db.Preload("User").Order("id")
+	}}
+	if *owner != "" {
+		users, err := ctl.users()
+		if err != nil {
+			return err
+		}
+		user, err := findUser(users, *owner)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where("user_id = ?", user.ID)
+		})
+	}
+
+	projects, err := repo.FindAll(filters...)
+	if err != nil {
+		return err
+	}
+	return ctl.printProjects(projects)
+}
+
+func transferProject(ctl *ctl, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("projects transfer", flag.ContinueOnError), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	projectID, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	users, err := ctl.users()
+	if err != nil {
+		return err
+	}
+	repo, err := ctl.projects()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(users, args[1])
+	if err != nil {
+		return err
+	}
+	if err := repo.TransferProject(projectID, user.ID); err != nil {
+		return fmt.Errorf("project %d: %w", projectID, err)
+	}
+
+	project, err := repo.FindByID(projectID)
+	if err != nil {
+		return err
+	}
+	project.User = *user
+	return ctl.printProjects([]domain.Project{*project})
+}
+
+// recomputeSizes fixes Project.TotalSize for the given projects, or for all
+// of them
+func recomputeSizes(ctl *ctl, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("projects recompute-sizes", flag.ContinueOnError), args, 0, -1)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, len(args))
+	for i, arg := range args {
+		if ids[i], err = parseID(arg); err != nil {
+			return err
+		}
+	}
+	repo, err := ctl.projects()
+	if err != nil {
+		return err
+	}
+
+	projects, err := repo.FindAll(func(db *gorm.DB) *gorm.DB {
+		if len(ids) > 0 {
+			db = db.Where("id IN ?", ids)
+		}
+		return db.Order("id")
+	})
+	if err != nil {
+		return err
+	}
+	if len(projects) < len(ids) {
+		return fmt.Errorf("only %d of %d projects found", len(projects), len(ids))
+	}
+
+	changes := make([]sizeChange, 0, len(projects))
+	rows := make([][]string, 0, len(projects))
+	for _, project := range projects {
+		size, err := repo.RecalculateSize(project.ID)
+		if err != nil {
+			return fmt.Errorf("project %d: %w", project.ID, err)
+		}
+		changes = append(changes, sizeChange{ID: project.ID, Name: project.Name, Before: project.TotalSize, After: size})
+
+		status := "unchanged"
+		if size != project.TotalSize {
+			status = "fixed"
+		}
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(project.ID), 10),
+			project.Name,
+			domain.FormatFileSize(project.TotalSize),
+			domain.FormatFileSize(size),
+			status,
+		})
+	}
+	return ctl.print(changes, []string{"ID", "NAME", "BEFORE", "AFTER", "STATUS"}, rows)
+}
+
+func parseID(arg string) (uint, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid project ID %q", arg)
+	}
+	return uint(id), nil
+}
+
+func (c *ctl) printProjects(projects []domain.Project) error {
+	rows := make([][]string, len(projects))
+	for i, project := range projects {
+		rows[i] = []string{
+			strconv.FormatUint(uint64(project.ID), 10),
+			project.Name,
+			project.User.Username,
+			strconv.FormatBool(project.IsPublic),
+			domain.FormatFileSize(project.TotalSize),
+			project.UpdatedAt.Format(timeFormat),
+		}
+	}
+	if projects == nil {
+		projects = []domain.Project{}
+	}
+	return c.print(projects, []string{"ID", "NAME", "OWNER", "PUBLIC", "SIZE", "UPDATED"}, rows)
+}
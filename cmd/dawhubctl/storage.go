@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"dawhub/internal/gc"
+)
+
+// errIntegrity makes storage verify exit non-zero after printing its report
+var errIntegrity = errors.New("storage integrity check failed")
+
+func verifyStorage(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("storage verify", flag.ContinueOnError)
+	deep := fs.Bool("deep", false, "download every object and check its hash")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	repo, err := ctl.projects()
+	if err != nil {
+		return err
+	}
+	store, err := ctl.storage()
+	if err != nil {
+		return err
+	}
+
+	report, err := gc.NewVerifier(repo, store).Run(*deep)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(report.Problems))
+	for i, problem := range report.Problems {
+		rows[i] = []string{problem.Path, problem.Problem}
+	}
+	if err := ctl.print(report, []string{"PATH", "PROBLEM"}, rows); err != nil {
+		return err
+	}
+	if !ctl.json {
+		fmt.Fprintf(os.Stderr, "Checked %d objects, %d problems\n", report.Checked, len(report.Problems))
+	}
+	if len(report.Problems) > 0 {
+		return errIntegrity
+	}
+	return nil
+}
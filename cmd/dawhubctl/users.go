@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"dawhub/internal/domain"
+	"dawhub/internal/repository"
+)
+
+// credentials is printed when a password is set, so that generated ones can
+// be handed to the user
+type credentials struct {
+	User     *domain.User `json:"user"`
+	Password string       `json:"password,omitempty"` // Only when generated
+}
+
+func listUsers(ctl *ctl, args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("users list", flag.ContinueOnError), args, 0, 0); err != nil {
+		return err
+	}
+	repo, err := ctl.users()
+	if err != nil {
+		return err
+	}
+
+	users, err := repo.List()
+	if err != nil {
+		return err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return ctl.printUsers(users)
+}
+
+func createUser(ctl *ctl, args []string) error {
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	admin := fs.Bool("admin", false, "grant administrator rights")
+	args, err := parseFlags(fs, args, 2, 3)
+	if err != nil {
+		return err
+	}
+	repo, err := ctl.users()
+	if err != nil {
+		return err
+	}
+
+	user := domain.User{Username: args[0], Email: args[1], IsAdmin: *admin}
+	if _, err := repo.GetByUsername(user.Username); err == nil {
+		return fmt.Errorf("username %s is already taken", user.Username)
+	}
+	if _, err := repo.GetByEmail(user.Email); err == nil {
+		return fmt.Errorf("email %s already has an account", user.Email)
+	}
+
+	password, generated, err := passwordArg(args, 2)
+	if err != nil {
+		return err
+	}
+	user.Password = password
+	if err := user.HashPassword(); err != nil {
+		return err
+	}
+	if err := repo.Create(&user); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return ctl.printCredentials(&user, password, generated)
+}
+
+func resetPassword(ctl *ctl, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("users reset-password", flag.ContinueOnError), args, 1, 2)
+	if err != nil {
+		return err
+	}
+	repo, err := ctl.users()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(repo, args[0])
+	if err != nil {
+		return err
+	}
+	password, generated, err := passwordArg(args, 1)
+	if err != nil {
+		return err
+	}
+	user.Password = password
+	if err := user.HashPassword(); err != nil {
+		return err
+	}
+	if err := repo.Update(user); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return ctl.printCredentials(user, password, generated)
+}
+
+func setUserDisabled(disabled bool) command {
+	return func(ctl *ctl, args []string) error {
+		return updateUser(ctl, args, func(repo *repository.UserRepository, id uint) error {
+			return repo.SetDisabled(id, disabled)
+		})
+	}
+}
+
+func setUserAdmin(admin bool) command {
+	return func(ctl *ctl, args []string) error {
+		return updateUser(ctl, args, func(repo *repository.UserRepository, id uint) error {
+			return repo.SetAdmin(id, admin)
+		})
+	}
+}
+
+// updateUser applies a change to the user named by the only argument and
+// prints the result
+func updateUser(ctl *ctl, args []string, apply func(repo *repository.UserRepository, id uint) error) error {
+	args, err := parseFlags(flag.NewFlagSet("users", flag.ContinueOnError), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	repo, err := ctl.users()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(repo, args[0])
+	if err != nil {
+		return err
+	}
+	if err := apply(repo, user.ID); err != nil {
+		return err
+	}
+	if user, err = repo.GetByID(user.ID); err != nil {
+		return err
+	}
+	return ctl.printUsers([]domain.User{*user})
+}
+
+func findUser(repo *repository.UserRepository, username string) (*domain.User, error) {
+	user, err := repo.GetByUsername(username)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", username, err)
+	}
+	return user, nil
+}
+
+// passwordArg returns the password given at args[i], or a random one
+func passwordArg(args []string, i int) (string, bool, error) {
+	if len(args) > i {
+		return args[i], false, nil
+	}
+
+	secret := make([]byte, 12)
+	if _, err := rand.Read(secret); err != nil {
+		return "", false, err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), true, nil
+}
+
+func (c *ctl) printUsers(users []domain.User) error {
+	rows := make([][]string, len(users))
+	for i, user := range users {
+		disabled := ""
+		if user.DisabledAt != nil {
+			disabled = user.DisabledAt.Format(timeFormat)
+		}
+		rows[i] = []string{
+			strconv.FormatUint(uint64(user.ID), 10),
+			user.Username,
+			user.Email,
+			strconv.FormatBool(user.IsAdmin),
+			disabled,
+			user.CreatedAt.Format(timeFormat),
+		}
+	}
+	if users == nil {
+		users = []domain.User{}
+	}
+	return c.print(users, []string{"ID", "USERNAME", "EMAIL", "ADMIN", "DISABLED", "CREATED"}, rows)
+}
+
+func (c *ctl) printCredentials(user *domain.User, password string, generated bool) error {
+	result := credentials{User: user}
+	if generated {
+		result.Password = password
+	} else {
+		password = "(as given)"
+	}
+	return c.print(result, []string{"ID", "USERNAME", "EMAIL", "PASSWORD"}, [][]string{{
+		strconv.FormatUint(uint64(user.ID), 10),
+		user.Username,
+		user.Email,
+		password,
+	}})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"dawhub/internal/config"
+	"dawhub/internal/domain"
+	"dawhub/internal/gc"
+	"dawhub/internal/repository"
+	"dawhub/internal/storage"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report orphaned objects without deleting them")
+	grace := flag.Duration("grace", 0, "only collect objects older than this (defaults to GC_GRACE_PERIOD)")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	if *grace == 0 {
+		*grace = cfg.GC.GracePeriod
+	}
+
+	db, err := repository.NewDB(cfg.DB)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+
+	store, _, err := storage.New(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	collector := gc.NewCollector(repository.NewProjectRepository(db), store, *grace, cfg.Upload.SessionTTL)
+	report, err := collector.Run(*dryRun)
+	if err != nil {
+		log.Fatal("Garbage collection failed:", err)
+	}
+
+	for _, obj := range report.Orphaned {
+		fmt.Printf("%s\t%s\t%s\n", obj.Path, domain.FormatFileSize(obj.Size), obj.LastModified.Format("2006-01-02 15:04"))
+	}
+	if report.DryRun {
+		fmt.Printf("Scanned %d objects, %d orphaned (dry run, nothing deleted)\n", report.Scanned, len(report.Orphaned))
+		return
+	}
+	fmt.Printf("Scanned %d objects, deleted %d (%s reclaimed)\n",
+		report.Scanned, report.Deleted, domain.FormatFileSize(report.ReclaimedBytes))
+}
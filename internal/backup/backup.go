@@ -0,0 +1,677 @@
+// Package backup takes consistent backups of the database together with
+// the stored objects it references, and restores them.
+//
+// A target holds any number of snapshots sharing one pool of objects:
+//
+//	snapshots/<id>.json       manifest, written last
+//	database/<id>.jsonl.gz    every table as JSON lines
+//	objects/<hh>/<hash>       object contents, keyed by SHA-256
+//
+// Objects are content-addressed, so each snapshot only copies objects the
+// target doesn't already have.
+package backup
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+	"dawhub/internal/migrate"
+)
+
+// ErrSnapshotNotFound is returned for snapshot IDs the target doesn't have
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ErrIncomplete is returned when referenced objects couldn't be backed up and
+// a partial snapshot wasn't allowed
+var ErrIncomplete = errors.New("snapshot is incomplete")
+
+// idFormat names snapshots by their UTC creation time, so they sort by age
+const idFormat = "20060102T150405Z"
+
+const (
+	snapshotPrefix = "snapshots/"
+	databasePrefix = "database/"
+	objectPrefix   = "objects/"
+)
+
+// Manifest describes one snapshot
+type Manifest struct {
+	ID            string           `json:"id"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Dialect       string           `json:"dialect"`
+	SchemaVersion int64            `json:"schema_version"`
+	Database      File             `json:"database"`
+	Tables        map[string]int64 `json:"tables"` // Rows per table
+	Objects       []Object         `json:"objects"`
+	Skipped       []Problem        `json:"skipped,omitempty"` // Referenced objects that couldn't be backed up
+
+	// What this snapshot added to the target; the rest was already there
+	Copied      int   `json:"copied"`
+	CopiedBytes int64 `json:"copied_bytes"`
+}
+
+// File is a file in the target along with its checksum
+type File struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash"`
+}
+
+// Object is a stored object referenced by the database
+type Object struct {
+	Path        string `json:"path"` // Key in storage
+	Hash        string `json:"hash"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// Size is the total size of the snapshot's database export and objects
+func (m *Manifest) Size() int64 {
+	size := m.Database.Size
+	for _, obj := range m.Objects {
+		size += obj.Size
+	}
+	return size
+}
+
+// RestoreReport summarizes a restore
+type RestoreReport struct {
+	Snapshot string           `json:"snapshot"`
+	Rows     map[string]int64 `json:"rows"`
+	Objects  int              `json:"objects"`  // Objects copied into storage
+	Existing int              `json:"existing"` // Objects storage already had
+}
+
+// Problem is something wrong with a snapshot
+type Problem struct {
+	Key     string `json:"key"`
+	Problem string `json:"problem"`
+}
+
+// VerifyReport summarizes a verification pass over one or more snapshots
+type VerifyReport struct {
+	Snapshots int       `json:"snapshots"`
+	Objects   int       `json:"objects"`
+	Deep      bool      `json:"deep"`
+	Problems  []Problem `json:"problems"`
+}
+
+// Archive reads and writes snapshots in a target
+type Archive struct {
+	target Target
+	now    func() time.Time
+}
+
+// NewArchive creates an archive over the given target
+func NewArchive(target Target) *Archive {
+	return &Archive{
+		target: target,
+		now:    time.Now,
+	}
+}
+
+// Target returns where the archive lives
+func (a *Archive) Target() Target {
+	return a.target
+}
+
+// Snapshots returns every complete snapshot, oldest first
+func (a *Archive) Snapshots() ([]Manifest, error) {
+	keys, err := a.target.List(snapshotPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make([]Manifest, 0, len(keys))
+	for _, obj := range keys {
+		id, ok := snapshotID(obj.Key)
+		if !ok {
+			continue
+		}
+		manifest, err := a.readManifest(id)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].ID < manifests[j].ID })
+	return manifests, nil
+}
+
+// Snapshot returns the manifest for id, or the newest snapshot when id is
+// empty or "latest"
+func (a *Archive) Snapshot(id string) (*Manifest, error) {
+	if id != "" && id != "latest" {
+		return a.readManifest(id)
+	}
+
+	keys, err := a.target.List(snapshotPrefix)
+	if err != nil {
+		return nil, err
+	}
+	latest := ""
+	for _, obj := range keys {
+		if id, ok := snapshotID(obj.Key); ok && id > latest {
+			latest = id
+		}
+	}
+	if latest == "" {
+		return nil, fmt.Errorf("%w: %s has no snapshots", ErrSnapshotNotFound, a.target)
+	}
+	return a.readManifest(latest)
+}
+
+// Backup takes a snapshot: the database is exported in a single read-only
+// transaction and every object it references is copied unless the target
+// already has it. Objects are copied once before the export too, which keeps
+// most of those deleted while it runs, but not all.
+//
+// Objects that are lost or damaged are listed in Skipped. Unless
+// allowPartial is set, such a snapshot isn't written and ErrIncomplete is
+// returned along with the manifest describing what was missing. The manifest
+// goes last, so a failed or interrupted backup leaves no snapshot, only
+// objects the next one can reuse.
+func (a *Archive) Backup(db *gorm.DB, storage domain.StorageService, allowPartial bool) (*Manifest, error) {
+	createdAt := a.now().UTC().Truncate(time.Second)
+	manifest := &Manifest{
+		ID:        createdAt.Format(idFormat),
+		CreatedAt: createdAt,
+		Dialect:   db.Dialector.Name(),
+	}
+	if _, err := a.target.Stat(manifestKey(manifest.ID)); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", manifest.ID)
+	}
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.Verify(); err != nil {
+		return nil, err
+	}
+	if migrations := migrator.Migrations(); len(migrations) > 0 {
+		manifest.SchemaVersion = migrations[len(migrations)-1].Version
+	}
+
+	precopied, err := a.precopyObjects(db, storage, manifest)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := a.backupDatabase(db, manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.backupObjects(storage, objects, precopied, manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Skipped) > 0 && !allowPartial {
+		return manifest, fmt.Errorf("%w: %d objects could not be backed up", ErrIncomplete, len(manifest.Skipped))
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := a.target.Put(manifestKey(manifest.ID), strings.NewReader(string(data)), int64(len(data))); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// backupDatabase exports the database to a temporary file, since the target
+// may need the size up front, then stores it
+func (a *Archive) backupDatabase(db *gorm.DB, manifest *Manifest) (map[string]Object, error) {
+	tmp, err := os.CreateTemp("", "dawhub-backup-*.jsonl.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(tmp, hasher))
+	exported, err := exportDatabase(db, gz)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export database: %w", err)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	manifest.Database = File{
+		Key:  databasePrefix + manifest.ID + ".jsonl.gz",
+		Size: size,
+		Hash: hex.EncodeToString(hasher.Sum(nil)),
+	}
+	manifest.Tables = exported.Tables
+	if err := a.target.Put(manifest.Database.Key, tmp, size); err != nil {
+		return nil, err
+	}
+	return exported.Objects, nil
+}
+
+// precopyObjects copies every object the database references before it is
+// exported, so one purged from the trash or discarded while the export runs
+// is usually safe already. That is only a head start: a file uploaded and
+// then purged in between, or one reached through a purge that skips the
+// grace period, can still be gone by the time backupObjects looks for it.
+// Objects that can't be read are left for backupObjects to report, as the
+// export may no longer reference them.
+func (a *Archive) precopyObjects(db *gorm.DB, storage domain.StorageService, manifest *Manifest) (map[string]Object, error) {
+	objects, err := referencedObjects(db)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := storedPaths(storage)
+	if err != nil {
+		return nil, err
+	}
+
+	precopied := make(map[string]Object, len(objects))
+	for _, obj := range sortedObjects(objects) {
+		if !stored[obj.Path] {
+			continue
+		}
+		copied, err := a.copyObject(storage, obj)
+		if errors.Is(err, domain.ErrInvalidHash) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if copied {
+			manifest.Copied++
+			manifest.CopiedBytes += obj.Size
+		}
+		precopied[obj.Path] = obj
+	}
+	return precopied, nil
+}
+
+// backupObjects records every object the export references, copying those
+// precopyObjects didn't
+func (a *Archive) backupObjects(storage domain.StorageService, objects, precopied map[string]Object, manifest *Manifest) error {
+	stored, err := storedPaths(storage)
+	if err != nil {
+		return err
+	}
+
+	// A lost or damaged object is recorded rather than failing the backup,
+	// so one bad file can't stop the database from being backed up
+	manifest.Objects = make([]Object, 0, len(objects))
+	for _, obj := range sortedObjects(objects) {
+		if precopied[obj.Path] == obj {
+			manifest.Objects = append(manifest.Objects, obj)
+			continue
+		}
+		if !stored[obj.Path] {
+			manifest.Skipped = append(manifest.Skipped, Problem{Key: obj.Path, Problem: "missing from storage"})
+			continue
+		}
+
+		copied, err := a.copyObject(storage, obj)
+		if errors.Is(err, domain.ErrInvalidHash) {
+			manifest.Skipped = append(manifest.Skipped, Problem{Key: obj.Path, Problem: "content doesn't match its hash"})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if copied {
+			manifest.Copied++
+			manifest.CopiedBytes += obj.Size
+		}
+		manifest.Objects = append(manifest.Objects, obj)
+	}
+	return nil
+}
+
+// storedPaths returns the path of every object in storage
+func storedPaths(storage domain.StorageService) (map[string]bool, error) {
+	listed, err := storage.ListFiles("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage: %w", err)
+	}
+	stored := make(map[string]bool, len(listed))
+	for _, obj := range listed {
+		stored[obj.Path] = true
+	}
+	return stored, nil
+}
+
+// sortedObjects orders objects by path, so they are copied and listed in the
+// manifest the same way every time
+func sortedObjects(objects map[string]Object) []Object {
+	sorted := make([]Object, 0, len(objects))
+	for _, obj := range objects {
+		sorted = append(sorted, obj)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	return sorted
+}
+
+// copyObject stores one object in the target unless it's already there. It
+// reports whether it copied anything.
+func (a *Archive) copyObject(storage domain.StorageService, obj Object) (bool, error) {
+	key, err := objectKey(obj.Hash)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", obj.Path, err)
+	}
+	if existing, err := a.target.Stat(key); err == nil && existing.Size == obj.Size {
+		return false, nil
+	}
+
+	reader, _, err := storage.GetFile(obj.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", obj.Path, err)
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	if err := a.target.Put(key, io.TeeReader(reader, hasher), obj.Size); err != nil {
+		return false, fmt.Errorf("failed to copy %s: %w", obj.Path, err)
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != obj.Hash {
+		a.target.Delete(key)
+		return false, fmt.Errorf("failed to copy %s: %w", obj.Path, domain.ErrInvalidHash)
+	}
+	return true, nil
+}
+
+// Restore loads a snapshot into an empty, fully migrated database and the
+// given storage. Every object and the database export are checked against
+// their hashes on the way in; storage keeps objects it already has.
+func (a *Archive) Restore(id string, db *gorm.DB, storage domain.StorageService) (*RestoreReport, error) {
+	manifest, err := a.Snapshot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		return nil, err
+	}
+	if migrations := migrator.Migrations(); len(migrations) == 0 || manifest.SchemaVersion > migrations[len(migrations)-1].Version {
+		return nil, fmt.Errorf("snapshot %s has schema version %d, newer than this build", manifest.ID, manifest.SchemaVersion)
+	}
+	if err := migrator.Verify(); err != nil {
+		return nil, err
+	}
+	populated, err := populatedTables(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(populated) > 0 {
+		return nil, fmt.Errorf("refusing to restore over existing data in %s", strings.Join(populated, ", "))
+	}
+
+	// Fetch the export first so a damaged one fails before storage is touched
+	dump, err := a.fetchDatabase(manifest)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(dump.Name())
+	defer dump.Close()
+
+	report := &RestoreReport{Snapshot: manifest.ID}
+	if err := a.restoreObjects(storage, manifest, report); err != nil {
+		return report, err
+	}
+
+	gz, err := gzip.NewReader(dump)
+	if err != nil {
+		return report, fmt.Errorf("failed to read database export: %w", err)
+	}
+	defer gz.Close()
+	if report.Rows, err = importDatabase(db, gz); err != nil {
+		return report, fmt.Errorf("failed to restore database: %w", err)
+	}
+	return report, nil
+}
+
+// fetchDatabase downloads a snapshot's database export to a temporary file
+// and checks it against the manifest
+func (a *Archive) fetchDatabase(manifest *Manifest) (*os.File, error) {
+	reader, err := a.target.Get(manifest.Database.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp("", "dawhub-restore-*.jsonl.gz")
+	if err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), reader)
+	if err == nil {
+		err = checkFile(manifest.Database, size, hex.EncodeToString(hasher.Sum(nil)))
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("database export: %w", err)
+	}
+	return tmp, nil
+}
+
+func (a *Archive) restoreObjects(storage domain.StorageService, manifest *Manifest, report *RestoreReport) error {
+	listed, err := storage.ListFiles("")
+	if err != nil {
+		return fmt.Errorf("failed to list storage: %w", err)
+	}
+	stored := make(map[string]int64, len(listed))
+	for _, obj := range listed {
+		stored[obj.Path] = obj.Size
+	}
+
+	for _, obj := range manifest.Objects {
+		if size, ok := stored[obj.Path]; ok && size == obj.Size {
+			report.Existing++
+			continue
+		}
+		if err := a.restoreObject(storage, obj); err != nil {
+			return err
+		}
+		stored[obj.Path] = obj.Size
+		report.Objects++
+	}
+	return nil
+}
+
+// restoreObject imports one object; storage checks the hash as it writes
+func (a *Archive) restoreObject(storage domain.StorageService, obj Object) error {
+	key, err := objectKey(obj.Hash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", obj.Path, err)
+	}
+	reader, err := a.target.Get(key)
+	if err != nil {
+		return fmt.Errorf("failed to read %s from backup: %w", obj.Path, err)
+	}
+	defer reader.Close()
+
+	path, err := storage.ImportFile(domain.FileMetadata{
+		Size:        obj.Size,
+		Filename:    obj.Path,
+		ContentType: obj.ContentType,
+		Hash:        obj.Hash,
+	}, reader)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", obj.Path, err)
+	}
+	if path != obj.Path {
+		return fmt.Errorf("restored %s as %s; storage layouts differ", obj.Path, path)
+	}
+	return nil
+}
+
+// Verify checks that a snapshot, or every snapshot when id is empty, is
+// complete: the manifest reads, and the database export and each object are
+// present with the recorded size. Deep mode downloads everything to check
+// hashes too. Objects shared between snapshots are checked once.
+func (a *Archive) Verify(id string, deep bool) (VerifyReport, error) {
+	report := VerifyReport{Deep: deep, Problems: []Problem{}}
+
+	var manifests []*Manifest
+	if id != "" {
+		manifest, err := a.Snapshot(id)
+		if err != nil {
+			return report, err
+		}
+		manifests = append(manifests, manifest)
+	} else {
+		keys, err := a.target.List(snapshotPrefix)
+		if err != nil {
+			return report, err
+		}
+		for _, obj := range keys {
+			id, ok := snapshotID(obj.Key)
+			if !ok {
+				continue
+			}
+			manifest, err := a.readManifest(id)
+			if err != nil {
+				report.Problems = append(report.Problems, Problem{Key: obj.Key, Problem: err.Error()})
+				continue
+			}
+			manifests = append(manifests, manifest)
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, manifest := range manifests {
+		report.Snapshots++
+		for _, skipped := range manifest.Skipped {
+			report.Problems = append(report.Problems, Problem{Key: skipped.Key, Problem: "not in snapshot " + manifest.ID + ": " + skipped.Problem})
+		}
+		if problem := a.inspect(manifest.Database, deep); problem != "" {
+			report.Problems = append(report.Problems, Problem{Key: manifest.Database.Key, Problem: problem})
+		}
+
+		for _, obj := range manifest.Objects {
+			key, err := objectKey(obj.Hash)
+			if err != nil {
+				report.Problems = append(report.Problems, Problem{Key: obj.Path, Problem: err.Error()})
+				continue
+			}
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+			report.Objects++
+			if problem := a.inspect(File{Key: key, Size: obj.Size, Hash: obj.Hash}, deep); problem != "" {
+				report.Problems = append(report.Problems, Problem{Key: key, Problem: problem})
+			}
+		}
+	}
+	return report, nil
+}
+
+// inspect describes what's wrong with a file in the target, if anything
+func (a *Archive) inspect(file File, deep bool) string {
+	info, err := a.target.Stat(file.Key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "missing from backup"
+	}
+	if err != nil {
+		return "unreadable: " + err.Error()
+	}
+	if info.Size != file.Size {
+		return fmt.Sprintf("size is %d, expected %d", info.Size, file.Size)
+	}
+	if !deep {
+		return ""
+	}
+
+	reader, err := a.target.Get(file.Key)
+	if err != nil {
+		return "unreadable: " + err.Error()
+	}
+	defer reader.Close()
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, reader)
+	if err != nil {
+		return "unreadable: " + err.Error()
+	}
+	if err := checkFile(file, size, hex.EncodeToString(hasher.Sum(nil))); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func (a *Archive) readManifest(id string) (*Manifest, error) {
+	reader, err := a.target.Get(manifestKey(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", id, err)
+	}
+	return &manifest, nil
+}
+
+// checkFile compares what was read against what the manifest recorded
+func checkFile(file File, size int64, hash string) error {
+	if size != file.Size {
+		return fmt.Errorf("size is %d, expected %d", size, file.Size)
+	}
+	if hash != file.Hash {
+		return fmt.Errorf("content doesn't match hash %s", file.Hash)
+	}
+	return nil
+}
+
+func manifestKey(id string) string {
+	return snapshotPrefix + id + ".json"
+}
+
+// snapshotID extracts the ID from a manifest key
+func snapshotID(key string) (string, bool) {
+	id, ok := strings.CutPrefix(key, snapshotPrefix)
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(id, ".json")
+}
+
+// objectKey is where an object with the given hash lives in the target
+func objectKey(hash string) (string, error) {
+	if len(hash) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid hash %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil || strings.ToLower(hash) != hash {
+		return "", fmt.Errorf("invalid hash %q", hash)
+	}
+	return objectPrefix + hash[:2] + "/" + hash, nil
+}
@@ -0,0 +1,248 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/config"
+	"dawhub/internal/domain"
+	"dawhub/internal/repository"
+	"dawhub/internal/storage"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := repository.NewDB(config.DBConfig{
+		Driver:  "sqlite",
+		Path:    filepath.Join(t.TempDir(), "dawhub.db"),
+		Migrate: "auto",
+	})
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func newTestStorage(t *testing.T) *storage.LocalStorage {
+	store, err := storage.NewLocalStorage(config.LocalStorageConfig{
+		Root:      t.TempDir(),
+		URLSecret: "test secret",
+		BaseURL:   "http://localhost",
+	})
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	return store
+}
+
+func newTestArchive(t *testing.T) (*Archive, string) {
+	dir := t.TempDir()
+	target, err := NewDirTarget(dir)
+	if err != nil {
+		t.Fatalf("NewDirTarget: %v", err)
+	}
+	return NewArchive(target), dir
+}
+
+// seed creates a project with a main file and two samples, and returns the
+// contents of every object by path
+func seed(t *testing.T, db *gorm.DB, store domain.StorageService) map[string]string {
+	user := domain.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	repo := repository.NewProjectRepository(db)
+	project := &domain.Project{Name: "Demo", UserID: user.ID}
+	if err := repo.Create(project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	contents := map[string]string{}
+	upload := func(name, content string) (domain.FileMetadata, string) {
+		info, path, err := store.UploadFile(name, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("UploadFile %s: %v", name, err)
+		}
+		contents[path] = content
+		return domain.FileMetadata{
+			Size:        info.Size,
+			Filename:    name,
+			ContentType: info.ContentType,
+			Hash:        info.Hash,
+			UploadedAt:  time.Now(),
+		}, path
+	}
+
+	meta, path := upload("song.als", "ableton project")
+	if err := repo.AddMainFile(project.ID, &domain.ProjectFile{FileMetadata: meta, FilePath: path}); err != nil {
+		t.Fatalf("AddMainFile: %v", err)
+	}
+	for name, content := range map[string]string{"kick.wav": "kick drum", "snare.wav": "snare drum"} {
+		meta, path := upload(name, content)
+		if err := repo.AddSampleFile(project.ID, &domain.SampleFile{FileMetadata: meta, FilePath: path}); err != nil {
+			t.Fatalf("AddSampleFile: %v", err)
+		}
+	}
+	return contents
+}
+
+// dump exports the database the way a backup does, for comparing two of them
+func dump(t *testing.T, db *gorm.DB) string {
+	var buf bytes.Buffer
+	if _, err := exportDatabase(db, &buf); err != nil {
+		t.Fatalf("exportDatabase: %v", err)
+	}
+	return buf.String()
+}
+
+func TestBackupRestore(t *testing.T) {
+	db := newTestDB(t)
+	store := newTestStorage(t)
+	contents := seed(t, db, store)
+	archive, _ := newTestArchive(t)
+
+	manifest, err := archive.Backup(db, store, false)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if len(manifest.Objects) != len(contents) || manifest.Copied != len(contents) {
+		t.Errorf("snapshot has %d objects, copied %d; want %d", len(manifest.Objects), manifest.Copied, len(contents))
+	}
+
+	restoredDB := newTestDB(t)
+	restoredStore := newTestStorage(t)
+	report, err := archive.Restore(manifest.ID, restoredDB, restoredStore)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if report.Objects != len(contents) {
+		t.Errorf("restored %d objects, want %d", report.Objects, len(contents))
+	}
+	for table, rows := range manifest.Tables {
+		if report.Rows[table] != rows {
+			t.Errorf("restored %d rows into %s, want %d", report.Rows[table], table, rows)
+		}
+	}
+	if got, want := dump(t, restoredDB), dump(t, db); got != want {
+		t.Errorf("restored database differs:\n got: %s\nwant: %s", got, want)
+	}
+
+	for path, content := range contents {
+		reader, _, err := restoredStore.GetFile(path)
+		if err != nil {
+			t.Errorf("GetFile %s: %v", path, err)
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil || string(data) != content {
+			t.Errorf("restored %s = %q, %v; want %q", path, data, err, content)
+		}
+	}
+
+	// Restoring again must not overwrite what's there
+	if _, err := archive.Restore(manifest.ID, restoredDB, restoredStore); err == nil {
+		t.Error("Restore over existing data succeeded")
+	}
+}
+
+func TestVerifyCorruptedObject(t *testing.T) {
+	db := newTestDB(t)
+	store := newTestStorage(t)
+	seed(t, db, store)
+	archive, dir := newTestArchive(t)
+
+	manifest, err := archive.Backup(db, store, false)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	report, err := archive.Verify(manifest.ID, true)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(report.Problems) > 0 {
+		t.Fatalf("fresh snapshot has problems: %v", report.Problems)
+	}
+
+	// Flip a byte without changing the size, so only a deep check notices
+	key, err := objectKey(manifest.Objects[0].Hash)
+	if err != nil {
+		t.Fatalf("objectKey: %v", err)
+	}
+	name := filepath.Join(dir, filepath.FromSlash(key))
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	data[0] ^= 0xff
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	if report, err := archive.Verify(manifest.ID, false); err != nil || len(report.Problems) > 0 {
+		t.Errorf("shallow Verify = %v, %v; want no problems", report.Problems, err)
+	}
+	report, err = archive.Verify(manifest.ID, true)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Key != key {
+		t.Errorf("deep Verify problems = %v, want one for %s", report.Problems, key)
+	}
+}
+
+func TestBackupMissingObject(t *testing.T) {
+	db := newTestDB(t)
+	store := newTestStorage(t)
+	contents := seed(t, db, store)
+	archive, _ := newTestArchive(t)
+
+	var lost string
+	for path := range contents {
+		lost = path
+		break
+	}
+	if err := store.DeleteFile(lost); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+
+	manifest, err := archive.Backup(db, store, false)
+	if !errors.Is(err, ErrIncomplete) {
+		t.Fatalf("Backup err = %v, want %v", err, ErrIncomplete)
+	}
+	if len(manifest.Skipped) != 1 || manifest.Skipped[0].Key != lost {
+		t.Errorf("skipped = %v, want %s", manifest.Skipped, lost)
+	}
+	if snapshots, err := archive.Snapshots(); err != nil || len(snapshots) != 0 {
+		t.Errorf("Snapshots = %d, %v; want none", len(snapshots), err)
+	}
+
+	// A second later so the snapshot gets its own ID
+	archive.now = func() time.Time { return time.Now().Add(time.Second) }
+	manifest, err = archive.Backup(db, store, true)
+	if err != nil {
+		t.Fatalf("partial Backup: %v", err)
+	}
+	if len(manifest.Objects) != len(contents)-1 || len(manifest.Skipped) != 1 {
+		t.Errorf("partial snapshot has %d objects and %d skipped", len(manifest.Objects), len(manifest.Skipped))
+	}
+	report, err := archive.Verify(manifest.ID, true)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Key != lost {
+		t.Errorf("Verify problems = %v, want the skipped object", report.Problems)
+	}
+}
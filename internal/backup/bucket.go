@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"dawhub/internal/config"
+)
+
+// partSize bounds the memory used per upload of unknown size
+const partSize = 16 * 1024 * 1024
+
+// BucketTarget keeps backups in an S3-compatible bucket, optionally under a
+// prefix. It should be a different bucket from the one being backed up, and
+// ideally on different hardware.
+type BucketTarget struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewBucketTarget connects to the bucket, creating it if needed
+func NewBucketTarget(cfg config.MinioConfig, bucket, prefix string) (*BucketTarget, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minio client: %w", err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket existence: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &BucketTarget{client: client, bucket: bucket, prefix: prefix}, nil
+}
+
+func (t *BucketTarget) Put(key string, reader io.Reader, size int64) error {
+	_, err := t.client.PutObject(context.Background(), t.bucket, t.prefix+key, reader, size, minio.PutObjectOptions{
+		PartSize: partSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+func (t *BucketTarget) Get(key string) (io.ReadCloser, error) {
+	obj, err := t.client.GetObject(context.Background(), t.bucket, t.prefix+key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, t.wrap(key, err)
+	}
+	// GetObject is lazy; surface a missing key now rather than on first read
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, t.wrap(key, err)
+	}
+	return obj, nil
+}
+
+func (t *BucketTarget) Stat(key string) (TargetObject, error) {
+	info, err := t.client.StatObject(context.Background(), t.bucket, t.prefix+key, minio.StatObjectOptions{})
+	if err != nil {
+		return TargetObject{}, t.wrap(key, err)
+	}
+	return TargetObject{Key: key, Size: info.Size, ModTime: info.LastModified}, nil
+}
+
+func (t *BucketTarget) List(prefix string) ([]TargetObject, error) {
+	var objects []TargetObject
+	for obj := range t.client.ListObjects(context.Background(), t.bucket, minio.ListObjectsOptions{
+		Prefix:    t.prefix + prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list backups: %w", obj.Err)
+		}
+		objects = append(objects, TargetObject{
+			Key:     strings.TrimPrefix(obj.Key, t.prefix),
+			Size:    obj.Size,
+			ModTime: obj.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+func (t *BucketTarget) Delete(key string) error {
+	if err := t.client.RemoveObject(context.Background(), t.bucket, t.prefix+key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (t *BucketTarget) String() string {
+	return fmt.Sprintf("s3://%s/%s", t.bucket, t.prefix)
+}
+
+// wrap reports missing keys with fs.ErrNotExist
+func (t *BucketTarget) wrap(key string, err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return fmt.Errorf("%s: %w", key, fs.ErrNotExist)
+	}
+	return fmt.Errorf("failed to read %s: %w", key, err)
+}
@@ -0,0 +1,357 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"dawhub/internal/domain"
+)
+
+// importBatchSize is the number of rows inserted per statement on restore
+const importBatchSize = 500
+
+// projectTag and libraryItemTag are the many2many join tables, which have
+// no models of their own
+type projectTag struct {
+	ProjectID uint `gorm:"primaryKey;autoIncrement:false"`
+	TagID     uint `gorm:"primaryKey;autoIncrement:false"`
+}
+
+func (projectTag) TableName() string {
+	return "project_tags"
+}
+
+type libraryItemTag struct {
+	LibraryItemID uint `gorm:"primaryKey;autoIncrement:false"`
+	TagID         uint `gorm:"primaryKey;autoIncrement:false"`
+}
+
+func (libraryItemTag) TableName() string {
+	return "library_item_tags"
+}
+
+// tables lists every exported table in an order that satisfies foreign keys
+// on restore. Within a table rows go by primary key, so replies follow the
+// comments they answer.
+var tables = []interface{}{
+	&domain.User{},
+	&domain.BetaUser{},
+	&domain.SignupInvite{},
+	&domain.Blob{},
+	&domain.ProjectFile{},
+	&domain.Project{},
+	&domain.SampleFile{},
+	&domain.ProjectRevision{},
+	&domain.RevisionFile{},
+	&domain.ProjectMember{},
+	&domain.ProjectInvite{},
+	&domain.ShareLink{},
+	&domain.Comment{},
+	&domain.ProjectStar{},
+	&domain.Tag{},
+	&projectTag{},
+	&domain.LibraryItem{},
+	&libraryItemTag{},
+}
+
+// skippedTables aren't backed up: the schema version is recorded in the
+// manifest, and half-finished uploads can't be resumed against new storage
+var skippedTables = map[string]bool{
+	"schema_migrations": true,
+	"upload_sessions":   true,
+}
+
+// objectTables lists the tables whose rows point at stored objects
+var objectTables = []interface{}{
+	&domain.ProjectFile{},
+	&domain.SampleFile{},
+	&domain.RevisionFile{},
+	&domain.LibraryItem{},
+}
+
+// record is one line of a database export
+type record struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// export is what exportDatabase saw in its snapshot
+type export struct {
+	Tables  map[string]int64
+	Objects map[string]Object // Every object a row points at, by path
+}
+
+// exportDatabase writes every row as JSON lines, reading all tables in one
+// read-only transaction so the export is a consistent snapshot
+func exportDatabase(db *gorm.DB, w io.Writer) (export, error) {
+	result := export{Tables: make(map[string]int64)}
+	schemas, err := parseTables(db)
+	if err != nil {
+		return result, err
+	}
+	if err := checkCoverage(db, schemas); err != nil {
+		return result, err
+	}
+
+	enc := json.NewEncoder(w)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i, model := range tables {
+			sch := schemas[i]
+			count, err := exportTable(tx, model, sch, enc)
+			if err != nil {
+				return fmt.Errorf("failed to export %s: %w", sch.Table, err)
+			}
+			result.Tables[sch.Table] = count
+		}
+
+		var err error
+		result.Objects, err = referencedObjects(tx)
+		return err
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	return result, err
+}
+
+func exportTable(tx *gorm.DB, model interface{}, sch *schema.Schema, enc *json.Encoder) (int64, error) {
+	query := tx.Unscoped().Model(model)
+	for _, field := range sch.PrimaryFields {
+		query = query.Order(field.DBName)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	ctx := context.Background()
+	var count int64
+	for rows.Next() {
+		ptr := reflect.New(sch.ModelType)
+		if err := tx.ScanRows(rows, ptr.Interface()); err != nil {
+			return count, err
+		}
+
+		columns := make(map[string]interface{}, len(sch.DBNames))
+		for _, name := range sch.DBNames {
+			columns[name], _ = sch.FieldsByDBName[name].ValueOf(ctx, ptr.Elem())
+		}
+		row, err := json.Marshal(columns)
+		if err != nil {
+			return count, err
+		}
+		if err := enc.Encode(record{Table: sch.Table, Row: row}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// referencedObjects returns the objects that file rows point at by path,
+// trashed rows included. Blob rows aren't consulted: files older than them
+// may have none.
+func referencedObjects(db *gorm.DB) (map[string]Object, error) {
+	objects := make(map[string]Object)
+	for _, model := range objectTables {
+		var rows []Object
+		if err := db.Unscoped().Model(model).
+			Select("DISTINCT file_path AS path, hash, size, content_type").
+			Order("path, hash, size, content_type").
+			Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to list referenced objects: %w", err)
+		}
+		// Rows sharing an object may disagree on its content type
+		for _, obj := range rows {
+			if _, ok := objects[obj.Path]; !ok {
+				objects[obj.Path] = obj
+			}
+		}
+	}
+	return objects, nil
+}
+
+// importDatabase loads an export into an empty database in one transaction,
+// so a failed restore leaves nothing behind. Hooks are skipped: the rows
+// already hold the blob reference counts and activity they had.
+func importDatabase(db *gorm.DB, r io.Reader) (map[string]int64, error) {
+	schemas, err := parseTables(db)
+	if err != nil {
+		return nil, err
+	}
+	byTable := make(map[string]*schema.Schema, len(schemas))
+	for _, sch := range schemas {
+		byTable[sch.Table] = sch
+	}
+
+	counts := make(map[string]int64)
+	err = db.Session(&gorm.Session{SkipHooks: true}).Transaction(func(tx *gorm.DB) error {
+		var (
+			sch   *schema.Schema
+			batch reflect.Value // Pointer to a slice of sch.ModelType
+		)
+		flush := func() error {
+			if sch == nil || batch.Elem().Len() == 0 {
+				return nil
+			}
+			if err := tx.Omit(clause.Associations).Create(batch.Interface()).Error; err != nil {
+				return fmt.Errorf("failed to restore %s: %w", sch.Table, err)
+			}
+			counts[sch.Table] += int64(batch.Elem().Len())
+			batch.Elem().SetLen(0)
+			return nil
+		}
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			var rec record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+
+			if sch == nil || rec.Table != sch.Table {
+				if err := flush(); err != nil {
+					return err
+				}
+				if sch = byTable[rec.Table]; sch == nil {
+					return fmt.Errorf("line %d: unknown table %q", line, rec.Table)
+				}
+				batch = reflect.New(reflect.SliceOf(sch.ModelType))
+				batch.Elem().Set(reflect.MakeSlice(batch.Elem().Type(), 0, importBatchSize))
+			}
+
+			row, err := decodeRow(sch, rec.Row)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			batch.Elem().Set(reflect.Append(batch.Elem(), row))
+			if batch.Elem().Len() == importBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		return resetSequences(tx, schemas)
+	})
+	return counts, err
+}
+
+// decodeRow fills a model from its exported columns
+func decodeRow(sch *schema.Schema, data json.RawMessage) (reflect.Value, error) {
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal(data, &columns); err != nil {
+		return reflect.Value{}, err
+	}
+
+	ctx := context.Background()
+	row := reflect.New(sch.ModelType).Elem()
+	for name, raw := range columns {
+		field := sch.FieldsByDBName[name]
+		if field == nil {
+			return reflect.Value{}, fmt.Errorf("unknown column %s.%s", sch.Table, name)
+		}
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("column %s.%s: %w", sch.Table, name, err)
+		}
+		if err := field.Set(ctx, row, value.Elem().Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("column %s.%s: %w", sch.Table, name, err)
+		}
+	}
+	return row, nil
+}
+
+// resetSequences moves Postgres serial sequences past the restored IDs, which
+// were inserted explicitly. SQLite tracks AUTOINCREMENT by itself.
+func resetSequences(tx *gorm.DB, schemas []*schema.Schema) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, sch := range schemas {
+		field := sch.PrioritizedPrimaryField
+		if field == nil || !field.AutoIncrement {
+			continue
+		}
+		err := tx.Exec(fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%s', '%s'), MAX(%s)) FROM %s HAVING MAX(%s) IS NOT NULL",
+			sch.Table, field.DBName, field.DBName, sch.Table, field.DBName,
+		)).Error
+		if err != nil {
+			return fmt.Errorf("failed to reset sequence for %s: %w", sch.Table, err)
+		}
+	}
+	return nil
+}
+
+// populatedTables returns the exported tables that already have rows
+func populatedTables(db *gorm.DB) ([]string, error) {
+	schemas, err := parseTables(db)
+	if err != nil {
+		return nil, err
+	}
+	var populated []string
+	for i, model := range tables {
+		var count int64
+		if err := db.Unscoped().Model(model).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			populated = append(populated, schemas[i].Table)
+		}
+	}
+	return populated, nil
+}
+
+func parseTables(db *gorm.DB) ([]*schema.Schema, error) {
+	schemas := make([]*schema.Schema, len(tables))
+	for i, model := range tables {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		schemas[i] = stmt.Schema
+	}
+	return schemas, nil
+}
+
+// checkCoverage refuses to back up a database with tables this build doesn't
+// know how to export, so a new table can't silently go missing from backups
+func checkCoverage(db *gorm.DB, schemas []*schema.Schema) error {
+	names, err := db.Migrator().GetTables()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(schemas))
+	for _, sch := range schemas {
+		known[sch.Table] = true
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if !known[name] && !skippedTables[name] && !strings.HasPrefix(name, "sqlite_") {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.New("tables not covered by backups: " + strings.Join(unknown, ", "))
+	}
+	return nil
+}
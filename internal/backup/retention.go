@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// pruneGrace protects files newer than this from the sweep, since a backup
+// running right now has written them without a manifest yet
+const pruneGrace = 24 * time.Hour
+
+// Policy says which snapshots to keep. A snapshot is kept if any rule
+// selects it; each period rule keeps the newest snapshot of that many of the
+// most recent UTC days, ISO weeks or months that have one.
+type Policy struct {
+	KeepLast    int `json:"keep_last"`
+	KeepDaily   int `json:"keep_daily"`
+	KeepWeekly  int `json:"keep_weekly"`
+	KeepMonthly int `json:"keep_monthly"`
+}
+
+// PruneReport summarizes a prune
+type PruneReport struct {
+	DryRun         bool     `json:"dry_run"`
+	Kept           []string `json:"kept"`
+	Removed        []string `json:"removed"`
+	DeletedObjects int      `json:"deleted_objects"` // Objects and orphaned exports no longer needed
+	ReclaimedBytes int64    `json:"reclaimed_bytes"`
+}
+
+// Keep returns the IDs of the snapshots the policy keeps
+func (p Policy) Keep(manifests []Manifest) map[string]bool {
+	keep := make(map[string]bool)
+
+	// Newest first
+	newest := make([]Manifest, len(manifests))
+	for i, manifest := range manifests {
+		newest[len(manifests)-1-i] = manifest
+	}
+
+	for i := 0; i < p.KeepLast && i < len(newest); i++ {
+		keep[newest[i].ID] = true
+	}
+
+	periods := []struct {
+		count  int
+		period func(time.Time) string
+	}{
+		{p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, rule := range periods {
+		seen := make(map[string]bool)
+		for _, manifest := range newest {
+			if len(seen) == rule.count {
+				break
+			}
+			period := rule.period(manifest.CreatedAt.UTC())
+			if !seen[period] {
+				seen[period] = true
+				keep[manifest.ID] = true
+			}
+		}
+	}
+	return keep
+}
+
+// Prune removes the snapshots the policy doesn't keep, then deletes objects
+// and database exports that no remaining snapshot needs
+func (a *Archive) Prune(policy Policy, dryRun bool) (PruneReport, error) {
+	report := PruneReport{DryRun: dryRun, Kept: []string{}, Removed: []string{}}
+	if policy.KeepLast <= 0 && policy.KeepDaily <= 0 && policy.KeepWeekly <= 0 && policy.KeepMonthly <= 0 {
+		return report, errors.New("retention policy keeps no snapshots")
+	}
+
+	manifests, err := a.Snapshots()
+	if err != nil {
+		return report, err
+	}
+	keep := policy.Keep(manifests)
+
+	needed := make(map[string]bool)
+	for _, manifest := range manifests {
+		if !keep[manifest.ID] {
+			report.Removed = append(report.Removed, manifest.ID)
+			if !dryRun {
+				// The manifest goes first so a half-pruned snapshot is
+				// never listed
+				if err := a.target.Delete(manifestKey(manifest.ID)); err != nil {
+					return report, err
+				}
+			}
+			continue
+		}
+
+		report.Kept = append(report.Kept, manifest.ID)
+		needed[manifest.Database.Key] = true
+		for _, obj := range manifest.Objects {
+			if key, err := objectKey(obj.Hash); err == nil {
+				needed[key] = true
+			}
+		}
+	}
+
+	cutoff := a.now().Add(-pruneGrace)
+	for _, prefix := range []string{databasePrefix, objectPrefix} {
+		files, err := a.target.List(prefix)
+		if err != nil {
+			return report, err
+		}
+		for _, file := range files {
+			if needed[file.Key] || file.ModTime.After(cutoff) {
+				continue
+			}
+			report.DeletedObjects++
+			report.ReclaimedBytes += file.Size
+			if !dryRun {
+				if err := a.target.Delete(file.Key); err != nil {
+					return report, err
+				}
+			}
+		}
+	}
+	return report, nil
+}
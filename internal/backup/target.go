@@ -0,0 +1,222 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dawhub/internal/config"
+)
+
+// Target is somewhere backups are kept: a directory or a bucket. Keys are
+// slash-separated. Missing keys are reported with fs.ErrNotExist.
+type Target interface {
+	// Put stores a key atomically; readers never see a partial object.
+	// Size is -1 when unknown.
+	Put(key string, reader io.Reader, size int64) error
+	Get(key string) (io.ReadCloser, error)
+	Stat(key string) (TargetObject, error)
+	List(prefix string) ([]TargetObject, error)
+	Delete(key string) error
+	String() string
+}
+
+// TargetObject describes a key stored in a target
+type TargetObject struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// OpenTarget opens the target named by cfg.Backup.Target: s3://bucket/prefix
+// for a bucket, anything else for a local directory. A target sharing space
+// with the configured storage is refused, since the collector would delete
+// backups as orphans and pruning would delete stored objects.
+func OpenTarget(cfg *config.Config) (Target, error) {
+	if cfg.Backup.Target == "" {
+		return nil, errors.New("no backup target configured")
+	}
+
+	if location, ok := strings.CutPrefix(cfg.Backup.Target, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(location, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid backup target %q", cfg.Backup.Target)
+		}
+		if usesMinio(cfg) && bucket == cfg.Minio.Bucket && cfg.Backup.Bucket.Endpoint == cfg.Minio.Endpoint {
+			return nil, fmt.Errorf("backup target %q is the storage bucket", cfg.Backup.Target)
+		}
+		return NewBucketTarget(cfg.Backup.Bucket, bucket, prefix)
+	}
+
+	root := strings.TrimPrefix(cfg.Backup.Target, "file://")
+	if cfg.Storage.Backend == "local" {
+		target, storage := resolveDir(root), resolveDir(cfg.Storage.Local.Root)
+		if isWithin(target, storage) || isWithin(storage, target) {
+			return nil, fmt.Errorf("backup target %q overlaps the storage directory %q", root, cfg.Storage.Local.Root)
+		}
+	}
+	return NewDirTarget(root)
+}
+
+// usesMinio reports whether storage is a bucket, mirroring storage.New
+func usesMinio(cfg *config.Config) bool {
+	return cfg.Storage.Backend == "minio" || cfg.Storage.Backend == ""
+}
+
+// resolveDir returns the absolute form of dir with symlinks followed, as far
+// as it exists yet
+func resolveDir(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.Clean(dir)
+	}
+	// Resolve the deepest existing ancestor, then add back the rest
+	rest := ""
+	for current := abs; ; current = filepath.Dir(current) {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if filepath.Dir(current) == current {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+	}
+}
+
+// isWithin reports whether dir is root or a directory below it
+func isWithin(dir, root string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// DirTarget keeps backups in a local directory, typically a mounted volume
+// on another disk. Writes go to tmp/ first and are renamed into place.
+type DirTarget struct {
+	root string
+}
+
+// NewDirTarget creates the directory if needed
+func NewDirTarget(root string) (*DirTarget, error) {
+	if err := os.MkdirAll(filepath.Join(root, "tmp"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &DirTarget{root: root}, nil
+}
+
+func (t *DirTarget) Put(key string, reader io.Reader, size int64) error {
+	name, err := t.path(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(t.root, "tmp"), "put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, reader)
+	if err == nil && size >= 0 && written != size {
+		err = fmt.Errorf("wrote %d bytes, expected %d", written, size)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (t *DirTarget) Get(key string) (io.ReadCloser, error) {
+	name, err := t.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+func (t *DirTarget) Stat(key string) (TargetObject, error) {
+	name, err := t.path(key)
+	if err != nil {
+		return TargetObject{}, err
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return TargetObject{}, err
+	}
+	return TargetObject{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (t *DirTarget) List(prefix string) ([]TargetObject, error) {
+	var objects []TargetObject
+	err := filepath.WalkDir(t.root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(t.root, name)
+		key := filepath.ToSlash(rel)
+		if entry.IsDir() {
+			if key == "tmp" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, TargetObject{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (t *DirTarget) Delete(key string) error {
+	name, err := t.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (t *DirTarget) String() string {
+	return t.root
+}
+
+// path maps a key to its file, rejecting keys that would escape the root
+func (t *DirTarget) path(key string) (string, error) {
+	if key == "" || path.Clean("/"+key) != "/"+key || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("invalid backup key %q", key)
+	}
+	return filepath.Join(t.root, filepath.FromSlash(key)), nil
+}
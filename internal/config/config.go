@@ -2,15 +2,21 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server ServerConfig
-	DB     DBConfig
-	Minio  MinioConfig
-	Email  ResendConfig
+	Server  ServerConfig
+	DB      DBConfig
+	Minio   MinioConfig
+	Storage StorageConfig
+	Email   ResendConfig
+	GC      GCConfig
+	Upload  UploadConfig
+	Backup  BackupConfig
 }
 
 type ServerConfig struct {
@@ -19,11 +25,15 @@ type ServerConfig struct {
 }
 
 type DBConfig struct {
+	Driver   string // "postgres" or "sqlite"
 	Host     string
 	Port     string
 	User     string
 	Password string
 	Name     string
+	SSLMode  string
+	Path     string // SQLite database file
+	Migrate  string // "auto" or "verify"
 }
 
 type MinioConfig struct {
@@ -34,9 +44,43 @@ type MinioConfig struct {
 	UseSSL    bool
 }
 
+type StorageConfig struct {
+	Backend string // "minio" or "local"
+	Local   LocalStorageConfig
+}
+
+type LocalStorageConfig struct {
+	Root      string // Directory holding the stored files
+	URLSecret string // Signs download and upload URLs
+	BaseURL   string // Public address signed URLs point at; empty gives relative URLs
+}
+
 type ResendConfig struct {
 	APIKey    string
 	FromEmail string
+	BaseURL   string // Public address used for links in emails
+}
+
+type GCConfig struct {
+	Interval       time.Duration // Zero disables the periodic job
+	GracePeriod    time.Duration
+	TrashRetention time.Duration // How long deleted projects and samples can be restored; zero keeps them until purged by hand
+}
+
+type BackupConfig struct {
+	Target string      // Directory, or s3://bucket/prefix for a bucket
+	Bucket MinioConfig // Credentials for bucket targets; the bucket name comes from Target
+
+	// Retention: the newest KeepLast snapshots are kept, plus the newest one
+	// from each of the last KeepDaily days, KeepWeekly weeks and KeepMonthly months
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+}
+
+type UploadConfig struct {
+	SessionTTL time.Duration // How long a resumable upload may stay unfinished
 }
 
 func Load() (*Config, error) {
@@ -44,17 +88,23 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	sessionSecret := getEnv("SESSION_SECRET", "default-secret-key")
+
 	return &Config{
 		Server: ServerConfig{
 			Port:          getEnv("APP_PORT", "8080"),
-			SessionSecret: getEnv("SESSION_SECRET", "default-secret-key"),
+			SessionSecret: sessionSecret,
 		},
 		DB: DBConfig{
+			Driver:   getEnv("DB_DRIVER", "postgres"),
 			Host:     getEnv("DB_HOST", "db"),
 			Port:     getEnv("DB_PORT", "5432"),
 			User:     getEnv("DB_USER", ""),
 			Password: getEnv("DB_PASSWORD", ""),
 			Name:     getEnv("DB_NAME", ""),
+			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Path:     getEnv("DB_PATH", "./data/dawhub.db"),
+			Migrate:  getEnv("DB_MIGRATE", "auto"),
 		},
 		Minio: MinioConfig{
 			Endpoint:  getEnv("MINIO_ENDPOINT", ""),
@@ -63,9 +113,39 @@ func Load() (*Config, error) {
 			Bucket:    getEnv("MINIO_BUCKET", ""),
 			UseSSL:    getEnv("MINIO_USE_SSL", "false") == "true",
 		},
+		Storage: StorageConfig{
+			Backend: getEnv("STORAGE_BACKEND", "minio"),
+			Local: LocalStorageConfig{
+				Root:      getEnv("STORAGE_PATH", "./data"),
+				URLSecret: getEnv("STORAGE_URL_SECRET", sessionSecret),
+				BaseURL:   getEnv("STORAGE_BASE_URL", ""),
+			},
+		},
 		Email: ResendConfig{
 			APIKey:    getEnv("RESEND_API_KEY", ""),
 			FromEmail: "no-reply@dawhub.io",
+			BaseURL:   getEnv("APP_BASE_URL", "https://dawhub.com"),
+		},
+		GC: GCConfig{
+			Interval:       getDurationEnv("GC_INTERVAL", 6*time.Hour),
+			GracePeriod:    getDurationEnv("GC_GRACE_PERIOD", 24*time.Hour),
+			TrashRetention: getDurationEnv("TRASH_RETENTION", 30*24*time.Hour),
+		},
+		Upload: UploadConfig{
+			SessionTTL: getDurationEnv("UPLOAD_SESSION_TTL", 24*time.Hour),
+		},
+		Backup: BackupConfig{
+			Target: getEnv("BACKUP_TARGET", "./backups"),
+			Bucket: MinioConfig{
+				Endpoint:  getEnv("BACKUP_S3_ENDPOINT", getEnv("MINIO_ENDPOINT", "")),
+				AccessKey: getEnv("BACKUP_S3_ACCESS_KEY", getEnv("MINIO_ACCESS_KEY", "")),
+				SecretKey: getEnv("BACKUP_S3_SECRET_KEY", getEnv("MINIO_SECRET_KEY", "")),
+				UseSSL:    getEnv("BACKUP_S3_USE_SSL", getEnv("MINIO_USE_SSL", "false")) == "true",
+			},
+			KeepLast:    getIntEnv("BACKUP_KEEP_LAST", 3),
+			KeepDaily:   getIntEnv("BACKUP_KEEP_DAILY", 7),
+			KeepWeekly:  getIntEnv("BACKUP_KEEP_WEEKLY", 4),
+			KeepMonthly: getIntEnv("BACKUP_KEEP_MONTHLY", 6),
 		},
 	}, nil
 }
@@ -76,3 +156,21 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+func getIntEnv(key string, fallback int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
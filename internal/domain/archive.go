@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// ArchiveManifestName is the manifest's path inside a project archive
+const ArchiveManifestName = "manifest.json"
+
+// ArchiveManifestFormat is bumped whenever the manifest layout changes
+const ArchiveManifestFormat = 1
+
+// ArchiveManifest describes a project archive so it can be re-imported
+// without losing names or silently accepting corrupted files
+type ArchiveManifest struct {
+	Format      int            `json:"format"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Version     string         `json:"version"`
+	Revision    int            `json:"revision,omitempty"` // Latest revision when exported
+	ExportedAt  time.Time      `json:"exported_at"`
+	Files       []ArchiveEntry `json:"files"`
+}
+
+// ArchiveEntry is one file in a project archive
+type ArchiveEntry struct {
+	Path        string `json:"path"` // Location inside the zip
+	Kind        string `json:"kind"` // RevisionFileMain or RevisionFileSample
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	Hash        string `json:"hash"`
+	FilePath    string `json:"-"` // Storage key, not exported
+}
+
+// NewArchiveManifest lists the project's current files, giving each a unique
+// path inside the archive
+func NewArchiveManifest(p *Project) ArchiveManifest {
+	manifest := ArchiveManifest{
+		Format:      ArchiveManifestFormat,
+		Name:        p.Name,
+		Description: p.Description,
+		Version:     p.Version,
+		ExportedAt:  time.Now().UTC(),
+	}
+
+	used := map[string]bool{ArchiveManifestName: true}
+	add := func(kind, dir string, file FileMetadata, filePath string) {
+		name := path.Base(file.Filename)
+		entryPath := path.Join(dir, name)
+		for i := 2; used[entryPath]; i++ {
+			ext := path.Ext(name)
+			entryPath = path.Join(dir, fmt.Sprintf("%s (%d)%s", name[:len(name)-len(ext)], i, ext))
+		}
+		used[entryPath] = true
+
+		manifest.Files = append(manifest.Files, ArchiveEntry{
+			Path:        entryPath,
+			Kind:        kind,
+			Filename:    file.Filename,
+			Size:        file.Size,
+			ContentType: file.ContentType,
+			Hash:        file.Hash,
+			FilePath:    filePath,
+		})
+	}
+
+	if p.MainFile != nil {
+		add(RevisionFileMain, "", p.MainFile.FileMetadata, p.MainFile.FilePath)
+	}
+	for _, sample := range p.SampleFiles {
+		add(RevisionFileSample, "samples", sample.FileMetadata, sample.FilePath)
+	}
+
+	return manifest
+}
+
+// Entry returns the manifest entry stored at path inside the archive
+func (m *ArchiveManifest) Entry(entryPath string) (ArchiveEntry, bool) {
+	for _, entry := range m.Files {
+		if entry.Path == entryPath {
+			return entry, true
+		}
+	}
+	return ArchiveEntry{}, false
+}
@@ -7,4 +7,6 @@ type BetaUser struct {
 	Email        string    `json:"email" form:"email" gorm:"unique;not null"`
 	IsSubscribed bool      `json:"is_subscribed" form:"subscribed"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	InvitedAt *time.Time `json:"invited_at,omitempty" form:"-"` // Set once a signup invite has been sent
 }
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Blob is a content-addressed object in storage. Every ProjectFile, SampleFile,
+// RevisionFile and LibraryItem with the same FilePath shares a single Blob.
+type Blob struct {
+	FilePath    string    `gorm:"primarykey" json:"file_path"`
+	Hash        string    `gorm:"index" json:"hash"`
+	Size        int64     `gorm:"not null" json:"size"`
+	ContentType string    `json:"content_type"`
+	RefCount    int64     `gorm:"not null;default:0" json:"ref_count"` // Rows currently pointing at the object; see retainBlob
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// retainBlob registers one more reference to the object at path. Creating a
+// file row counts it here; every change that removes rows recounts the paths
+// it touched under the blob row lock, and storage verify reports any drift.
+func retainBlob(tx *gorm.DB, path string, metadata FileMetadata) error {
+	blob := Blob{
+		FilePath:    path,
+		Hash:        metadata.Hash,
+		Size:        metadata.Size,
+		ContentType: metadata.ContentType,
+		RefCount:    1,
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "file_path"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("blobs.ref_count + 1")}),
+		}).
+		Create(&blob).Error
+}
+
+// GORM Hooks
+func (f *ProjectFile) AfterCreate(tx *gorm.DB) error {
+	return retainBlob(tx, f.FilePath, f.FileMetadata)
+}
+
+func (f *SampleFile) AfterCreate(tx *gorm.DB) error {
+	return retainBlob(tx, f.FilePath, f.FileMetadata)
+}
+
+func (f *RevisionFile) AfterCreate(tx *gorm.DB) error {
+	return retainBlob(tx, f.FilePath, f.FileMetadata)
+}
+
+func (i *LibraryItem) AfterCreate(tx *gorm.DB) error {
+	return retainBlob(tx, i.FilePath, i.FileMetadata)
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCommentLength bounds a comment's body in characters
+const MaxCommentLength = 4000
+
+// Comment is feedback on one of a project's files, optionally pinned to a
+// point in the audio. Replies point at the top-level comment they answer.
+type Comment struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	ProjectID  uint       `gorm:"not null;index:idx_comment_file" json:"project_id"`
+	FileKind   string     `gorm:"not null;size:16;index:idx_comment_file" json:"file_kind"` // RevisionFileMain or RevisionFileSample
+	FileID     uint       `gorm:"not null;index:idx_comment_file" json:"file_id"`           // ProjectFile or SampleFile ID, kept current when rows are replaced
+	ParentID   *uint      `gorm:"index" json:"parent_id,omitempty"`
+	UserID     uint       `gorm:"not null" json:"user_id"`
+	Body       string     `gorm:"type:text;not null" json:"body"`
+	Timestamp  *float64   `gorm:"column:offset_seconds" json:"timestamp,omitempty"` // Seconds into the file
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy *uint      `json:"resolved_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	User    PublicUser `gorm:"foreignKey:UserID" json:"author"`
+	Replies []Comment  `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
+}
+
+// IsResolved reports whether the comment has been ticked off
+func (c *Comment) IsResolved() bool {
+	return c.ResolvedAt != nil
+}
+
+// TimestampLabel renders the comment's time offset as m:ss, or "" if it has none
+func (c *Comment) TimestampLabel() string {
+	if c.Timestamp == nil {
+		return ""
+	}
+	return FormatTimestamp(*c.Timestamp)
+}
+
+// HasFile reports whether the project's current files include the main
+// (RevisionFileMain) or sample (RevisionFileSample) file with the given ID
+func (p *Project) HasFile(kind string, id uint) bool {
+	switch kind {
+	case RevisionFileMain:
+		return p.MainFile != nil && p.MainFile.ID == id
+	case RevisionFileSample:
+		for _, sample := range p.SampleFiles {
+			if sample.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FormatTimestamp renders seconds as m:ss, or h:mm:ss past an hour
+func FormatTimestamp(seconds float64) string {
+	total := int(seconds)
+	h, m, s := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// ParseTimestamp accepts plain seconds ("83.5") or a clock position
+// ("1:23", "1:02:03") and returns seconds
+func ParseTimestamp(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, ErrInvalidTimestamp
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, ErrInvalidTimestamp
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, nil
+}
+
+// NewComment builds a comment by userID on one of the project's files. A
+// reply inherits its parent's file and is attached to the top of the thread,
+// so threads stay one level deep.
+func NewComment(project *Project, parent *Comment, userID uint, fileKind string, fileID uint, body string, timestamp *float64) (*Comment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len([]rune(body)) > MaxCommentLength {
+		return nil, ErrInvalidComment
+	}
+	if timestamp != nil && *timestamp < 0 {
+		return nil, ErrInvalidTimestamp
+	}
+
+	comment := &Comment{
+		ProjectID: project.ID,
+		UserID:    userID,
+		Body:      body,
+		Timestamp: timestamp,
+	}
+
+	if parent != nil {
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		comment.ParentID = &rootID
+		comment.FileKind = parent.FileKind
+		comment.FileID = parent.FileID
+		return comment, nil
+	}
+
+	if !project.HasFile(fileKind, fileID) {
+		return nil, ErrFileNotFound
+	}
+	comment.FileKind = fileKind
+	comment.FileID = fileID
+	return comment, nil
+}
+
+// Comment errors
+var (
+	ErrInvalidComment   = ProjectError{Code: "INVALID_COMMENT", Message: "comment must be between 1 and 4000 characters"}
+	ErrInvalidTimestamp = ProjectError{Code: "INVALID_TIMESTAMP", Message: "invalid timestamp"}
+)
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxFolderLength bounds a library folder path
+const MaxFolderLength = 255
+
+// LibraryItem is an audio file in a user's personal library. Attaching it to
+// a project adds a SampleFile pointing at the same stored object, so it is
+// never uploaded or copied twice.
+type LibraryItem struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	UserID       uint      `gorm:"not null;index" json:"user_id"`
+	Folder       string    `gorm:"not null;default:'';index" json:"folder"` // Slash-separated, "" for the top level
+	FilePath     string    `gorm:"not null;index:idx_library_items_blob" json:"file_path"`
+	FileMetadata           // Embed common file metadata
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Tags []Tag `gorm:"many2many:library_item_tags" json:"tags"` // Managed through SetLibraryItemTags
+}
+
+// NewLibraryItem creates a library item for an uploaded file. Only audio
+// can go in the library; project files belong to projects.
+func NewLibraryItem(userID uint, folder string, fileInfo FileInfo, filePath string) (*LibraryItem, error) {
+	if !IsLibraryFileType(fileInfo.ContentType) {
+		return nil, ErrNotAudio
+	}
+	folder, err := NormalizeFolder(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LibraryItem{
+		UserID:   userID,
+		Folder:   folder,
+		FilePath: filePath,
+		FileMetadata: FileMetadata{
+			Size:        fileInfo.Size,
+			Filename:    fileInfo.Filename,
+			ContentType: fileInfo.ContentType,
+			Hash:        fileInfo.Hash,
+			UploadedAt:  time.Now(),
+		},
+	}, nil
+}
+
+// SampleFile returns the sample that attaches the item to a project
+func (i *LibraryItem) SampleFile(projectID uint) SampleFile {
+	itemID := i.ID
+	metadata := i.FileMetadata
+	metadata.UploadedAt = time.Now()
+	return SampleFile{
+		ProjectID:     projectID,
+		FilePath:      i.FilePath,
+		FileMetadata:  metadata,
+		LibraryItemID: &itemID,
+	}
+}
+
+// IsLibraryFileType reports whether a file of the content type can be kept
+// in the library: audio, but not a DAW project
+func IsLibraryFileType(contentType string) bool {
+	_, isProject := DAWFileTypes[contentType]
+	return strings.HasPrefix(contentType, "audio/") && !isProject && IsAllowedFileType(contentType)
+}
+
+// NormalizeFolder cleans a slash-separated folder path, dropping empty,
+// "." and ".." segments
+func NormalizeFolder(folder string) (string, error) {
+	var segments []string
+	for _, segment := range strings.Split(folder, "/") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+
+	normalized := strings.Join(segments, "/")
+	if len(normalized) > MaxFolderLength {
+		return "", ErrInvalidFolder
+	}
+	return normalized, nil
+}
+
+// Library errors
+var (
+	ErrNotAudio         = ProjectError{Code: "NOT_AUDIO", Message: "only audio files can be added to the library"}
+	ErrInvalidFolder    = ProjectError{Code: "INVALID_FOLDER", Message: "folder name is too long"}
+	ErrLibraryItemInUse = ProjectError{Code: "LIBRARY_ITEM_IN_USE", Message: "library item is used by projects"}
+)
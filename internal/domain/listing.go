@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Listing page sizes
+const (
+	DefaultPageSize = 24
+	MaxPageSize     = 100
+)
+
+// ProjectSort is a key project listings can be ordered by
+type ProjectSort string
+
+const (
+	SortCreated ProjectSort = "created"
+	SortUpdated ProjectSort = "updated"
+	SortName    ProjectSort = "name"
+	SortSize    ProjectSort = "size"
+)
+
+// Column returns the projects column a sort key orders by
+func (s ProjectSort) Column() string {
+	switch s {
+	case SortUpdated:
+		return "updated_at"
+	case SortName:
+		return "name"
+	case SortSize:
+		return "total_size"
+	default:
+		return "created_at"
+	}
+}
+
+// IsValidProjectSort reports whether s is a supported sort key
+func IsValidProjectSort(s ProjectSort) bool {
+	switch s {
+	case SortCreated, SortUpdated, SortName, SortSize:
+		return true
+	}
+	return false
+}
+
+// Visibility filters
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// ProjectListOptions selects one page of the projects a user can access
+type ProjectListOptions struct {
+	UserID uint // Whose projects: owned and shared with them
+	Public bool // List everyone's public projects instead
+
+	Sort      ProjectSort
+	Ascending bool
+	Cursor    string // From the previous page; empty for the first
+	Limit     int
+
+	// Filters, ignored when empty
+	Visibility  string // VisibilityPublic or VisibilityPrivate
+	Version     string
+	ContentType string // Of the main file
+	OwnerID     uint
+	Tag         string
+}
+
+// ProjectPage is one page of a project listing
+type ProjectPage struct {
+	Items      []Project `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"` // Empty on the last page
+	Limit      int       `json:"limit"`
+}
+
+// ProjectCursor is the position after the last project of a page. It is
+// tied to the ordering it was issued for.
+type ProjectCursor struct {
+	Sort      ProjectSort `json:"s"`
+	Ascending bool        `json:"a"`
+	Value     string      `json:"v"` // Sort column of the last project
+	ID        uint        `json:"i"` // Tie-breaker for equal values
+}
+
+// CursorAfter returns the cursor for the page following project
+func (o ProjectListOptions) CursorAfter(project Project) string {
+	cursor := ProjectCursor{Sort: o.Sort, Ascending: o.Ascending, ID: project.ID}
+	switch o.Sort {
+	case SortUpdated:
+		cursor.Value = project.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case SortName:
+		cursor.Value = project.Name
+	case SortSize:
+		cursor.Value = strconv.FormatInt(project.TotalSize, 10)
+	default:
+		cursor.Value = project.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeCursor returns the position in the options' cursor and the sort
+// column value to continue from. It fails if the cursor is malformed or was
+// issued for a different ordering.
+func (o ProjectListOptions) DecodeCursor() (*ProjectCursor, interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+	var cursor ProjectCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+	if cursor.Sort != o.Sort || cursor.Ascending != o.Ascending {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	switch o.Sort {
+	case SortName:
+		return &cursor, cursor.Value, nil
+	case SortSize:
+		size, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+		return &cursor, size, nil
+	default:
+		at, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+		return &cursor, at, nil
+	}
+}
+
+// ParseProjectListOptions reads listing options from query parameters:
+// sort, order (asc or desc), cursor, limit, visibility, version,
+// content_type, owner (a user ID) and tag. Names sort ascending by default
+// and everything else newest or largest first.
+func ParseProjectListOptions(query url.Values) (ProjectListOptions, error) {
+	opts := ProjectListOptions{
+		Sort:        SortCreated,
+		Cursor:      query.Get("cursor"),
+		Limit:       DefaultPageSize,
+		Version:     query.Get("version"),
+		ContentType: query.Get("content_type"),
+		Tag:         NormalizeTagName(query.Get("tag")),
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		opts.Sort = ProjectSort(sort)
+		if !IsValidProjectSort(opts.Sort) {
+			return opts, ErrInvalidListOptions("sort must be created, updated, name or size")
+		}
+	}
+
+	switch query.Get("order") {
+	case "":
+		opts.Ascending = opts.Sort == SortName
+	case "asc":
+		opts.Ascending = true
+	case "desc":
+		opts.Ascending = false
+	default:
+		return opts, ErrInvalidListOptions("order must be asc or desc")
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return opts, ErrInvalidListOptions("invalid limit")
+		}
+		opts.Limit = min(n, MaxPageSize)
+	}
+
+	switch visibility := query.Get("visibility"); visibility {
+	case "", VisibilityPublic, VisibilityPrivate:
+		opts.Visibility = visibility
+	default:
+		return opts, ErrInvalidListOptions("visibility must be public or private")
+	}
+
+	if owner := query.Get("owner"); owner != "" {
+		id, err := strconv.ParseUint(owner, 10, 32)
+		if err != nil {
+			return opts, ErrInvalidListOptions("owner must be a user ID")
+		}
+		opts.OwnerID = uint(id)
+	}
+
+	if opts.Cursor != "" {
+		if _, _, err := opts.DecodeCursor(); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+// ErrInvalidListOptions reports a bad listing parameter
+func ErrInvalidListOptions(message string) ProjectError {
+	return ProjectError{Code: "INVALID_LIST_OPTIONS", Message: message}
+}
+
+// Listing errors
+var (
+	ErrInvalidCursor = ProjectError{Code: "INVALID_CURSOR", Message: "invalid or expired cursor"}
+)
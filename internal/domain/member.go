@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// ProjectRole is a collaborator's level of access to a project
+type ProjectRole string
+
+const (
+	RoleOwner  ProjectRole = "owner"  // Full control, including members and deletion
+	RoleEditor ProjectRole = "editor" // Uploads samples and replaces the main file
+	RoleViewer ProjectRole = "viewer" // Views and downloads only
+)
+
+// InviteTTL is how long an emailed invite can be accepted
+const InviteTTL = 7 * 24 * time.Hour
+
+// ProjectMember grants a user other than the owner access to a project
+type ProjectMember struct {
+	ID        uint        `gorm:"primarykey" json:"id"`
+	ProjectID uint        `gorm:"not null;uniqueIndex:idx_project_member" json:"project_id"`
+	UserID    uint        `gorm:"not null;uniqueIndex:idx_project_member;index" json:"user_id"`
+	Role      ProjectRole `gorm:"not null;size:16" json:"role"`
+	InvitedBy uint        `json:"invited_by"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	User PublicUser `gorm:"foreignKey:UserID" json:"user"`
+}
+
+// ProjectInvite is a pending invitation, accepted through an emailed link.
+// Invites to registered users record the account instead of its address.
+type ProjectInvite struct {
+	ID         uint        `gorm:"primarykey" json:"id"`
+	ProjectID  uint        `gorm:"not null;index" json:"project_id"`
+	Email      string      `gorm:"not null" json:"email,omitempty"` // Empty when UserID is set
+	UserID     *uint       `gorm:"index" json:"-"`
+	Role       ProjectRole `gorm:"not null;size:16" json:"role"`
+	TokenHash  string      `gorm:"not null;uniqueIndex;size:64" json:"-"` // SHA-256 of the emailed token
+	InvitedBy  uint        `gorm:"not null" json:"invited_by"`
+	ExpiresAt  time.Time   `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time  `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+
+	Invitee *PublicUser `gorm:"foreignKey:UserID" json:"invitee,omitempty"`
+}
+
+// IsValidMemberRole reports whether role can be granted to a collaborator.
+// Ownership is not transferable through membership.
+func IsValidMemberRole(role ProjectRole) bool {
+	return role == RoleEditor || role == RoleViewer
+}
+
+// CanWrite reports whether the role may change the project's files
+func (r ProjectRole) CanWrite() bool {
+	return r == RoleOwner || r == RoleEditor
+}
+
+// roleRanks orders roles by how much access they grant
+var roleRanks = map[ProjectRole]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Includes reports whether the role grants at least the access other does
+func (r ProjectRole) Includes(other ProjectRole) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+// IsPending reports whether the invite can still be accepted
+func (i *ProjectInvite) IsPending() bool {
+	return i.AcceptedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// NewProjectInvite creates an invite and the secret token to email to the
+// invitee. Only the token's hash is stored.
+func NewProjectInvite(projectID uint, email string, role ProjectRole, invitedBy uint) (*ProjectInvite, string, error) {
+	token, err := newSecretToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &ProjectInvite{
+		ProjectID: projectID,
+		Email:     email,
+		Role:      role,
+		TokenHash: HashInviteToken(token),
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().Add(InviteTTL),
+	}, token, nil
+}
+
+// SetInvitee addresses the invite to a registered user. Their account address
+// is only used to send the email; it is not stored or shown to the inviter.
+func (i *ProjectInvite) SetInvitee(user *User) {
+	i.Email = ""
+	i.UserID = &user.ID
+	i.Invitee = &PublicUser{ID: user.ID, Username: user.Username}
+}
+
+// HashInviteToken returns the form of an invite token kept in the database
+func HashInviteToken(token string) string {
+	return hashSecretToken(token)
+}
+
+// newSecretToken returns a random, URL-safe token for links sent to people
+func newSecretToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// hashSecretToken returns the SHA-256 of a token so only the holder of the
+// link, not the database, knows the token itself
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Member errors
+var (
+	ErrAlreadyMember = ProjectError{Code: "ALREADY_MEMBER", Message: "user is already a member"}
+	ErrInviteInvalid = ProjectError{Code: "INVITE_INVALID", Message: "invite is invalid or expired"}
+)
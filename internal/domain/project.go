@@ -25,6 +25,13 @@ type FileInfo struct {
 	Hash        string
 }
 
+// StoredObject describes an object as listed from storage
+type StoredObject struct {
+	Path         string
+	Size         int64
+	LastModified time.Time
+}
+
 // Project model with size calculations
 type Project struct {
 	ID          uint      `gorm:"primarykey" json:"id"`
@@ -36,6 +43,9 @@ type Project struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	UserID      uint      `json:"user_id"`
 
+	// Set while the project is in the trash
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
 	// Main project file ID
 	MainFileID *uint `json:"main_file_id"`
 
@@ -43,24 +53,33 @@ type Project struct {
 	MainFile    *ProjectFile `gorm:"foreignKey:MainFileID" json:"main_file"`
 	SampleFiles []SampleFile `gorm:"foreignKey:ProjectID" json:"sample_files"`
 	User        User         `gorm:"foreignKey:UserID" json:"-"`
+	Tags        []Tag        `gorm:"many2many:project_tags" json:"tags"` // Managed through SetProjectTags
 
 	// Calculated total size (updated on file changes)
 	TotalSize int64 `gorm:"not null;default:0" json:"total_size"` // Total size in bytes
+
+	// Activity, maintained as stars and downloads happen
+	StarCount     int64   `gorm:"not null;default:0" json:"star_count"`
+	DownloadCount int64   `gorm:"not null;default:0" json:"download_count"`
+	TrendingScore float64 `gorm:"not null;default:0;index" json:"-"` // See BumpTrendingScore
 }
 
 // ProjectFile model for the main project file
 type ProjectFile struct {
 	ID           uint   `gorm:"primarykey" json:"id"`
 	FileMetadata        // Embed common file metadata
-	FilePath     string `gorm:"not null;uniqueIndex" json:"file_path"`
+	FilePath     string `gorm:"not null;index:idx_project_files_blob" json:"file_path"` // Shared, content-addressed object key
 }
 
 // SampleFile model with metadata
 type SampleFile struct {
-	ID           uint   `gorm:"primarykey" json:"id"`
-	ProjectID    uint   `json:"project_id"`
-	FilePath     string `gorm:"not null;uniqueIndex" json:"file_path"`
-	FileMetadata        // Embed common file metadata
+	ID            uint   `gorm:"primarykey" json:"id"`
+	ProjectID     uint   `json:"project_id"`
+	FilePath      string `gorm:"not null;index:idx_sample_files_blob" json:"file_path"` // Shared, content-addressed object key
+	FileMetadata         // Embed common file metadata
+	LibraryItemID *uint  `gorm:"index" json:"library_item_id,omitempty"` // Set when attached from the owner's library
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // Set while the sample is in the trash
 }
 
 // ProjectRepository defines the interface for project storage operations
@@ -70,6 +89,7 @@ type ProjectRepository interface {
 	FindByUserID(id uint) ([]Project, error)
 	FindAll(filters ...func(*gorm.DB) *gorm.DB) ([]Project, error)
 	FindAllPublic() ([]Project, error)
+	ListProjects(opts ProjectListOptions) (*ProjectPage, error)
 	FindByID(id uint) (*Project, error)
 	Update(project *Project) error
 	Delete(id uint) error
@@ -79,11 +99,91 @@ type ProjectRepository interface {
 	AddSampleFile(projectID uint, file *SampleFile) error
 	RemoveSampleFile(projectID uint, fileID uint) error
 	GetProjectSize(projectID uint) (int64, error)
+	ReleaseBlobs(paths []string) ([]string, error)
+	FindUnreferencedPaths(paths []string) ([]string, error)
+	CountBlobReferences(paths []string) (map[string]int64, error)
 
 	// Batch operations
 	AddSampleFiles(projectID uint, files []SampleFile) error
 	RemoveSampleFiles(projectID uint, fileIDs []uint) error
 
+	// Revision operations
+	CreateRevision(projectID uint, userID uint, message string) (*ProjectRevision, error)
+	FindRevisions(projectID uint) ([]ProjectRevision, error)
+	FindRevision(projectID uint, number int) (*ProjectRevision, error)
+	DeleteRevisions(projectID uint) error
+	RestoreRevision(projectID uint, number int, userID uint) (*ProjectRevision, error)
+
+	// Member operations
+	FindAccessible(userID uint) ([]Project, error)
+	FindMember(projectID, userID uint) (*ProjectMember, error)
+	FindMembers(projectID uint) ([]ProjectMember, error)
+	UpdateMemberRole(projectID, userID uint, role ProjectRole) error
+	RemoveMember(projectID, userID uint) error
+	RemoveMemberships(userID uint) error
+	CreateInvite(invite *ProjectInvite) error
+	FindPendingInvites(projectID uint) ([]ProjectInvite, error)
+	DeleteInvite(projectID, inviteID uint) error
+	AcceptInvite(tokenHash string, userID uint) (*ProjectMember, error)
+
+	// Share link operations
+	CreateShareLink(link *ShareLink) error
+	FindShareLinks(projectID uint) ([]ShareLink, error)
+	FindShareLink(tokenHash string) (*ShareLink, error)
+	RevokeShareLink(projectID, linkID uint) error
+	RecordShareLinkUse(linkID uint) error
+
+	// Comment operations
+	CreateComment(comment *Comment) error
+	FindComments(projectID uint) ([]Comment, error)
+	FindComment(projectID, commentID uint) (*Comment, error)
+	ResolveComment(projectID, commentID, userID uint, resolved bool) error
+	DeleteComment(projectID, commentID uint) error
+
+	// Activity operations
+	StarProject(projectID, userID uint) error
+	UnstarProject(projectID, userID uint) error
+	IsStarred(projectID, userID uint) (bool, error)
+	FindStarred(userID uint) ([]Project, error)
+	RemoveStars(userID uint) error
+	RecordDownload(projectID uint) error
+	FindTrending(limit int) ([]Project, error)
+
+	// Search operations
+	Search(query SearchQuery) (*SearchResults, error)
+
+	// Tag operations
+	SetProjectTags(projectID uint, names []string) error
+	FindTag(name string) (*Tag, error)
+	FindTagCounts(limit int) ([]TagCount, error)
+
+	// Library operations
+	CreateLibraryItem(item *LibraryItem) error
+	FindLibraryItems(userID uint, folder, tag string) ([]LibraryItem, error)
+	FindLibraryItem(userID, itemID uint) (*LibraryItem, error)
+	FindLibraryFolders(userID uint) ([]string, error)
+	MoveLibraryItem(userID, itemID uint, folder string) error
+	SetLibraryItemTags(itemID uint, names []string) error
+	FindLibraryItemUsage(itemID uint) ([]Project, error)
+	DeleteLibraryItem(userID, itemID uint) error
+	RemoveLibrary(userID uint) error
+	AttachLibraryItems(projectID uint, items []LibraryItem) (int, error)
+
+	// Trash operations
+	FindTrash(userID uint) (*Trash, error)
+	FindExpiredTrash(before time.Time) (*Trash, error)
+	FindTrashedProject(projectID uint) (*Project, error)
+	FindTrashedSample(projectID, fileID uint) (*SampleFile, error)
+	RestoreProject(projectID uint) error
+	RestoreSampleFile(projectID, fileID uint) error
+	PurgeProject(projectID uint) ([]string, error)
+	PurgeSampleFile(projectID, fileID uint) ([]string, error)
+
+	// Administration
+	TransferProject(projectID, userID uint) error
+	RecalculateSize(projectID uint) (int64, error)
+	FindBlobs() ([]Blob, error)
+
 	// Transaction support
 	WithTx(tx *gorm.DB) ProjectRepository
 	Begin() (ProjectRepository, error)
@@ -93,9 +193,10 @@ type ProjectRepository interface {
 
 // StorageService defines the interface for file storage operations
 type StorageService interface {
-	UploadFile(projectID uint, filename string, reader io.Reader) (FileInfo, string, error)
+	UploadFile(filename string, reader io.Reader) (FileInfo, string, error)
 	GetDownloadURL(filepath string) (string, error)
 	GetFile(filepath string) (io.ReadCloser, FileInfo, error)
+	GetFileRange(filepath string, start, end int64) (io.ReadCloser, error)
 	DeleteFile(filepath string) error
 
 	// Metadata operations
@@ -105,6 +206,20 @@ type StorageService interface {
 	// Batch operations
 	DeleteFiles(filepaths []string) error
 	ValidateFiles(files map[string]io.Reader) (map[string]FileMetadata, error)
+	ListFiles(prefix string) ([]StoredObject, error)
+
+	// Resumable upload operations
+	StartMultipartUpload(filename string) (MultipartUpload, error)
+	UploadPart(path, uploadID string, partNumber int, reader io.Reader, size int64) error
+	CompleteMultipartUpload(path, uploadID string, metadata FileMetadata) (string, error)
+	AbortMultipartUpload(path, uploadID string) error
+
+	// Direct upload operations
+	PresignUpload(filename string, expiry time.Duration) (PresignedUpload, error)
+	FinalizeUpload(path string, expected FileMetadata) (string, error)
+
+	// Import operations
+	ImportFile(expected FileMetadata, reader io.Reader) (string, error)
 }
 
 // FileValidator interface for file validation operations
@@ -131,6 +246,10 @@ func (p *Project) BeforeCreate(tx *gorm.DB) error {
 	if p.MainFile != nil {
 		p.MainFileID = &p.MainFile.ID
 	}
+	// New projects get a little activity so they can surface before anyone stars them
+	if p.TrendingScore == 0 {
+		p.TrendingScore = BumpTrendingScore(0, TrendingWeightCreate, time.Now())
+	}
 	return nil
 }
 
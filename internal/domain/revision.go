@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// Revision file kinds
+const (
+	RevisionFileMain   = "main"
+	RevisionFileSample = "sample"
+)
+
+// ProjectRevision is an immutable snapshot of a project's files
+type ProjectRevision struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	ProjectID uint      `gorm:"not null;uniqueIndex:idx_project_revision_number" json:"project_id"`
+	Number    int       `gorm:"not null;uniqueIndex:idx_project_revision_number" json:"number"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	User  PublicUser     `gorm:"foreignKey:UserID" json:"author"`
+	Files []RevisionFile `gorm:"foreignKey:RevisionID" json:"files"`
+}
+
+// RevisionFile records a file exactly as it was when the revision was taken
+type RevisionFile struct {
+	ID            uint   `gorm:"primarykey" json:"id"`
+	RevisionID    uint   `gorm:"not null;index" json:"revision_id"`
+	Kind          string `gorm:"not null" json:"kind"` // RevisionFileMain or RevisionFileSample
+	FilePath      string `gorm:"not null;index" json:"file_path"`
+	FileMetadata         // Embed common file metadata
+	LibraryItemID *uint  `gorm:"index" json:"library_item_id,omitempty"` // Library item a sample was attached from
+}
+
+// MainFile returns the revision's main project file, if any
+func (r *ProjectRevision) MainFile() *RevisionFile {
+	for i := range r.Files {
+		if r.Files[i].Kind == RevisionFileMain {
+			return &r.Files[i]
+		}
+	}
+	return nil
+}
+
+// SampleFiles returns the revision's sample files
+func (r *ProjectRevision) SampleFiles() []RevisionFile {
+	var samples []RevisionFile
+	for _, f := range r.Files {
+		if f.Kind == RevisionFileSample {
+			samples = append(samples, f)
+		}
+	}
+	return samples
+}
+
+// NewRevisionFiles snapshots a project's current files
+func NewRevisionFiles(p *Project) []RevisionFile {
+	var files []RevisionFile
+	if p.MainFile != nil {
+		files = append(files, RevisionFile{
+			Kind:         RevisionFileMain,
+			FilePath:     p.MainFile.FilePath,
+			FileMetadata: p.MainFile.FileMetadata,
+		})
+	}
+	for _, sample := range p.SampleFiles {
+		files = append(files, RevisionFile{
+			Kind:          RevisionFileSample,
+			FilePath:      sample.FilePath,
+			FileMetadata:  sample.FileMetadata,
+			LibraryItemID: sample.LibraryItemID,
+		})
+	}
+	return files
+}
+
+// CalculateTotalSize sums the snapshot the same way Project.CalculateTotalSize does
+func (r *ProjectRevision) CalculateTotalSize() int64 {
+	var total int64
+	if main := r.MainFile(); main != nil {
+		total += main.Size
+	}
+	for _, sample := range r.SampleFiles() {
+		total += sample.Size
+	}
+	return total
+}
+
+// FileChange pairs two versions of a sample that share a filename
+type FileChange struct {
+	From RevisionFile `json:"from"`
+	To   RevisionFile `json:"to"`
+}
+
+// RevisionDiff describes what changed between two revisions
+type RevisionDiff struct {
+	From            int            `json:"from"`
+	To              int            `json:"to"`
+	Added           []RevisionFile `json:"added"`
+	Removed         []RevisionFile `json:"removed"`
+	Changed         []FileChange   `json:"changed"`
+	MainFileChanged bool           `json:"main_file_changed"`
+	MainFileFrom    *RevisionFile  `json:"main_file_from"`
+	MainFileTo      *RevisionFile  `json:"main_file_to"`
+	SizeDelta       int64          `json:"size_delta"` // Bytes gained (or lost) going from -> to
+}
+
+// HasChanges reports whether the two revisions differ at all
+func (d RevisionDiff) HasChanges() bool {
+	return d.MainFileChanged || len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// DiffRevisions compares two revisions. Projects may hold several samples
+// with the same filename, so samples are matched as a multiset: copies with
+// the same filename and hash cancel out, and whatever is left under a
+// filename is paired up as changes, with any surplus added or removed.
+func DiffRevisions(from, to *ProjectRevision) RevisionDiff {
+	diff := RevisionDiff{
+		From:         from.Number,
+		To:           to.Number,
+		Added:        []RevisionFile{},
+		Removed:      []RevisionFile{},
+		Changed:      []FileChange{},
+		MainFileFrom: from.MainFile(),
+		MainFileTo:   to.MainFile(),
+		SizeDelta:    to.CalculateTotalSize() - from.CalculateTotalSize(),
+	}
+
+	switch {
+	case diff.MainFileFrom == nil && diff.MainFileTo == nil:
+	case diff.MainFileFrom == nil || diff.MainFileTo == nil:
+		diff.MainFileChanged = true
+	default:
+		diff.MainFileChanged = diff.MainFileFrom.Filename != diff.MainFileTo.Filename ||
+			diff.MainFileFrom.Hash != diff.MainFileTo.Hash
+	}
+
+	before := groupByFilename(from.SampleFiles())
+	after := groupByFilename(to.SampleFiles())
+
+	for name, added := range after {
+		removed := before[name]
+		added, removed = unmatched(added, removed), unmatched(removed, added)
+		for len(added) > 0 && len(removed) > 0 {
+			diff.Changed = append(diff.Changed, FileChange{From: removed[0], To: added[0]})
+			added, removed = added[1:], removed[1:]
+		}
+		diff.Added = append(diff.Added, added...)
+		diff.Removed = append(diff.Removed, removed...)
+	}
+	for name, removed := range before {
+		if _, kept := after[name]; !kept {
+			diff.Removed = append(diff.Removed, removed...)
+		}
+	}
+
+	sort.SliceStable(diff.Added, func(i, j int) bool { return diff.Added[i].Filename < diff.Added[j].Filename })
+	sort.SliceStable(diff.Removed, func(i, j int) bool { return diff.Removed[i].Filename < diff.Removed[j].Filename })
+	sort.SliceStable(diff.Changed, func(i, j int) bool { return diff.Changed[i].To.Filename < diff.Changed[j].To.Filename })
+
+	return diff
+}
+
+// groupByFilename buckets samples by filename, keeping revision order
+func groupByFilename(samples []RevisionFile) map[string][]RevisionFile {
+	groups := make(map[string][]RevisionFile)
+	for _, sample := range samples {
+		groups[sample.Filename] = append(groups[sample.Filename], sample)
+	}
+	return groups
+}
+
+// unmatched returns the files in a that have no copy with the same hash in
+// b, matching each file in b at most once
+func unmatched(a, b []RevisionFile) []RevisionFile {
+	available := make(map[string]int)
+	for _, f := range b {
+		available[f.Hash]++
+	}
+	var rest []RevisionFile
+	for _, f := range a {
+		if available[f.Hash] > 0 {
+			available[f.Hash]--
+			continue
+		}
+		rest = append(rest, f)
+	}
+	return rest
+}
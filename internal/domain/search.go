@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"html"
+	"strings"
+)
+
+// Search limits
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MaxSearchLength    = 256
+)
+
+// Markers placed around matched words by the database. They cannot occur in
+// user text that went through HighlightHTML's escaping, so it is safe to
+// swap them for tags afterwards.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// SearchQuery is a full-text search on behalf of a user. Only projects the
+// user can see are returned.
+type SearchQuery struct {
+	Text   string
+	UserID uint
+	Limit  int
+	Offset int
+}
+
+// SearchResult is one matching project with what matched highlighted
+type SearchResult struct {
+	Project              Project  `json:"project"`
+	Rank                 float64  `json:"rank"`
+	NameHighlight        string   `json:"name_highlight"`        // HTML, matches wrapped in <mark>
+	DescriptionHighlight string   `json:"description_highlight"` // HTML, matches wrapped in <mark>
+	MatchingSamples      []string `json:"matching_samples,omitempty"`
+}
+
+// SearchResults is one page of results
+type SearchResults struct {
+	Results []SearchResult `json:"results"`
+	Total   int64          `json:"total"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+}
+
+// HighlightHTML escapes text marked with HighlightStart and HighlightStop
+// and turns the markers into <mark> tags
+func HighlightHTML(marked string) string {
+	escaped := html.EscapeString(marked)
+	escaped = strings.ReplaceAll(escaped, HighlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, HighlightStop, "</mark>")
+}
+
+// MarkTerms wraps every case-insensitive occurrence of the terms in text with
+// HighlightStart and HighlightStop. It is used where the database cannot
+// highlight matches itself.
+func MarkTerms(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Case folding changed byte offsets; leave the text unmarked
+		return text
+	}
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		if term == "" {
+			continue
+		}
+		for start := 0; ; {
+			i := strings.Index(lower[start:], term)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(term); j++ {
+				marked[j] = true
+			}
+			start += i + len(term)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(HighlightStart)
+		}
+		b.WriteByte(text[i])
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteString(HighlightStop)
+		}
+	}
+	return b.String()
+}
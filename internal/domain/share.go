@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ShareLink gives anyone holding its token read-only access to a project,
+// without an account. Links can expire, be password protected and be
+// revoked at any time.
+type ShareLink struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	ProjectID     uint       `gorm:"not null;index" json:"project_id"`
+	TokenHash     string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // SHA-256 of the shared token
+	Label         string     `json:"label"`
+	PasswordHash  string     `json:"-"`
+	AllowDownload bool       `gorm:"not null;default:false" json:"allow_download"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	UseCount      int64      `gorm:"not null;default:0" json:"use_count"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy     uint       `gorm:"not null" json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ShareLinkOptions are the settings chosen when a link is created
+type ShareLinkOptions struct {
+	Label         string
+	Password      string        // Empty for no password
+	AllowDownload bool          // Whether files can be downloaded, not just listed
+	ExpiresIn     time.Duration // Zero for a link that never expires
+}
+
+// NewShareLink creates a link for a project and the token to hand out.
+// Only the token's hash is stored.
+func NewShareLink(projectID, createdBy uint, opts ShareLinkOptions) (*ShareLink, string, error) {
+	token, err := newSecretToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	link := &ShareLink{
+		ProjectID:     projectID,
+		TokenHash:     HashShareToken(token),
+		Label:         opts.Label,
+		AllowDownload: opts.AllowDownload,
+		CreatedBy:     createdBy,
+	}
+
+	if opts.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(opts.ExpiresIn)
+		link.ExpiresAt = &expiresAt
+	}
+
+	if opts.Password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(opts.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", err
+		}
+		link.PasswordHash = string(hashed)
+	}
+
+	return link, token, nil
+}
+
+// HashShareToken returns the form of a share token kept in the database
+func HashShareToken(token string) string {
+	return hashSecretToken(token)
+}
+
+// IsActive reports whether the link has neither expired nor been revoked
+func (l *ShareLink) IsActive() bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	return l.ExpiresAt == nil || time.Now().Before(*l.ExpiresAt)
+}
+
+// HasPassword reports whether visitors must enter a password
+func (l *ShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
+
+// CheckPassword verifies a visitor's password against the link's
+func (l *ShareLink) CheckPassword(password string) bool {
+	if !l.HasPassword() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(l.PasswordHash), []byte(password)) == nil
+}
+
+// ErrShareLinkInvalid is returned for unknown, expired and revoked links alike
+var ErrShareLinkInvalid = ProjectError{Code: "SHARE_LINK_INVALID", Message: "share link is invalid, expired or revoked"}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// SignupInviteTTL is how long an emailed signup invite can be used
+const SignupInviteTTL = 14 * 24 * time.Hour
+
+// SignupInvite lets someone create an account while registration is closed,
+// through an emailed link
+type SignupInvite struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	Email      string     `gorm:"not null;index" json:"email"`
+	TokenHash  string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // SHA-256 of the emailed token
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsPending reports whether the invite can still be used
+func (i *SignupInvite) IsPending() bool {
+	return i.AcceptedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// NewSignupInvite creates an invite and the secret token to email to the
+// invitee. Only the token's hash is stored.
+func NewSignupInvite(email string) (*SignupInvite, string, error) {
+	token, err := newSecretToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &SignupInvite{
+		Email:     email,
+		TokenHash: HashInviteToken(token),
+		ExpiresAt: time.Now().Add(SignupInviteTTL),
+	}, token, nil
+}
+
+// Signup errors
+var (
+	ErrSignupInviteInvalid = ProjectError{Code: "SIGNUP_INVITE_INVALID", Message: "invite is invalid or expired"}
+	ErrAccountExists       = ProjectError{Code: "ACCOUNT_EXISTS", Message: "username or email is already taken"}
+)
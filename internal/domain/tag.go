@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TagKind groups tags. Genres, moods and instruments come from a curated
+// taxonomy; anything else a user types is a free-form tag.
+type TagKind string
+
+const (
+	TagGenre      TagKind = "genre"
+	TagMood       TagKind = "mood"
+	TagInstrument TagKind = "instrument"
+	TagFree       TagKind = "tag"
+)
+
+// Tag limits
+const (
+	MaxTagLength   = 32
+	MaxProjectTags = 20
+)
+
+// Tag categorizes projects. Names are normalized, so "Lo Fi" and "lo-fi"
+// are the same tag.
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Name      string    `gorm:"not null;uniqueIndex;size:32" json:"name"`
+	Kind      TagKind   `gorm:"not null;size:16;index" json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagCount is a tag with the number of public projects carrying it
+type TagCount struct {
+	Tag
+	ProjectCount int64 `json:"project_count"`
+}
+
+// TagTaxonomy is the curated vocabulary offered in the project forms
+var TagTaxonomy = map[TagKind][]string{
+	TagGenre: {
+		"ambient", "drum-and-bass", "dubstep", "electronic", "funk", "hip-hop",
+		"house", "jazz", "lo-fi", "pop", "r-and-b", "rock", "soul", "techno",
+		"trance", "trap",
+	},
+	TagMood: {
+		"aggressive", "chill", "dark", "dreamy", "energetic", "happy",
+		"melancholic", "uplifting",
+	},
+	TagInstrument: {
+		"bass", "drums", "guitar", "keys", "pads", "piano", "strings", "synth",
+		"vocals",
+	},
+}
+
+// taxonomyKinds maps each curated tag name to its kind
+var taxonomyKinds = func() map[string]TagKind {
+	kinds := make(map[string]TagKind)
+	for kind, names := range TagTaxonomy {
+		for _, name := range names {
+			kinds[name] = kind
+		}
+	}
+	return kinds
+}()
+
+// NormalizeTagName lowercases a tag name and joins its words with hyphens,
+// dropping punctuation. It returns "" if nothing usable is left.
+func NormalizeTagName(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		case r == '&':
+			pendingHyphen = b.Len() > 0
+			if pendingHyphen {
+				b.WriteString("-and")
+			}
+		default:
+			pendingHyphen = true
+		}
+	}
+
+	normalized := b.String()
+	if len(normalized) > MaxTagLength {
+		// Don't leave half of a multi-byte character behind
+		normalized = strings.ToValidUTF8(normalized[:MaxTagLength], "")
+		normalized = strings.TrimRight(normalized, "-")
+	}
+	return normalized
+}
+
+// NewTags normalizes tag names, dropping blanks and duplicates, and assigns
+// each its kind from the taxonomy
+func NewTags(names []string) ([]Tag, error) {
+	seen := make(map[string]bool)
+	var tags []Tag
+	for _, name := range names {
+		name = NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		kind, ok := taxonomyKinds[name]
+		if !ok {
+			kind = TagFree
+		}
+		tags = append(tags, Tag{Name: name, Kind: kind})
+	}
+
+	if len(tags) > MaxProjectTags {
+		return nil, ErrTooManyTags
+	}
+	return tags, nil
+}
+
+// TagNames returns the names of the project's tags
+func (p *Project) TagNames() []string {
+	names := make([]string, len(p.Tags))
+	for i, tag := range p.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// HasTag reports whether the project carries the named tag
+func (p *Project) HasTag(name string) bool {
+	for _, tag := range p.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FreeTagList returns the project's free-form tags as a comma-separated list
+func (p *Project) FreeTagList() string {
+	var names []string
+	for _, tag := range p.Tags {
+		if tag.Kind == TagFree {
+			names = append(names, tag.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Tag errors
+var (
+	ErrTooManyTags = ProjectError{Code: "TOO_MANY_TAGS", Message: "a project can have at most 20 tags"}
+)
@@ -0,0 +1,19 @@
+package domain
+
+// Trash holds what a user deleted and can still restore: their projects, and
+// samples removed from projects they own
+type Trash struct {
+	Projects []Project       `json:"projects"`
+	Samples  []TrashedSample `json:"samples"`
+}
+
+// TrashedSample is a sample in the trash along with the project it came from
+type TrashedSample struct {
+	SampleFile
+	ProjectName string `json:"project_name"`
+}
+
+// IsEmpty reports whether there is nothing in the trash
+func (t *Trash) IsEmpty() bool {
+	return len(t.Projects) == 0 && len(t.Samples) == 0
+}
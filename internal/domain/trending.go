@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// ProjectStar records that a user starred a project
+type ProjectStar struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	ProjectID uint      `gorm:"primaryKey;autoIncrement:false;index" json:"project_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// How much each kind of activity counts towards a project's trending score
+const (
+	TrendingWeightCreate   = 1.0
+	TrendingWeightDownload = 1.0
+	TrendingWeightStar     = 5.0
+)
+
+// TrendingHalfLife is how long it takes for activity to count half as much
+const TrendingHalfLife = 3 * 24 * time.Hour
+
+// trendingEpoch anchors trending scores; any fixed time works
+var trendingEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// BumpTrendingScore adds activity of the given weight at time at to a
+// project's score and returns the new score.
+//
+// A project's score is the log of the sum of weight·2^((t-epoch)/halfLife)
+// over its activity. Dividing every term by 2^((now-epoch)/halfLife) gives
+// the usual exponentially decayed activity, and that factor is the same for
+// every project, so ordering by the stored score ranks projects by decayed
+// activity at any moment without ever recomputing old scores. Keeping the
+// log stops the terms from overflowing.
+func BumpTrendingScore(score, weight float64, at time.Time) float64 {
+	term := math.Log(weight) + at.Sub(trendingEpoch).Seconds()/TrendingHalfLife.Seconds()*math.Ln2
+	if score == 0 {
+		return term
+	}
+	// log(e^score + e^term) without overflow
+	high, low := math.Max(score, term), math.Min(score, term)
+	return high + math.Log1p(math.Exp(low-high))
+}
@@ -37,6 +37,19 @@ var AllowedFileTypes = map[string]bool{
 	"application/x-7z":  true,
 }
 
+// DAWFileTypes names the DAW behind each project file content type
+var DAWFileTypes = map[string]string{
+	"audio/x-flp":        "FL Studio",
+	"audio/x-logic":      "Logic Pro",
+	"audio/x-ableton":    "Ableton",
+	"audio/x-protools":   "Pro Tools",
+	"audio/x-cubase":     "Cubase",
+	"audio/x-studio-one": "Studio One",
+	"audio/x-reason":     "Reason",
+	"audio/x-reaper":     "Reaper",
+	"audio/x-bitwig":     "Bitwig",
+}
+
 // Helper function to determine content type based on extension
 func determineContentType(filename string) string {
 	ext := strings.ToLower(path.Ext(filename))
@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+// Resumable upload limits
+const (
+	MinUploadChunkSize = 5 * 1024 * 1024   // Storage rejects smaller parts, except the last
+	MaxUploadChunkSize = 100 * 1024 * 1024 // Largest chunk accepted per request
+)
+
+// UploadSession tracks a resumable upload that is assembled from sequential chunks
+type UploadSession struct {
+	ID          string    `gorm:"primarykey;size:32" json:"id"`
+	ProjectID   uint      `gorm:"not null;index" json:"project_id"`
+	UserID      uint      `gorm:"not null" json:"user_id"`
+	Filename    string    `gorm:"not null" json:"filename"`
+	Kind        string    `gorm:"not null" json:"kind"` // RevisionFileMain or RevisionFileSample
+	ContentType string    `gorm:"not null" json:"content_type"`
+	Message     string    `json:"message"`
+	Size        int64     `gorm:"not null" json:"size"`                                  // Declared total size
+	Hash        string    `gorm:"size:64" json:"hash,omitempty"`                         // Declared SHA-256, for direct uploads
+	Offset      int64     `gorm:"column:upload_offset;not null;default:0" json:"offset"` // Bytes received so far
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Storage-side state, never exposed to clients
+	StagingPath     string `gorm:"not null" json:"-"`
+	StorageUploadID string `gorm:"not null" json:"-"`
+	PartCount       int    `gorm:"not null;default:0" json:"-"`
+	HashState       []byte `json:"-"` // Serialized SHA-256 state of the bytes received so far
+}
+
+// IsExpired reports whether the session can no longer accept chunks or be
+// finalized
+func (s *UploadSession) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// IsDirect reports whether the client uploads straight to the bucket through
+// a presigned URL rather than in chunks through the app
+func (s *UploadSession) IsDirect() bool {
+	return s.StorageUploadID == ""
+}
+
+// IsComplete reports whether every declared byte has been received
+func (s *UploadSession) IsComplete() bool {
+	return s.Offset == s.Size
+}
+
+// MultipartUpload identifies an unfinished upload in storage
+type MultipartUpload struct {
+	Path        string
+	UploadID    string
+	ContentType string
+}
+
+// PresignedUpload is a URL the client can upload one file to directly
+type PresignedUpload struct {
+	Path        string
+	URL         string
+	ContentType string
+	ExpiresAt   time.Time
+}
+
+// Upload session errors
+var (
+	ErrUploadOffsetMismatch = ProjectError{Code: "UPLOAD_OFFSET_MISMATCH", Message: "upload offset does not match"}
+	ErrUploadIncomplete     = ProjectError{Code: "UPLOAD_INCOMPLETE", Message: "upload is incomplete"}
+	ErrUploadExpired        = ProjectError{Code: "UPLOAD_EXPIRED", Message: "upload session expired"}
+	ErrUploadSizeMismatch   = ProjectError{Code: "UPLOAD_SIZE_MISMATCH", Message: "uploaded size does not match"}
+)
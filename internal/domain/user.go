@@ -13,6 +13,26 @@ type User struct {
 	Password  string    `json:"-" form:"password" gorm:"not null"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	IsAdmin    bool       `json:"is_admin" form:"-" gorm:"not null;default:false"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty" form:"-"` // Set while the account is locked out
+}
+
+// PublicUser is the part of an account anyone may see. Relationships that
+// are shown to other users, such as revision authors, load this instead of
+// User so addresses and account flags never leave the server.
+type PublicUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+func (PublicUser) TableName() string {
+	return "users"
+}
+
+// IsDisabled reports whether the account has been locked out
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
 }
 
 func (u *User) HashPassword() error {
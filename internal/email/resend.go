@@ -2,12 +2,15 @@ package email
 
 import (
 	"fmt"
+	"html"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/resendlabs/resend-go"
 
 	"dawhub/internal/config"
+	"dawhub/internal/domain"
 	"dawhub/pkg/common"
 )
 
@@ -16,16 +19,18 @@ const (
 )
 
 type ResendService struct {
-	client *resend.Client
-	from   string
+	client  *resend.Client
+	from    string
+	baseURL string
 }
 
 func NewResendService(cfg config.ResendConfig) *ResendService {
 	client := resend.NewClient(cfg.APIKey)
 
 	return &ResendService{
-		client: client,
-		from:   cfg.FromEmail,
+		client:  client,
+		from:    cfg.FromEmail,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
 	}
 }
 
@@ -79,3 +84,33 @@ func (s *ResendService) SendPasswordResetEmail(email, resetToken string) error {
 
 	return s.SendEmail(email, "Password Reset Request", htmlContent)
 }
+
+func (s *ResendService) SendProjectInviteEmail(email, inviter, projectName string, role domain.ProjectRole, token string) error {
+	htmlContent := fmt.Sprintf(`
+		<h1>You're invited to collaborate</h1>
+		<p>%s has invited you to join <strong>%s</strong> on DawHub as %s.</p>
+		<a href="%s/invites/%s">Accept Invite</a>
+		<p>This invite expires in 7 days. If you weren't expecting it, you can ignore this email.</p>
+	`, html.EscapeString(inviter), html.EscapeString(projectName), html.EscapeString(articleFor(string(role))), s.baseURL, token)
+
+	return s.SendEmail(email, fmt.Sprintf("%s invited you to %s", inviter, projectName), htmlContent)
+}
+
+func (s *ResendService) SendSignupInviteEmail(email, token string) error {
+	htmlContent := fmt.Sprintf(`
+		<h1>Your DawHub account is ready</h1>
+		<p>Thanks for joining the DawHub beta. You can now create your account:</p>
+		<a href="%s/signup/%s">Create Account</a>
+		<p>This invite expires in 14 days. If you weren't expecting it, you can ignore this email.</p>
+	`, s.baseURL, token)
+
+	return s.SendEmail(email, "You're invited to DawHub", htmlContent)
+}
+
+// articleFor prefixes a role with "a" or "an"
+func articleFor(word string) string {
+	if word != "" && strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an " + word
+	}
+	return "a " + word
+}
@@ -0,0 +1,155 @@
+package gc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"dawhub/internal/domain"
+)
+
+// Report summarizes a single collection run
+type Report struct {
+	DryRun         bool
+	Scanned        int
+	Orphaned       []domain.StoredObject
+	Deleted        int
+	ReclaimedBytes int64
+}
+
+// stagingPrefixes hold uploads that belong to an upload session rather than
+// a row: files sent to a presigned URL, and direct uploads being finalized
+var stagingPrefixes = []string{"uploads/", "claimed/"}
+
+// Collector finds storage objects that no database row references and
+// removes them once they are older than the grace period. Staged uploads are
+// kept for the upload session TTL on top of that, so only those whose session
+// has expired, or which a crash left behind, are collected.
+type Collector struct {
+	repo        domain.ProjectRepository
+	storage     domain.StorageService
+	gracePeriod time.Duration
+	uploadTTL   time.Duration
+}
+
+// NewCollector creates a garbage collector for the given repository and storage
+func NewCollector(repo domain.ProjectRepository, storage domain.StorageService, gracePeriod, uploadTTL time.Duration) *Collector {
+	return &Collector{
+		repo:        repo,
+		storage:     storage,
+		gracePeriod: gracePeriod,
+		uploadTTL:   uploadTTL,
+	}
+}
+
+// Run performs one collection pass. In dry-run mode orphans are only reported.
+func (c *Collector) Run(dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun}
+
+	objects, err := c.storage.ListFiles("")
+	if err != nil {
+		return report, fmt.Errorf("failed to list storage: %w", err)
+	}
+	report.Scanned = len(objects)
+
+	// Objects younger than the grace period may belong to an upload whose
+	// transaction has not committed yet
+	cutoff := time.Now().Add(-c.gracePeriod)
+	stagingCutoff := cutoff.Add(-c.uploadTTL)
+	candidates := make(map[string]domain.StoredObject)
+	var paths []string
+	for _, obj := range objects {
+		if obj.LastModified.After(cutoff) || (isStaged(obj.Path) && obj.LastModified.After(stagingCutoff)) {
+			continue
+		}
+		candidates[obj.Path] = obj
+		paths = append(paths, obj.Path)
+	}
+
+	unreferenced, err := c.repo.FindUnreferencedPaths(paths)
+	if err != nil {
+		return report, fmt.Errorf("failed to cross-reference files: %w", err)
+	}
+	for _, path := range unreferenced {
+		report.Orphaned = append(report.Orphaned, candidates[path])
+	}
+
+	if dryRun || len(unreferenced) == 0 {
+		return report, nil
+	}
+
+	// Recount under a transaction so a file row added since the scan keeps its object
+	released, err := c.repo.ReleaseBlobs(unreferenced)
+	if err != nil {
+		return report, fmt.Errorf("failed to release blobs: %w", err)
+	}
+
+	orphans, err := Sweep(c.storage, released, c.gracePeriod)
+	if err != nil {
+		return report, fmt.Errorf("failed to delete orphaned files: %w", err)
+	}
+
+	report.Deleted = len(orphans)
+	for _, path := range orphans {
+		report.ReclaimedBytes += candidates[path].Size
+	}
+
+	return report, nil
+}
+
+// Sweep deletes objects whose rows are gone, except those modified within the
+// grace period. An upload reusing an object refreshes it before its row
+// commits, so a recent one may be about to be referenced again; if it isn't,
+// the collector removes it on a later run. Every path that deletes released
+// objects goes through here. It returns the paths it deleted.
+func Sweep(storage domain.StorageService, paths []string, gracePeriod time.Duration) ([]string, error) {
+	cutoff := time.Now().Add(-gracePeriod)
+	var stale []string
+	for _, path := range paths {
+		objects, err := storage.ListFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recheck %s: %w", path, err)
+		}
+		for _, obj := range objects {
+			if obj.Path == path && !obj.LastModified.After(cutoff) {
+				stale = append(stale, path)
+			}
+		}
+	}
+	if err := storage.DeleteFiles(stale); err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// isStaged reports whether path is an upload still owned by its session
+func isStaged(path string) bool {
+	for _, prefix := range stagingPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunPeriodically runs the collector every interval until stop is closed
+func (c *Collector) RunPeriodically(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			report, err := c.Run(false)
+			if err != nil {
+				log.Printf("[ERROR] Garbage collection failed: %v", err)
+				continue
+			}
+			log.Printf("[INFO] Garbage collection scanned %d objects, deleted %d (%s reclaimed)",
+				report.Scanned, report.Deleted, domain.FormatFileSize(report.ReclaimedBytes))
+		case <-stop:
+			return
+		}
+	}
+}
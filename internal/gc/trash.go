@@ -0,0 +1,104 @@
+package gc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// PurgeReport summarizes a single trash purge
+type PurgeReport struct {
+	Projects int
+	Samples  int
+	Deleted  int // Storage objects removed
+}
+
+// TrashPurger permanently deletes projects and samples that have been in the
+// trash longer than the retention period, and the objects only they used
+type TrashPurger struct {
+	repo        domain.ProjectRepository
+	storage     domain.StorageService
+	retention   time.Duration
+	gracePeriod time.Duration // Objects modified this recently are left to the collector
+}
+
+// NewTrashPurger creates a purger for the given repository and storage
+func NewTrashPurger(repo domain.ProjectRepository, storage domain.StorageService, retention, gracePeriod time.Duration) *TrashPurger {
+	return &TrashPurger{
+		repo:        repo,
+		storage:     storage,
+		retention:   retention,
+		gracePeriod: gracePeriod,
+	}
+}
+
+// Run purges everything whose retention period has passed
+func (p *TrashPurger) Run() (PurgeReport, error) {
+	var report PurgeReport
+
+	trash, err := p.repo.FindExpiredTrash(time.Now().Add(-p.retention))
+	if err != nil {
+		return report, err
+	}
+
+	// Samples first: those of an expired project would otherwise be gone already
+	var orphans []string
+	for _, sample := range trash.Samples {
+		paths, err := p.repo.PurgeSampleFile(sample.ProjectID, sample.ID)
+		if errors.Is(err, common.ErrNotFound) {
+			continue // Restored or purged since
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to purge sample %d: %w", sample.ID, err)
+		}
+		orphans = append(orphans, paths...)
+		report.Samples++
+	}
+	for _, project := range trash.Projects {
+		paths, err := p.repo.PurgeProject(project.ID)
+		if errors.Is(err, common.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to purge project %d: %w", project.ID, err)
+		}
+		orphans = append(orphans, paths...)
+		report.Projects++
+	}
+
+	// The rows are gone; objects that fail to delete are left to the collector
+	deleted, err := Sweep(p.storage, orphans, p.gracePeriod)
+	if err != nil {
+		return report, fmt.Errorf("failed to delete purged files: %w", err)
+	}
+	report.Deleted = len(deleted)
+
+	return report, nil
+}
+
+// RunPeriodically runs the purger every interval until stop is closed
+func (p *TrashPurger) RunPeriodically(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			report, err := p.Run()
+			if err != nil {
+				log.Printf("[ERROR] Trash purge failed: %v", err)
+				continue
+			}
+			if report.Projects > 0 || report.Samples > 0 {
+				log.Printf("[INFO] Purged %d projects and %d samples from the trash, deleted %d objects",
+					report.Projects, report.Samples, report.Deleted)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package gc
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"dawhub/internal/domain"
+	"dawhub/internal/repository"
+)
+
+// SessionReaper discards resumable uploads that expired before completing,
+// along with the parts already held in storage
+type SessionReaper struct {
+	uploads *repository.UploadSessionRepository
+	storage domain.StorageService
+}
+
+// NewSessionReaper creates a reaper for the given upload sessions and storage
+func NewSessionReaper(uploads *repository.UploadSessionRepository, storage domain.StorageService) *SessionReaper {
+	return &SessionReaper{
+		uploads: uploads,
+		storage: storage,
+	}
+}
+
+// Run aborts every expired session and returns how many were removed
+func (r *SessionReaper) Run() (int, error) {
+	sessions, err := r.uploads.FindExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, session := range sessions {
+		var err error
+		if session.IsDirect() {
+			err = r.storage.DeleteFile(session.StagingPath)
+		} else {
+			err = r.storage.AbortMultipartUpload(session.StagingPath, session.StorageUploadID)
+		}
+		if err != nil {
+			// The storage side may already be gone; the row must still be removed
+			log.Printf("[WARN] Failed to abort expired upload %s: %v", session.ID, err)
+		}
+		if err := r.uploads.Delete(session.ID); err != nil {
+			return removed, fmt.Errorf("failed to delete upload session %s: %w", session.ID, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// RunPeriodically runs the reaper every interval until stop is closed
+func (r *SessionReaper) RunPeriodically(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := r.Run()
+			if err != nil {
+				log.Printf("[ERROR] Upload session cleanup failed: %v", err)
+				continue
+			}
+			if removed > 0 {
+				log.Printf("[INFO] Removed %d expired upload sessions", removed)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
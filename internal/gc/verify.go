@@ -0,0 +1,123 @@
+package gc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"dawhub/internal/domain"
+)
+
+// IntegrityProblem is a stored object that doesn't match the database
+type IntegrityProblem struct {
+	Path    string `json:"path"`
+	Problem string `json:"problem"`
+}
+
+// IntegrityReport summarizes a single verification pass
+type IntegrityReport struct {
+	Deep     bool               `json:"deep"`
+	Checked  int                `json:"checked"`
+	Problems []IntegrityProblem `json:"problems"`
+}
+
+// Verifier checks that every object the database knows about is in storage
+// with the recorded size and, in deep mode, the recorded hash, and that its
+// reference count matches the rows pointing at it. Objects nothing references
+// are the collector's business.
+type Verifier struct {
+	repo    domain.ProjectRepository
+	storage domain.StorageService
+}
+
+// NewVerifier creates a verifier for the given repository and storage
+func NewVerifier(repo domain.ProjectRepository, storage domain.StorageService) *Verifier {
+	return &Verifier{
+		repo:    repo,
+		storage: storage,
+	}
+}
+
+// Run verifies every blob. Deep mode downloads each object to hash it.
+func (v *Verifier) Run(deep bool) (IntegrityReport, error) {
+	report := IntegrityReport{Deep: deep, Problems: []IntegrityProblem{}}
+
+	blobs, err := v.repo.FindBlobs()
+	if err != nil {
+		return report, err
+	}
+	objects, err := v.storage.ListFiles("")
+	if err != nil {
+		return report, fmt.Errorf("failed to list storage: %w", err)
+	}
+	stored := make(map[string]domain.StoredObject, len(objects))
+	for _, obj := range objects {
+		stored[obj.Path] = obj
+	}
+	paths := make([]string, len(blobs))
+	for i, blob := range blobs {
+		paths[i] = blob.FilePath
+	}
+	references, err := v.repo.CountBlobReferences(paths)
+	if err != nil {
+		return report, fmt.Errorf("failed to count references: %w", err)
+	}
+
+	for _, blob := range blobs {
+		report.Checked++
+		problem := func(format string, args ...interface{}) {
+			report.Problems = append(report.Problems, IntegrityProblem{Path: blob.FilePath, Problem: fmt.Sprintf(format, args...)})
+		}
+
+		if count := references[blob.FilePath]; count != blob.RefCount {
+			problem("reference count is %d, but %d rows refer to it", blob.RefCount, count)
+		}
+
+		obj, ok := stored[blob.FilePath]
+		if !ok {
+			problem("missing from storage")
+			continue
+		}
+		if obj.Size != blob.Size {
+			problem("size is %d, expected %d", obj.Size, blob.Size)
+			continue
+		}
+		if !deep || blob.Hash == "" {
+			continue
+		}
+
+		// Storage checks the hash itself while reading
+		hash, err := v.hash(blob.FilePath)
+		if errors.Is(err, domain.ErrInvalidHash) {
+			problem("content doesn't match hash %s", blob.Hash)
+			continue
+		}
+		if err != nil {
+			problem("unreadable: %v", err)
+			continue
+		}
+		if hash != strings.ToLower(blob.Hash) {
+			problem("content doesn't match hash %s", blob.Hash)
+		}
+	}
+
+	return report, nil
+}
+
+// hash returns the SHA-256 of an object's content
+func (v *Verifier) hash(path string) (string, error) {
+	reader, _, err := v.storage.GetFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+)
+
+// Archive handles GET /projects/:id/archive, streaming the main file and all
+// samples as a zip together with a manifest for lossless re-import
+func (h *ProjectHandler) Archive(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	manifest := domain.NewArchiveManifest(project)
+	if revisions, err := h.repo.FindRevisions(project.ID); err == nil && len(revisions) > 0 {
+		manifest.Revision = revisions[0].Number
+	}
+
+	c.Header("Content-Disposition", attachment(project.Name+".zip"))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	// From here on the status is sent; a failure can only cut the stream short,
+	// which leaves the client with an unreadable zip rather than a bad one
+	archive := zip.NewWriter(c.Writer)
+	if err := writeArchiveManifest(archive, manifest); err != nil {
+		log.Printf("Failed to write archive manifest for project %d: %v", project.ID, err)
+		return
+	}
+
+	for _, entry := range manifest.Files {
+		if err := h.writeArchiveEntry(archive, entry); err != nil {
+			log.Printf("Failed to archive %s for project %d: %v", entry.Path, project.ID, err)
+			panic(http.ErrAbortHandler) // Reset the connection so the download visibly fails
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		log.Printf("Failed to finish archive for project %d: %v", project.ID, err)
+	}
+}
+
+func writeArchiveManifest(archive *zip.Writer, manifest domain.ArchiveManifest) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     domain.ArchiveManifestName,
+		Method:   zip.Deflate,
+		Modified: manifest.ExportedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(manifest)
+}
+
+// writeArchiveEntry copies one stored object into the archive, checking it
+// against the recorded hash on the way
+func (h *ProjectHandler) writeArchiveEntry(archive *zip.Writer, entry domain.ArchiveEntry) error {
+	obj, _, err := h.storage.GetFile(entry.FilePath)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	// Audio is already compressed, so store it as is
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:   entry.Path,
+		Method: zip.Store,
+	})
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(w, io.TeeReader(obj, hash)); err != nil {
+		return err
+	}
+	if entry.Hash != "" && hex.EncodeToString(hash.Sum(nil)) != entry.Hash {
+		return domain.ErrInvalidHash
+	}
+
+	return nil
+}
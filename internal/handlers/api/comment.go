@@ -0,0 +1,172 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+type commentRequest struct {
+	FileKind  string   `json:"file_kind"` // "main" or "sample"; ignored for replies
+	FileID    uint     `json:"file_id"`
+	ParentID  *uint    `json:"parent_id"`
+	Body      string   `json:"body" binding:"required"`
+	Timestamp *float64 `json:"timestamp"` // Seconds into the file
+}
+
+type resolveRequest struct {
+	Resolved bool `json:"resolved"`
+}
+
+// ListComments handles GET /projects/:id/comments
+func (h *ProjectHandler) ListComments(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	comments, err := h.repo.FindComments(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// CreateComment handles POST /projects/:id/comments to comment on a file or reply to a comment
+func (h *ProjectHandler) CreateComment(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionComment)
+	if !ok {
+		return
+	}
+
+	var req commentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var parent *domain.Comment
+	if req.ParentID != nil {
+		var err error
+		if parent, err = h.repo.FindComment(project.ID, *req.ParentID); err != nil {
+			if errors.Is(err, common.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Parent comment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment"})
+			return
+		}
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	comment, err := domain.NewComment(project, parent, userID, req.FileKind, req.FileID, req.Body, req.Timestamp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.repo.CreateComment(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ResolveComment handles PATCH /projects/:id/comments/:commentId to resolve
+// or reopen a comment. Editors and the comment's author may do this.
+func (h *ProjectHandler) ResolveComment(c *gin.Context) {
+	project, comment, role, ok := h.authorizeComment(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if comment.UserID != userID && !role.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to resolve this comment"})
+		return
+	}
+
+	var req resolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.ResolveComment(project.ID, comment.ID, userID, req.Resolved); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": comment.ID, "resolved": req.Resolved})
+}
+
+// DeleteComment handles DELETE /projects/:id/comments/:commentId. The author
+// and the project owner may delete a comment; its replies go with it.
+func (h *ProjectHandler) DeleteComment(c *gin.Context) {
+	project, comment, role, ok := h.authorizeComment(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if comment.UserID != userID && role != domain.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to delete this comment"})
+		return
+	}
+
+	if err := h.repo.DeleteComment(project.ID, comment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// authorizeComment resolves the project and comment named in the URL for a
+// caller allowed to comment, returning the caller's role on the project
+func (h *ProjectHandler) authorizeComment(c *gin.Context) (*domain.Project, *domain.Comment, domain.ProjectRole, bool) {
+	project, ok := h.authorizeProject(c, authz.ActionComment)
+	if !ok {
+		return nil, nil, "", false
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return nil, nil, "", false
+	}
+
+	comment, err := h.repo.FindComment(project.ID, uint(commentID))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return nil, nil, "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment"})
+		return nil, nil, "", false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	role, err := h.authz.Role(userID, project)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return nil, nil, "", false
+	}
+
+	return project, comment, role, true
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/domain"
+)
+
+// byteRange is an inclusive range of bytes within a file
+type byteRange struct {
+	start, end int64
+}
+
+func (r byteRange) length() int64 {
+	return r.end - r.start + 1
+}
+
+// serveFile streams a stored file, honouring conditional requests and a
+// single byte range so players can seek without downloading the whole file
+func (h *ProjectHandler) serveFile(c *gin.Context, filePath string, metadata domain.FileMetadata) {
+	etag := fileETag(metadata.Hash)
+	lastModified := metadata.UploadedAt.UTC().Truncate(time.Second)
+
+	contentType := metadata.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("Accept-Ranges", "bytes")
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// Serve the whole file unless a usable range was requested
+	var requested *byteRange
+	if header := c.GetHeader("Range"); header != "" && rangeStillValid(c.GetHeader("If-Range"), etag, lastModified) {
+		r, satisfiable, ok := parseByteRange(header, metadata.Size)
+		if ok && !satisfiable {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", metadata.Size))
+			c.Status(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if ok {
+			requested = &r
+		}
+	}
+
+	c.Header("Content-Disposition", attachment(metadata.Filename))
+	c.Header("Content-Type", contentType)
+
+	status := http.StatusOK
+	length := metadata.Size
+	if requested != nil {
+		status = http.StatusPartialContent
+		length = requested.length()
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", requested.start, requested.end, metadata.Size))
+	}
+	c.Header("Content-Length", strconv.FormatInt(length, 10))
+
+	if c.Request.Method == http.MethodHead {
+		c.Status(status)
+		return
+	}
+
+	var obj io.ReadCloser
+	var err error
+	if requested != nil {
+		obj, err = h.storage.GetFileRange(filePath, requested.start, requested.end)
+	} else {
+		obj, _, err = h.storage.GetFile(filePath)
+	}
+	if err != nil {
+		c.Header("Content-Length", "")
+		c.Header("Content-Range", "")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file"})
+		return
+	}
+	defer obj.Close()
+
+	// Stream the file to the client. Reading to the end lets storage check
+	// the hash; a damaged file aborts the response so the transfer fails
+	// instead of completing with bad content.
+	c.Status(status)
+	if _, err := io.Copy(c.Writer, obj); err != nil {
+		log.Printf("Error streaming file: %v", err)
+		if errors.Is(err, domain.ErrInvalidHash) {
+			panic(http.ErrAbortHandler)
+		}
+		return
+	}
+}
+
+// isFreshDownload reports whether a request fetches a file from the start.
+// Range requests from players seeking, HEAD probes and revalidations are not
+// counted as downloads.
+func isFreshDownload(c *gin.Context) bool {
+	return c.Request.Method == http.MethodGet &&
+		c.GetHeader("Range") == "" &&
+		c.GetHeader("If-None-Match") == "" &&
+		c.GetHeader("If-Modified-Since") == ""
+}
+
+// fileETag derives a strong entity tag from the stored content hash
+func fileETag(hash string) string {
+	if hash == "" {
+		return ""
+	}
+	return `"` + hash + `"`
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := r.Header.Get("If-Modified-Since"); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		return err == nil && !lastModified.After(since)
+	}
+
+	return false
+}
+
+// rangeStillValid reports whether a Range request applies, given its If-Range
+// precondition. If-Range needs a strong ETag or an exact date match.
+func rangeStillValid(ifRange, etag string, lastModified time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return etag != "" && ifRange == etag
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	date, err := http.ParseTime(ifRange)
+	return err == nil && date.Equal(lastModified)
+}
+
+// parseByteRange parses a single-range Range header against a file of the
+// given size. ok is false when the header should be ignored (malformed, not
+// bytes, or several ranges), in which case the whole file is served.
+func parseByteRange(header string, size int64) (r byteRange, satisfiable bool, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return r, false, false
+	}
+
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return r, false, false
+	}
+
+	if first == "" {
+		// Suffix range: the final N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return r, false, false
+		}
+		if n == 0 || size == 0 {
+			return r, false, true
+		}
+		if n > size {
+			n = size
+		}
+		return byteRange{start: size - n, end: size - 1}, true, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return r, false, false
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return r, false, false
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return r, false, true
+	}
+
+	return byteRange{start: start, end: end}, true, true
+}
+
+// attachment builds a Content-Disposition header offering the response as a
+// download under the given name. Quotes and non-ASCII names are escaped.
+func attachment(filename string) string {
+	if header := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); header != "" {
+		return header
+	}
+	return "attachment"
+}
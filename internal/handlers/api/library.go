@@ -0,0 +1,348 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+type libraryItemRequest struct {
+	Folder *string   `json:"folder"`
+	Tags   *[]string `json:"tags"`
+}
+
+type attachLibraryRequest struct {
+	ItemIDs []uint `json:"item_ids" binding:"required"`
+}
+
+// ListLibrary handles GET /library to list the caller's sample library,
+// optionally limited to a folder and its subfolders, or to a tag
+func (h *ProjectHandler) ListLibrary(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	folder, err := domain.NormalizeFolder(c.Query("folder"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.repo.FindLibraryItems(userID, folder, c.Query("tag"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library"})
+		return
+	}
+	folders, err := h.repo.FindLibraryFolders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "folders": folders})
+}
+
+// UploadLibraryItem handles POST /library to add an audio file to the
+// caller's library. The multipart form takes the file, a folder and
+// comma-separated tags.
+func (h *ProjectHandler) UploadLibraryItem(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	if c.Request.ContentLength > maxUploadRequestSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadRequestSize)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+
+	var fileInfo domain.FileInfo
+	var filePath, folder, tags string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if filePath != "" {
+				h.discardUpload(h.repo, filePath)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload"})
+			return
+		}
+
+		switch part.FormName() {
+		case "folder":
+			value, _ := io.ReadAll(io.LimitReader(part, domain.MaxFolderLength+1))
+			folder = string(value)
+		case "tags":
+			value, _ := io.ReadAll(io.LimitReader(part, maxMessageLength))
+			tags = string(value)
+		case "file":
+			if filePath != "" {
+				part.Close()
+				h.discardUpload(h.repo, filePath)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Only one file per upload"})
+				return
+			}
+			fileInfo, filePath, err = h.storage.UploadFile(part.FileName(), part)
+			if err != nil {
+				part.Close()
+				c.JSON(uploadErrorStatus(err), gin.H{"error": "Failed to upload file"})
+				return
+			}
+		}
+		part.Close()
+	}
+
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+
+	item, err := domain.NewLibraryItem(userID, folder, fileInfo, filePath)
+	if err == nil {
+		err = h.createLibraryItem(item, strings.Split(tags, ","))
+	}
+	if err != nil {
+		h.discardUpload(h.repo, filePath)
+		c.JSON(libraryErrorStatus(err), gin.H{"error": libraryErrorMessage(err, "Failed to save file information")})
+		return
+	}
+
+	item, err = h.repo.FindLibraryItem(userID, item.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library item"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// createLibraryItem saves a library item with its tags
+func (h *ProjectHandler) createLibraryItem(item *domain.LibraryItem, tags []string) error {
+	tx, err := h.repo.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.CreateLibraryItem(item); err != nil {
+		return err
+	}
+	if err := tx.SetLibraryItemTags(item.ID, tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateLibraryItem handles PATCH /library/:itemId to move an item to
+// another folder or replace its tags
+func (h *ProjectHandler) UpdateLibraryItem(c *gin.Context) {
+	item, ok := h.findLibraryItem(c)
+	if !ok {
+		return
+	}
+
+	var req libraryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var err error
+	if req.Folder != nil {
+		err = h.repo.MoveLibraryItem(item.UserID, item.ID, *req.Folder)
+	}
+	if err == nil && req.Tags != nil {
+		err = h.repo.SetLibraryItemTags(item.ID, *req.Tags)
+	}
+	if err != nil {
+		c.JSON(libraryErrorStatus(err), gin.H{"error": libraryErrorMessage(err, "Failed to update library item")})
+		return
+	}
+
+	item, err = h.repo.FindLibraryItem(item.UserID, item.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteLibraryItem handles DELETE /library/:itemId. An item attached to
+// projects is only deleted with ?force=true; otherwise the projects using it
+// are returned with a 409. Those projects keep their copies of the sample.
+func (h *ProjectHandler) DeleteLibraryItem(c *gin.Context) {
+	item, ok := h.findLibraryItem(c)
+	if !ok {
+		return
+	}
+
+	if c.Query("force") != "true" {
+		projects, err := h.repo.FindLibraryItemUsage(item.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check library item usage"})
+			return
+		}
+		if len(projects) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    domain.ErrLibraryItemInUse.Message,
+				"projects": projects,
+			})
+			return
+		}
+	}
+
+	if err := h.repo.DeleteLibraryItem(item.UserID, item.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete library item"})
+		return
+	}
+	h.discardUpload(h.repo, item.FilePath)
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// AttachLibraryItems handles POST /projects/:id/library to add items from
+// the caller's library to a project as samples, without copying them
+func (h *ProjectHandler) AttachLibraryItems(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	var req attachLibraryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	items := make([]domain.LibraryItem, 0, len(req.ItemIDs))
+	for _, id := range req.ItemIDs {
+		item, err := h.repo.FindLibraryItem(userID, id)
+		if err != nil {
+			if errors.Is(err, common.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Library item %d not found", id)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library item"})
+			return
+		}
+		items = append(items, *item)
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No library items given"})
+		return
+	}
+
+	attached, err := h.attachLibraryItems(project.ID, userID, items)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err), gin.H{"error": libraryErrorMessage(err, "Failed to attach library items")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attached": attached})
+}
+
+// attachLibraryItems attaches items to a project and records a revision
+func (h *ProjectHandler) attachLibraryItems(projectID, userID uint, items []domain.LibraryItem) (int, error) {
+	tx, err := h.repo.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	attached, err := tx.AttachLibraryItems(projectID, items)
+	if err != nil || attached == 0 {
+		return 0, err
+	}
+
+	message := fmt.Sprintf("Added %d samples from library", attached)
+	if attached == 1 {
+		message = "Added 1 sample from library"
+	}
+	if _, err := tx.CreateRevision(projectID, userID, message); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return attached, nil
+}
+
+// findLibraryItem resolves the caller's library item named in the URL
+func (h *ProjectHandler) findLibraryItem(c *gin.Context) (*domain.LibraryItem, bool) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return nil, false
+	}
+
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid library item ID"})
+		return nil, false
+	}
+
+	item, err := h.repo.FindLibraryItem(userID, uint(itemID))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Library item not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library item"})
+		return nil, false
+	}
+
+	return item, true
+}
+
+// libraryErrorStatus maps a library operation error to an HTTP status
+func libraryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotAudio):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrInvalidFolder), errors.Is(err, domain.ErrTooManyTags),
+		errors.Is(err, domain.ErrTooManySamples):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrProjectTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, common.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// libraryErrorMessage exposes domain errors and hides the rest behind fallback
+func libraryErrorMessage(err error, fallback string) string {
+	var projectErr domain.ProjectError
+	if errors.As(err, &projectErr) {
+		return projectErr.Message
+	}
+	return fallback
+}
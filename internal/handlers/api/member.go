@@ -0,0 +1,256 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/email"
+	"dawhub/internal/middleware"
+	"dawhub/internal/repository"
+	"dawhub/pkg/common"
+)
+
+// MemberHandler handles HTTP requests for project collaborators
+type MemberHandler struct {
+	repo         domain.ProjectRepository
+	users        *repository.UserRepository
+	authz        *authz.Authorizer
+	emailService *email.ResendService
+}
+
+// NewMemberHandler creates a new member handler
+func NewMemberHandler(repo domain.ProjectRepository, users *repository.UserRepository, emailService *email.ResendService) *MemberHandler {
+	return &MemberHandler{
+		repo:         repo,
+		users:        users,
+		authz:        authz.NewAuthorizer(repo),
+		emailService: emailService,
+	}
+}
+
+type inviteRequest struct {
+	Identifier string             `json:"identifier" binding:"required"` // Username or email
+	Role       domain.ProjectRole `json:"role" binding:"required"`
+}
+
+type roleRequest struct {
+	Role domain.ProjectRole `json:"role" binding:"required"`
+}
+
+// List handles GET /projects/:id/members. Pending invites are only shown to the owner.
+func (h *MemberHandler) List(c *gin.Context) {
+	project, ok := authorizeProject(c, h.authz, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	members, err := h.repo.FindMembers(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+		return
+	}
+
+	response := gin.H{"members": members}
+	userID, _ := middleware.CurrentUserID(c)
+	if userID == project.UserID {
+		invites, err := h.repo.FindPendingInvites(project.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invites"})
+			return
+		}
+		response["invites"] = invites
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Invite handles POST /projects/:id/members to email an invite by username or email
+func (h *MemberHandler) Invite(c *gin.Context) {
+	project, ok := authorizeProject(c, h.authz, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	var req inviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !domain.IsValidMemberRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be editor or viewer"})
+		return
+	}
+
+	// Registered users are invited at their account address
+	identifier := strings.TrimSpace(req.Identifier)
+	invitee, err := h.users.GetByUsername(identifier)
+	if err != nil && strings.Contains(identifier, "@") {
+		invitee, err = h.users.GetByEmail(identifier)
+	}
+	address := identifier
+	registered := err == nil
+	switch {
+	case registered:
+		address = invitee.Email
+		if invitee.ID == project.UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The owner cannot be invited"})
+			return
+		}
+		if _, err := h.repo.FindMember(project.ID, invitee.ID); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+			return
+		}
+	case !strings.Contains(identifier, "@"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	inviterID, _ := middleware.CurrentUserID(c)
+	invite, token, err := domain.NewProjectInvite(project.ID, address, req.Role, inviterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+	if registered {
+		invite.SetInvitee(invitee)
+	}
+	if err := h.repo.CreateInvite(invite); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	inviterName := "A DawHub user"
+	if inviter, err := h.users.GetByID(inviterID); err == nil {
+		inviterName = inviter.Username
+	}
+	if err := h.emailService.SendProjectInviteEmail(address, inviterName, project.Name, req.Role, token); err != nil {
+		if err := h.repo.DeleteInvite(project.ID, invite.ID); err != nil {
+			log.Printf("Failed to delete unsent invite %d: %v", invite.ID, err)
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send invite email"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// UpdateRole handles PUT /projects/:id/members/:userId to change a collaborator's role
+func (h *MemberHandler) UpdateRole(c *gin.Context) {
+	project, ok := authorizeProject(c, h.authz, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req roleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !domain.IsValidMemberRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be editor or viewer"})
+		return
+	}
+
+	if err := h.repo.UpdateMemberRole(project.ID, uint(memberID), req.Role); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": memberID, "role": req.Role})
+}
+
+// Remove handles DELETE /projects/:id/members/:userId. The owner can remove
+// anyone; members can remove themselves.
+func (h *MemberHandler) Remove(c *gin.Context) {
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	action := authz.ActionManage
+	if userID, _ := middleware.CurrentUserID(c); userID == uint(memberID) {
+		action = authz.ActionRead
+	}
+	project, ok := authorizeProject(c, h.authz, action)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RemoveMember(project.ID, uint(memberID)); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "removed"})
+}
+
+// RevokeInvite handles DELETE /projects/:id/invites/:inviteId
+func (h *MemberHandler) RevokeInvite(c *gin.Context) {
+	project, ok := authorizeProject(c, h.authz, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.ParseUint(c.Param("inviteId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	if err := h.repo.DeleteInvite(project.ID, uint(inviteID)); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
+// Accept handles POST /invites/:token/accept to join a project
+func (h *MemberHandler) Accept(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	member, err := h.repo.AcceptInvite(domain.HashInviteToken(c.Param("token")), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInviteInvalid):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invite is invalid or expired"})
+		case errors.Is(err, domain.ErrAlreadyMember):
+			c.JSON(http.StatusConflict, gin.H{"error": "You already own this project"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invite"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
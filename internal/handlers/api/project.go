@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,31 +11,78 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"dawhub/internal/authz"
 	"dawhub/internal/domain"
+	"dawhub/internal/gc"
+	"dawhub/internal/middleware"
+	"dawhub/internal/repository"
+	"dawhub/pkg/common"
+)
+
+const (
+	// maxUploadRequestSize leaves room for multipart framing around a maximum-size file
+	maxUploadRequestSize = domain.MaxFileSize + 1024*1024
+
+	maxMessageLength = 1024
 )
 
 // ProjectHandler handles HTTP requests for project operations
 type ProjectHandler struct {
-	repo    domain.ProjectRepository
-	storage domain.StorageService
+	repo      domain.ProjectRepository
+	authz     *authz.Authorizer
+	storage   domain.StorageService
+	uploads   *repository.UploadSessionRepository
+	uploadTTL time.Duration
+
+	gracePeriod time.Duration // Released objects modified this recently are left to the collector
 }
 
-// NewProjectHandler creates a new project handler with the given repository and storage service
-func NewProjectHandler(repo domain.ProjectRepository, storage domain.StorageService) *ProjectHandler {
+// NewProjectHandler creates a new project handler with the given repositories and storage service
+func NewProjectHandler(repo domain.ProjectRepository, storage domain.StorageService, uploads *repository.UploadSessionRepository, uploadTTL, gracePeriod time.Duration) *ProjectHandler {
 	return &ProjectHandler{
-		repo:    repo,
-		storage: storage,
+		repo:        repo,
+		authz:       authz.NewAuthorizer(repo),
+		storage:     storage,
+		uploads:     uploads,
+		uploadTTL:   uploadTTL,
+		gracePeriod: gracePeriod,
 	}
 }
 
+// projectRequest holds the project fields clients may set. Files, tags,
+// ownership and activity counters all have their own endpoints or are
+// maintained by the server.
+type projectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+func (r projectRequest) apply(project *domain.Project) {
+	project.Name = r.Name
+	project.Description = r.Description
+	project.Version = r.Version
+	project.IsPublic = r.IsPublic
+}
+
 // Create handles POST /projects to create a new project
 func (h *ProjectHandler) Create(c *gin.Context) {
-	var project domain.Project
-	if err := c.ShouldBindJSON(&project); err != nil {
+	var req projectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// The caller owns what they create
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	project := domain.Project{UserID: userID}
+	req.apply(&project)
+
 	if err := h.repo.Create(&project); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
 		return
@@ -43,28 +91,29 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, project)
 }
 
-// List handles GET /projects to retrieve all projects
+// List handles GET /projects to page through the projects the caller owns or
+// collaborates on. See domain.ParseProjectListOptions for the parameters.
 func (h *ProjectHandler) List(c *gin.Context) {
-	projects, err := h.repo.FindAll()
+	opts, err := domain.ParseProjectListOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.UserID, _ = middleware.CurrentUserID(c)
+
+	page, err := h.repo.ListProjects(opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
 		return
 	}
 
-	c.JSON(http.StatusOK, projects)
+	c.JSON(http.StatusOK, page)
 }
 
 // Get handles GET /projects/:id to retrieve a specific project
 func (h *ProjectHandler) Get(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
-		return
-	}
-
-	project, err := h.repo.FindByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
 		return
 	}
 
@@ -73,22 +122,23 @@ func (h *ProjectHandler) Get(c *gin.Context) {
 
 // Update handles PUT /projects/:id to modify an existing project
 func (h *ProjectHandler) Update(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
 		return
 	}
 
-	project, err := h.repo.FindByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-		return
+	// Fields left out of the body keep their current values
+	req := projectRequest{
+		Name:        project.Name,
+		Description: project.Description,
+		Version:     project.Version,
+		IsPublic:    project.IsPublic,
 	}
-
-	if err := c.ShouldBindJSON(project); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.apply(project)
 
 	if err := h.repo.Update(project); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
@@ -98,15 +148,14 @@ func (h *ProjectHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, project)
 }
 
-// Delete handles DELETE /projects/:id to remove a project
+// Delete handles DELETE /projects/:id to move a project to the trash
 func (h *ProjectHandler) Delete(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+	project, ok := h.authorizeProject(c, authz.ActionDelete)
+	if !ok {
 		return
 	}
 
-	if err := h.repo.Delete(uint(id)); err != nil {
+	if err := h.repo.Delete(project.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
 		return
 	}
@@ -116,69 +165,124 @@ func (h *ProjectHandler) Delete(c *gin.Context) {
 
 // Upload handles POST /projects/:id/upload for file uploads
 func (h *ProjectHandler) Upload(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
 		return
 	}
 
-	project, err := h.repo.FindByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	// Reject oversized requests before reading the body
+	if c.Request.ContentLength > maxUploadRequestSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
 		return
 	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadRequestSize)
 
-	file, header, err := c.Request.FormFile("file")
+	// Stream the multipart body part by part so the file goes straight to storage
+	reader, err := c.Request.MultipartReader()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
 		return
 	}
-	defer file.Close()
 
-	// Start transaction
+	var fileInfo domain.FileInfo
+	var filePath, message string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if filePath != "" {
+				h.discardUpload(h.repo, filePath)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload"})
+			return
+		}
+
+		switch part.FormName() {
+		case "message":
+			value, _ := io.ReadAll(io.LimitReader(part, maxMessageLength))
+			message = string(value)
+		case "file":
+			if filePath != "" {
+				part.Close()
+				h.discardUpload(h.repo, filePath)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Only one file per upload"})
+				return
+			}
+			fileInfo, filePath, err = h.storage.UploadFile(part.FileName(), part)
+			if err != nil {
+				part.Close()
+				c.JSON(uploadErrorStatus(err), gin.H{"error": "Failed to upload file"})
+				return
+			}
+		}
+		part.Close()
+	}
+
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if err := h.recordUpload(project.ID, userID, domain.RevisionFileSample, fileInfo, filePath, message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file information"})
+		return
+	}
+
+	h.respondUploaded(c, fileInfo, filePath)
+}
+
+// recordUpload attaches a stored file to the project as its main file or a
+// sample and records a revision. The upload is discarded if anything fails.
+func (h *ProjectHandler) recordUpload(projectID, userID uint, kind string, fileInfo domain.FileInfo, filePath, message string) error {
 	tx, err := h.repo.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
-		return
+		h.discardUpload(h.repo, filePath)
+		return err
 	}
 	defer tx.Rollback()
 
-	// Upload file and get metadata
-	fileInfo, filePath, err := h.storage.UploadFile(project.ID, header.Filename, file)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
-		return
+	metadata := domain.FileMetadata{
+		Size:        fileInfo.Size,
+		Filename:    fileInfo.Filename,
+		ContentType: fileInfo.ContentType,
+		Hash:        fileInfo.Hash,
+		UploadedAt:  time.Now(),
 	}
 
-	// Create SampleFile record
-	sampleFile := &domain.SampleFile{
-		ProjectID: project.ID,
-		FileMetadata: domain.FileMetadata{
-			Size:        fileInfo.Size,
-			Filename:    fileInfo.Filename,
-			ContentType: fileInfo.ContentType,
-			Hash:        fileInfo.Hash,
-			UploadedAt:  time.Now(),
-		},
-		FilePath: filePath,
+	if kind == domain.RevisionFileMain {
+		err = tx.AddMainFile(projectID, &domain.ProjectFile{FileMetadata: metadata, FilePath: filePath})
+	} else {
+		err = tx.AddSampleFile(projectID, &domain.SampleFile{ProjectID: projectID, FileMetadata: metadata, FilePath: filePath})
+	}
+	if err != nil {
+		tx.Rollback()
+		h.discardUpload(h.repo, filePath)
+		return err
 	}
 
-	// Add sample file to project
-	if err := tx.AddSampleFile(project.ID, sampleFile); err != nil {
-		// Cleanup uploaded file on error
-		h.storage.DeleteFile(filePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file information"})
-		return
+	// Record the change in the project's history
+	if message == "" {
+		message = fmt.Sprintf("Added %s", fileInfo.Filename)
+	}
+	if _, err := tx.CreateRevision(projectID, userID, message); err != nil {
+		tx.Rollback()
+		h.discardUpload(h.repo, filePath)
+		return err
 	}
 
-	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		h.storage.DeleteFile(filePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
-		return
+		h.discardUpload(h.repo, filePath)
+		return err
 	}
 
-	// Return success response with HTMX
+	return nil
+}
+
+// respondUploaded reports a stored file, as an HTMX fragment when requested
+func (h *ProjectHandler) respondUploaded(c *gin.Context, fileInfo domain.FileInfo, filePath string) {
 	if c.GetHeader("HX-Request") == "true" {
 		c.HTML(http.StatusOK, "upload-response", gin.H{
 			"success": true,
@@ -205,23 +309,22 @@ func (h *ProjectHandler) Upload(c *gin.Context) {
 
 // Download handles GET /projects/:id/download to serve project files
 func (h *ProjectHandler) Download(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
 		return
 	}
 
-	project, err := h.repo.FindByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-		return
-	}
+	h.downloadFile(c, project)
+}
 
+// downloadFile serves the project file chosen by the type and fileId query
+// parameters. Callers are responsible for checking access to the project.
+func (h *ProjectHandler) downloadFile(c *gin.Context, project *domain.Project) {
 	fileType := c.Query("type")
 	fileId := c.Query("fileId")
 
 	var filePath string
-	var fileName string
+	var metadata domain.FileMetadata
 
 	switch fileType {
 	case "main":
@@ -230,7 +333,7 @@ func (h *ProjectHandler) Download(c *gin.Context) {
 			return
 		}
 		filePath = project.MainFile.FilePath
-		fileName = project.MainFile.Filename
+		metadata = project.MainFile.FileMetadata
 	case "sample":
 		if fileId == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "File ID is required for sample files"})
@@ -256,27 +359,79 @@ func (h *ProjectHandler) Download(c *gin.Context) {
 			return
 		}
 		filePath = sampleFile.FilePath
-		fileName = sampleFile.Filename
+		metadata = sampleFile.FileMetadata
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type"})
 		return
 	}
 
-	obj, fileInfo, err := h.storage.GetFile(filePath)
+	if isFreshDownload(c) {
+		if err := h.repo.RecordDownload(project.ID); err != nil {
+			log.Printf("[WARN] Failed to record download of project %d: %v", project.ID, err)
+		}
+	}
+
+	h.serveFile(c, filePath, metadata)
+}
+
+// authorizeProject resolves the project named in the URL and checks that the
+// caller may perform action on it, responding with 400, 403 or 404 otherwise
+func (h *ProjectHandler) authorizeProject(c *gin.Context, action authz.Action) (*domain.Project, bool) {
+	return authorizeProject(c, h.authz, action)
+}
+
+func authorizeProject(c *gin.Context, authorizer *authz.Authorizer, action authz.Action) (*domain.Project, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file"})
-		return
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return nil, false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	project, err := authorizer.Authorize(userID, uint(id), action)
+	if err != nil {
+		respondAuthzError(c, err)
+		return nil, false
 	}
-	defer obj.Close()
 
-	// Set headers for download
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Length", fmt.Sprintf("%d", fileInfo.Size))
+	return project, true
+}
+
+// respondAuthzError reports a failed authorization with a consistent status
+func respondAuthzError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, common.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to change this project"})
+	case errors.Is(err, common.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+	}
+}
 
-	// Stream the file to the client
-	if _, err := io.Copy(c.Writer, obj); err != nil {
-		log.Printf("Error streaming file: %v", err)
+// discardUpload deletes an uploaded object unless another file row still references it
+func (h *ProjectHandler) discardUpload(repo domain.ProjectRepository, filePath string) {
+	orphans, err := repo.ReleaseBlobs([]string{filePath})
+	if err != nil {
+		log.Printf("Failed to release upload %s: %v", filePath, err)
 		return
 	}
+	if _, err := gc.Sweep(h.storage, orphans, h.gracePeriod); err != nil {
+		log.Printf("Failed to delete upload %s: %v", filePath, err)
+	}
+}
+
+// uploadErrorStatus maps a storage upload error to an HTTP status
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, domain.ErrFileTooLarge), errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrInvalidFileType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrInvalidHash), errors.Is(err, domain.ErrUploadSizeMismatch):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
 }
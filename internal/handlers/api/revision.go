@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// ListRevisions handles GET /projects/:id/revisions to retrieve a project's history
+func (h *ProjectHandler) ListRevisions(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.repo.FindRevisions(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision handles GET /projects/:id/revisions/:number to retrieve a single revision
+func (h *ProjectHandler) GetRevision(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	revision, err := h.repo.FindRevision(project.ID, number)
+	if err != nil {
+		if common.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// Compare handles GET /projects/:id/compare?from=&to= to diff two revisions
+func (h *ProjectHandler) Compare(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	fromNumber, err := strconv.Atoi(c.Query("from"))
+	if err != nil || fromNumber <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' revision"})
+		return
+	}
+
+	toNumber, err := strconv.Atoi(c.Query("to"))
+	if err != nil || toNumber <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' revision"})
+		return
+	}
+
+	from, err := h.repo.FindRevision(project.ID, fromNumber)
+	if err != nil {
+		if common.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+		return
+	}
+
+	to, err := h.repo.FindRevision(project.ID, toNumber)
+	if err != nil {
+		if common.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.DiffRevisions(from, to))
+}
+
+// Restore handles POST /projects/:id/revisions/:number/restore to make an earlier revision current
+func (h *ProjectHandler) Restore(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	revision, err := h.repo.RestoreRevision(project.ID, number, userID)
+	if err != nil {
+		if common.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore revision"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
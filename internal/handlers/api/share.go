@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+type shareLinkRequest struct {
+	Label         string `json:"label"`
+	Password      string `json:"password"`
+	AllowDownload bool   `json:"allow_download"`
+	ExpiresInDays int    `json:"expires_in_days"` // Zero for a link that never expires
+}
+
+// ListShareLinks handles GET /projects/:id/shares
+func (h *ProjectHandler) ListShareLinks(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	links, err := h.repo.FindShareLinks(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch share links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// CreateShareLink handles POST /projects/:id/shares. The token is only
+// returned here; it cannot be recovered later.
+func (h *ProjectHandler) CreateShareLink(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	var req shareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must not be negative"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	link, token, err := domain.NewShareLink(project.ID, userID, domain.ShareLinkOptions{
+		Label:         req.Label,
+		Password:      req.Password,
+		AllowDownload: req.AllowDownload,
+		ExpiresIn:     time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+	if err := h.repo.CreateShareLink(link); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"link":  link,
+		"token": token,
+		"path":  "/s/" + token,
+	})
+}
+
+// RevokeShareLink handles DELETE /projects/:id/shares/:shareId
+func (h *ProjectHandler) RevokeShareLink(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	linkID, err := strconv.ParseUint(c.Param("shareId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link ID"})
+		return
+	}
+
+	if err := h.repo.RevokeShareLink(project.ID, uint(linkID)); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
+// SharedDownload handles GET /shares/:token/download, serving a file of a
+// shared project to anyone holding the link
+func (h *ProjectHandler) SharedDownload(c *gin.Context) {
+	link, project, err := h.authz.AuthorizeShare(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, domain.ErrShareLinkInvalid) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share link is invalid or has expired"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve share link"})
+		return
+	}
+
+	if !middleware.ShareUnlocked(c, link) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "This link requires a password"})
+		return
+	}
+	if !link.AllowDownload {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Downloads are not allowed through this link"})
+		return
+	}
+
+	if isFreshDownload(c) {
+		if err := h.repo.RecordShareLinkUse(link.ID); err != nil {
+			log.Printf("[WARN] Failed to record use of share link %d: %v", link.ID, err)
+		}
+	}
+
+	h.downloadFile(c, project)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/middleware"
+)
+
+const (
+	defaultTrendingLimit = 20
+	maxTrendingLimit     = 100
+)
+
+// Star handles PUT /projects/:id/star
+func (h *ProjectHandler) Star(c *gin.Context) {
+	h.setStarred(c, true)
+}
+
+// Unstar handles DELETE /projects/:id/star
+func (h *ProjectHandler) Unstar(c *gin.Context) {
+	h.setStarred(c, false)
+}
+
+func (h *ProjectHandler) setStarred(c *gin.Context, starred bool) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var err error
+	if starred {
+		err = h.repo.StarProject(project.ID, userID)
+	} else {
+		err = h.repo.UnstarProject(project.ID, userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update star"})
+		return
+	}
+
+	// Reload for the current count
+	project, err = h.repo.FindByID(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"starred": starred, "star_count": project.StarCount})
+}
+
+// ListStarred handles GET /starred to list the projects the caller starred
+func (h *ProjectHandler) ListStarred(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projects, err := h.repo.FindStarred(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch starred projects"})
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+// Trending handles GET /projects/trending to rank public projects by recent activity
+func (h *ProjectHandler) Trending(c *gin.Context) {
+	limit := defaultTrendingLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(n, maxTrendingLimit)
+	}
+
+	projects, err := h.repo.FindTrending(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending projects"})
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
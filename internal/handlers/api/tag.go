@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+type tagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// ListTags handles GET /tags to list the tags on public projects with their
+// project counts. The curated taxonomy is included for tag pickers.
+func (h *ProjectHandler) ListTags(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	tags, err := h.repo.FindTagCounts(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags, "taxonomy": domain.TagTaxonomy})
+}
+
+// TagProjects handles GET /tags/:name/projects to page through the public
+// projects with a tag. It takes the same parameters as List.
+func (h *ProjectHandler) TagProjects(c *gin.Context) {
+	tag, err := h.repo.FindTag(c.Param("name"))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+		return
+	}
+
+	opts, err := domain.ParseProjectListOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.Public = true
+	opts.Tag = tag.Name
+
+	page, err := h.repo.ListProjects(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// SetTags handles PUT /projects/:id/tags to replace a project's tags
+func (h *ProjectHandler) SetTags(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	var req tagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.SetProjectTags(project.ID, req.Tags); err != nil {
+		if errors.Is(err, domain.ErrTooManyTags) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+		return
+	}
+
+	project, err := h.repo.FindByID(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": project.Tags})
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/gc"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// ListTrash handles GET /trash to list the caller's deleted projects and the
+// samples deleted from their projects
+func (h *ProjectHandler) ListTrash(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	trash, err := h.repo.FindTrash(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
+
+// RestoreProject handles POST /trash/projects/:id/restore
+func (h *ProjectHandler) RestoreProject(c *gin.Context) {
+	project, ok := h.authorizeTrashedProject(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RestoreProject(project.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore project"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "restored"})
+}
+
+// PurgeProject handles DELETE /trash/projects/:id to delete a project in the
+// trash for good
+func (h *ProjectHandler) PurgeProject(c *gin.Context) {
+	project, ok := h.authorizeTrashedProject(c)
+	if !ok {
+		return
+	}
+
+	orphans, err := h.repo.PurgeProject(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+		return
+	}
+	if _, err := gc.Sweep(h.storage, orphans, h.gracePeriod); err != nil {
+		log.Printf("Failed to delete project files: %v", err)
+		// The rows are gone; the collector removes leftover objects
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// RemoveSample handles DELETE /projects/:id/samples/:fileId to move a sample
+// to the trash
+func (h *ProjectHandler) RemoveSample(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	fileID, ok := sampleFileID(c)
+	if !ok {
+		return
+	}
+	var sample *domain.SampleFile
+	for i := range project.SampleFiles {
+		if project.SampleFiles[i].ID == fileID {
+			sample = &project.SampleFiles[i]
+			break
+		}
+	}
+	if sample == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	err := h.changeSample(project.ID, userID, fmt.Sprintf("Removed %s", sample.Filename), func(tx domain.ProjectRepository) error {
+		return tx.RemoveSampleFile(project.ID, fileID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove file"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "trashed"})
+}
+
+// RestoreSample handles POST /trash/projects/:id/samples/:fileId/restore to
+// put a sample back into its project
+func (h *ProjectHandler) RestoreSample(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	sample, ok := h.findTrashedSample(c, project.ID)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	err := h.changeSample(project.ID, userID, fmt.Sprintf("Restored %s", sample.Filename), func(tx domain.ProjectRepository) error {
+		return tx.RestoreSampleFile(project.ID, sample.ID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTooManySamples):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrProjectTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore file"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "restored"})
+}
+
+// PurgeSample handles DELETE /trash/projects/:id/samples/:fileId to delete a
+// sample in the trash for good
+func (h *ProjectHandler) PurgeSample(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionDelete)
+	if !ok {
+		return
+	}
+
+	sample, ok := h.findTrashedSample(c, project.ID)
+	if !ok {
+		return
+	}
+
+	orphans, err := h.repo.PurgeSampleFile(project.ID, sample.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
+		return
+	}
+	if _, err := gc.Sweep(h.storage, orphans, h.gracePeriod); err != nil {
+		log.Printf("Failed to delete sample file: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// changeSample applies a change to a project's samples and records it as a revision
+func (h *ProjectHandler) changeSample(projectID, userID uint, message string, change func(tx domain.ProjectRepository) error) error {
+	tx, err := h.repo.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+	if _, err := tx.CreateRevision(projectID, userID, message); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// authorizeTrashedProject resolves the trashed project named in the URL.
+// Only its owner may restore or purge it.
+func (h *ProjectHandler) authorizeTrashedProject(c *gin.Context) (*domain.Project, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return nil, false
+	}
+
+	project, err := h.repo.FindTrashedProject(uint(id))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found in trash"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return nil, false
+	}
+
+	// Don't reveal other users' trash
+	userID, _ := middleware.CurrentUserID(c)
+	if project.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found in trash"})
+		return nil, false
+	}
+
+	return project, true
+}
+
+// findTrashedSample resolves the project's trashed sample named in the URL
+func (h *ProjectHandler) findTrashedSample(c *gin.Context, projectID uint) (*domain.SampleFile, bool) {
+	fileID, ok := sampleFileID(c)
+	if !ok {
+		return nil, false
+	}
+
+	sample, err := h.repo.FindTrashedSample(projectID, fileID)
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found in trash"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch file"})
+		return nil, false
+	}
+
+	return sample, true
+}
+
+func sampleFileID(c *gin.Context) (uint, bool) {
+	fileID, err := strconv.ParseUint(c.Param("fileId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return 0, false
+	}
+	return uint(fileID), true
+}
@@ -0,0 +1,476 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+)
+
+// Headers exchanged with resumable upload clients
+const (
+	uploadOffsetHeader = "Upload-Offset"
+	uploadLengthHeader = "Upload-Length"
+)
+
+type createUploadRequest struct {
+	Filename string `json:"filename" binding:"required"`
+	Size     int64  `json:"size" binding:"required,min=1"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	SHA256   string `json:"sha256"` // Required for direct uploads
+}
+
+// CreateUpload handles POST /projects/:id/uploads to start a resumable upload
+func (h *ProjectHandler) CreateUpload(c *gin.Context) {
+	project, req, ok := h.bindUploadRequest(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := newUploadSessionID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	upload, err := h.storage.StartMultipartUpload(req.Filename)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	session := &domain.UploadSession{
+		ID:              sessionID,
+		ProjectID:       project.ID,
+		UserID:          userID,
+		Filename:        req.Filename,
+		Kind:            req.Kind,
+		ContentType:     upload.ContentType,
+		Message:         req.Message,
+		Size:            req.Size,
+		ExpiresAt:       time.Now().Add(h.uploadTTL),
+		StagingPath:     upload.Path,
+		StorageUploadID: upload.UploadID,
+	}
+	if err := h.uploads.Create(session); err != nil {
+		if abortErr := h.storage.AbortMultipartUpload(upload.Path, upload.UploadID); abortErr != nil {
+			log.Printf("Failed to abort upload %s: %v", upload.Path, abortErr)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	c.Header("Location", "/api/v1/uploads/"+session.ID)
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusCreated, session)
+}
+
+// UploadStatus handles HEAD and GET /uploads/:uploadId so clients can find
+// where to resume
+func (h *ProjectHandler) UploadStatus(c *gin.Context) {
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Cache-Control", "no-store")
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// AppendUpload handles PATCH /uploads/:uploadId to append the next chunk. The
+// Upload-Offset header must match the bytes already received.
+func (h *ProjectHandler) AppendUpload(c *gin.Context) {
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		return
+	}
+	if session.IsDirect() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Direct uploads do not accept chunks"})
+		return
+	}
+	if session.IsExpired() {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload expired"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Offset header"})
+		return
+	}
+	if offset != session.Offset {
+		setUploadHeaders(c, session)
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload offset does not match"})
+		return
+	}
+
+	length := c.Request.ContentLength
+	switch {
+	case length < 0:
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length required"})
+		return
+	case length == 0:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Empty chunk"})
+		return
+	case length > domain.MaxUploadChunkSize || offset+length > session.Size:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk too large"})
+		return
+	case length < domain.MinUploadChunkSize && offset+length != session.Size:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only the final chunk may be smaller than 5MB"})
+		return
+	}
+
+	digest, err := restoreUploadHash(session.HashState)
+	if err != nil {
+		log.Printf("Failed to restore upload hash for %s: %v", session.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume upload"})
+		return
+	}
+
+	// A failed part leaves the offset unchanged, so the client retries the same
+	// chunk and the part is overwritten
+	body := io.TeeReader(http.MaxBytesReader(c.Writer, c.Request.Body, length), digest)
+	partNumber := session.PartCount + 1
+	if err := h.storage.UploadPart(session.StagingPath, session.StorageUploadID, partNumber, body, length); err != nil {
+		log.Printf("Failed to upload part %d of %s: %v", partNumber, session.ID, err)
+		c.JSON(uploadErrorStatus(err), gin.H{"error": "Failed to upload chunk"})
+		return
+	}
+
+	state, err := digest.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save upload progress"})
+		return
+	}
+	session.Offset += length
+	session.PartCount = partNumber
+	session.HashState = state
+
+	if err := h.uploads.Advance(session, offset); err != nil {
+		if errors.Is(err, domain.ErrUploadOffsetMismatch) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Upload offset does not match"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save upload progress"})
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Status(http.StatusNoContent)
+}
+
+// CompleteUpload handles POST /uploads/:uploadId/complete to assemble the
+// chunks and attach the file to the project
+func (h *ProjectHandler) CompleteUpload(c *gin.Context) {
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		return
+	}
+	if session.IsDirect() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Direct uploads are completed with /finalize"})
+		return
+	}
+	if !session.IsComplete() {
+		setUploadHeaders(c, session)
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload incomplete"})
+		return
+	}
+
+	digest, err := restoreUploadHash(session.HashState)
+	if err != nil {
+		log.Printf("Failed to restore upload hash for %s: %v", session.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete upload"})
+		return
+	}
+
+	metadata := domain.FileMetadata{
+		Size:        session.Size,
+		Filename:    session.Filename,
+		ContentType: session.ContentType,
+		Hash:        hex.EncodeToString(digest.Sum(nil)),
+		UploadedAt:  time.Now(),
+	}
+	filePath, err := h.storage.CompleteMultipartUpload(session.StagingPath, session.StorageUploadID, metadata)
+	if err != nil {
+		log.Printf("Failed to complete upload %s: %v", session.ID, err)
+		c.JSON(uploadErrorStatus(err), gin.H{"error": "Failed to complete upload"})
+		return
+	}
+
+	fileInfo := domain.FileInfo{
+		Size:        metadata.Size,
+		Filename:    metadata.Filename,
+		ContentType: metadata.ContentType,
+		Hash:        metadata.Hash,
+	}
+	if err := h.recordUpload(session.ProjectID, session.UserID, session.Kind, fileInfo, filePath, session.Message); err != nil {
+		// recordUpload discarded the assembled file; the client must start over
+		if err := h.uploads.Delete(session.ID); err != nil {
+			log.Printf("Failed to delete upload session %s: %v", session.ID, err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file information"})
+		return
+	}
+
+	if err := h.uploads.Delete(session.ID); err != nil {
+		log.Printf("Failed to delete upload session %s: %v", session.ID, err)
+	}
+
+	h.respondUploaded(c, fileInfo, filePath)
+}
+
+// AbortUpload handles DELETE /uploads/:uploadId to discard an upload
+func (h *ProjectHandler) AbortUpload(c *gin.Context) {
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if err := discardUploadSession(h.storage, session); err != nil {
+		log.Printf("Failed to abort upload %s: %v", session.ID, err)
+	}
+	if err := h.uploads.Delete(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to abort upload"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PresignUpload handles POST /projects/:id/uploads/presign. It returns a URL
+// the client PUTs the file to directly, followed by a call to FinalizeUpload.
+func (h *ProjectHandler) PresignUpload(c *gin.Context) {
+	project, req, ok := h.bindUploadRequest(c)
+	if !ok {
+		return
+	}
+	if !isSHA256Hex(req.SHA256) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A hex SHA-256 of the file is required"})
+		return
+	}
+
+	sessionID, err := newUploadSessionID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	upload, err := h.storage.PresignUpload(req.Filename, h.uploadTTL)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	session := &domain.UploadSession{
+		ID:          sessionID,
+		ProjectID:   project.ID,
+		UserID:      userID,
+		Filename:    req.Filename,
+		Kind:        req.Kind,
+		ContentType: upload.ContentType,
+		Message:     req.Message,
+		Size:        req.Size,
+		Hash:        strings.ToLower(req.SHA256),
+		ExpiresAt:   upload.ExpiresAt,
+		StagingPath: upload.Path,
+	}
+	if err := h.uploads.Create(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":     session.ID,
+		"url":    upload.URL,
+		"method": http.MethodPut,
+		"headers": gin.H{
+			"Content-Type": upload.ContentType,
+		},
+		"expires_at":   session.ExpiresAt,
+		"finalize_url": "/api/v1/uploads/" + session.ID + "/finalize",
+	})
+}
+
+// FinalizeUpload handles POST /uploads/:uploadId/finalize once the client has
+// uploaded the file to its presigned URL. The file is only recorded after
+// storage confirms its size, content type and hash.
+func (h *ProjectHandler) FinalizeUpload(c *gin.Context) {
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		return
+	}
+	if !session.IsDirect() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Chunked uploads are completed with /complete"})
+		return
+	}
+	if session.IsExpired() {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload expired"})
+		return
+	}
+
+	metadata := domain.FileMetadata{
+		Size:        session.Size,
+		Filename:    session.Filename,
+		ContentType: session.ContentType,
+		Hash:        session.Hash,
+		UploadedAt:  time.Now(),
+	}
+	filePath, err := h.storage.FinalizeUpload(session.StagingPath, metadata)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			c.JSON(http.StatusConflict, gin.H{"error": "File has not been uploaded"})
+			return
+		}
+		log.Printf("Failed to finalize upload %s: %v", session.ID, err)
+		status := uploadErrorStatus(err)
+		if status != http.StatusInternalServerError {
+			// The object failed verification and is gone; the client must start over
+			if err := h.uploads.Delete(session.ID); err != nil {
+				log.Printf("Failed to delete upload session %s: %v", session.ID, err)
+			}
+		}
+		c.JSON(status, gin.H{"error": "Failed to finalize upload"})
+		return
+	}
+
+	fileInfo := domain.FileInfo{
+		Size:        metadata.Size,
+		Filename:    metadata.Filename,
+		ContentType: metadata.ContentType,
+		Hash:        metadata.Hash,
+	}
+	if err := h.recordUpload(session.ProjectID, session.UserID, session.Kind, fileInfo, filePath, session.Message); err != nil {
+		// recordUpload discarded the assembled file; the client must start over
+		if err := h.uploads.Delete(session.ID); err != nil {
+			log.Printf("Failed to delete upload session %s: %v", session.ID, err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file information"})
+		return
+	}
+
+	if err := h.uploads.Delete(session.ID); err != nil {
+		log.Printf("Failed to delete upload session %s: %v", session.ID, err)
+	}
+
+	h.respondUploaded(c, fileInfo, filePath)
+}
+
+// bindUploadRequest loads the project and validates the description of the
+// file about to be uploaded
+func (h *ProjectHandler) bindUploadRequest(c *gin.Context) (*domain.Project, createUploadRequest, bool) {
+	var req createUploadRequest
+
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return nil, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, req, false
+	}
+	if req.Kind == "" {
+		req.Kind = domain.RevisionFileSample
+	}
+	if req.Kind != domain.RevisionFileMain && req.Kind != domain.RevisionFileSample {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kind must be main or sample"})
+		return nil, req, false
+	}
+	if req.Size > domain.MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+		return nil, req, false
+	}
+	if len(req.Message) > maxMessageLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message too long"})
+		return nil, req, false
+	}
+
+	return project, req, true
+}
+
+// findUploadSession loads the session named in the URL, responding with 404
+// if it does not exist or belongs to another user, and re-checks that the
+// user may still change the project
+func (h *ProjectHandler) findUploadSession(c *gin.Context) (*domain.UploadSession, bool) {
+	session, err := h.uploads.FindByID(c.Param("uploadId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil, false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if session.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil, false
+	}
+
+	// Access to the project may have been revoked since the upload started
+	if _, err := h.authz.Authorize(userID, session.ProjectID, authz.ActionWrite); err != nil {
+		respondAuthzError(c, err)
+		return nil, false
+	}
+
+	return session, true
+}
+
+// discardUploadSession removes whatever storage holds for an unfinished upload
+func discardUploadSession(storage domain.StorageService, session *domain.UploadSession) error {
+	if session.IsDirect() {
+		return storage.DeleteFile(session.StagingPath)
+	}
+	return storage.AbortMultipartUpload(session.StagingPath, session.StorageUploadID)
+}
+
+func setUploadHeaders(c *gin.Context, session *domain.UploadSession) {
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(session.Size, 10))
+}
+
+// restoreUploadHash resumes the SHA-256 of the bytes received so far
+func restoreUploadHash(state []byte) (hash.Hash, error) {
+	digest := sha256.New()
+	if len(state) == 0 {
+		return digest, nil
+	}
+	if err := digest.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, fmt.Errorf("invalid hash state: %w", err)
+	}
+	return digest, nil
+}
+
+func isSHA256Hex(value string) bool {
+	decoded, err := hex.DecodeString(value)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+func newUploadSessionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"errors"
 	"log"
 	"math"
 	"net/http"
@@ -103,6 +104,88 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.Redirect(http.StatusSeeOther, "/")
 }
 
+// SignupPage shows the registration form for an emailed signup invite
+func (h *AuthHandler) SignupPage(c *gin.Context) {
+	invite, err := h.userRepo.FindSignupInvite(domain.HashInviteToken(c.Param("token")))
+	if err != nil {
+		h.renderInvalidSignup(c, err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "auth_layout", gin.H{
+		"content": "register",
+		"action":  c.Request.URL.Path,
+		"email":   invite.Email,
+	})
+}
+
+// Signup creates an account from an emailed signup invite, which works
+// while open registration is turned off
+func (h *AuthHandler) Signup(c *gin.Context) {
+	tokenHash := domain.HashInviteToken(c.Param("token"))
+	invite, err := h.userRepo.FindSignupInvite(tokenHash)
+	if err != nil {
+		h.renderInvalidSignup(c, err)
+		return
+	}
+
+	renderError := func(status int, message string) {
+		c.HTML(status, "auth_layout", gin.H{
+			"content": "register",
+			"action":  c.Request.URL.Path,
+			"email":   invite.Email,
+			"error":   message,
+		})
+	}
+
+	user := domain.User{
+		Username: c.PostForm("username"),
+		Password: c.PostForm("password"),
+	}
+	if user.Username == "" || user.Password == "" {
+		renderError(http.StatusBadRequest, "All fields are required")
+		return
+	}
+
+	if err := user.HashPassword(); err != nil {
+		renderError(http.StatusInternalServerError, "Server error")
+		return
+	}
+
+	if err := h.userRepo.AcceptSignupInvite(tokenHash, &user); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrSignupInviteInvalid):
+			h.renderInvalidSignup(c, err)
+		case errors.Is(err, domain.ErrAccountExists):
+			renderError(http.StatusConflict, "That username is already taken, or this email already has an account")
+		default:
+			renderError(http.StatusInternalServerError, "Failed to create user")
+		}
+		return
+	}
+
+	// Store user data in session
+	session := sessions.Default(c)
+	session.Set("user_id", user.ID)
+	session.Set("username", user.Username)
+	session.Set("email", user.Email)
+	session.Save()
+
+	c.Redirect(http.StatusSeeOther, "/dashboard")
+}
+
+// renderInvalidSignup sends visitors with an unusable invite to the login form
+func (h *AuthHandler) renderInvalidSignup(c *gin.Context, err error) {
+	status, message := http.StatusInternalServerError, "Server error"
+	if errors.Is(err, domain.ErrSignupInviteInvalid) {
+		status, message = http.StatusNotFound, "This invite is invalid or has expired"
+	}
+	c.HTML(status, "auth_layout", gin.H{
+		"content": "login",
+		"error":   message,
+	})
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
 	username := c.PostForm("username")
 	password := c.PostForm("password")
@@ -124,6 +207,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if user.IsDisabled() {
+		c.HTML(http.StatusForbidden, "auth_layout", gin.H{
+			"content": "login",
+			"error":   "This account has been disabled",
+		})
+		return
+	}
+
 	// Store user data in session
 	session := sessions.Default(c)
 	session.Set("user_id", user.ID)
@@ -258,13 +349,44 @@ func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	// Delete all user projects
+	trash, err := h.projectRepo.FindTrash(uint(userID.(uint)))
+	if err != nil {
+		c.Header("HX-Trigger", `{"showToast": {"message": "Failed to get user projects", "type": "error"}}`)
+		return
+	}
+
+	// Delete all user projects for good, skipping the trash. Objects they
+	// leave behind are removed by the garbage collector.
 	for _, project := range projects {
 		if err := h.projectRepo.Delete(project.ID); err != nil {
 			c.Header("HX-Trigger", `{"showToast": {"message": "Failed to delete user projects", "type": "error"}}`)
 			return
 		}
 	}
+	for _, project := range append(projects, trash.Projects...) {
+		if _, err := h.projectRepo.PurgeProject(project.ID); err != nil {
+			c.Header("HX-Trigger", `{"showToast": {"message": "Failed to delete user projects", "type": "error"}}`)
+			return
+		}
+	}
+
+	// Leave projects shared with the user
+	if err := h.projectRepo.RemoveMemberships(uint(userID.(uint))); err != nil {
+		c.Header("HX-Trigger", `{"showToast": {"message": "Failed to remove project memberships", "type": "error"}}`)
+		return
+	}
+
+	// Take back the user's stars so counts stay accurate
+	if err := h.projectRepo.RemoveStars(uint(userID.(uint))); err != nil {
+		c.Header("HX-Trigger", `{"showToast": {"message": "Failed to remove stars", "type": "error"}}`)
+		return
+	}
+
+	// Projects keep samples attached from the library
+	if err := h.projectRepo.RemoveLibrary(uint(userID.(uint))); err != nil {
+		c.Header("HX-Trigger", `{"showToast": {"message": "Failed to delete sample library", "type": "error"}}`)
+		return
+	}
 
 	// Delete user
 	if err := h.userRepo.Delete(uint(userID.(uint))); err != nil {
@@ -0,0 +1,196 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// commentThread is what the "comments" template needs to render the
+// comments on one file
+type commentThread struct {
+	ProjectID  uint
+	FileKind   string
+	FileID     uint
+	Comments   []domain.Comment
+	UserID     uint
+	CanComment bool
+	CanResolve bool // Editors resolve any comment; others only their own
+	IsOwner    bool // Owners delete any comment; others only their own
+}
+
+// commentEntry is a single comment as shown in a thread, with what the
+// viewer may do to it
+type commentEntry struct {
+	domain.Comment
+	CanResolve bool
+	CanDelete  bool
+}
+
+// Entry prepares one of the thread's comments or replies for display
+func (t commentThread) Entry(comment domain.Comment) *commentEntry {
+	own := comment.UserID == t.UserID
+	return &commentEntry{
+		Comment:    comment,
+		CanResolve: comment.ParentID == nil && (t.CanResolve || own),
+		CanDelete:  t.IsOwner || own,
+	}
+}
+
+// commentThreads returns a template function selecting the comments on a file
+func commentThreads(project *domain.Project, comments []domain.Comment, userID uint, role domain.ProjectRole) func(string, uint) commentThread {
+	return func(kind string, fileID uint) commentThread {
+		thread := commentThread{
+			ProjectID:  project.ID,
+			FileKind:   kind,
+			FileID:     fileID,
+			UserID:     userID,
+			CanComment: role != "",
+			CanResolve: role.CanWrite(),
+			IsOwner:    role == domain.RoleOwner,
+		}
+		for _, comment := range comments {
+			if comment.FileKind == kind && comment.FileID == fileID {
+				thread.Comments = append(thread.Comments, comment)
+			}
+		}
+		return thread
+	}
+}
+
+// CreateComment handles POST /projects/:id/comments from the comment and reply forms
+func (h *ProjectHandler) CreateComment(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionComment)
+	if !ok {
+		return
+	}
+
+	var parent *domain.Comment
+	if value := c.PostForm("parent_id"); value != "" {
+		parentID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			common.RenderError(c, "Invalid comment ID")
+			return
+		}
+		if parent, err = h.repo.FindComment(project.ID, uint(parentID)); err != nil {
+			common.RenderErrorStatus(c, http.StatusNotFound, "Comment not found")
+			return
+		}
+	}
+
+	// Accept "1:23" as well as plain seconds
+	var timestamp *float64
+	if value := c.PostForm("at"); value != "" {
+		seconds, err := domain.ParseTimestamp(value)
+		if err != nil {
+			common.RenderError(c, "Time must look like 1:23")
+			return
+		}
+		timestamp = &seconds
+	}
+
+	fileID, _ := strconv.ParseUint(c.PostForm("file_id"), 10, 32)
+	userID, _ := middleware.CurrentUserID(c)
+	comment, err := domain.NewComment(project, parent, userID, c.PostForm("file_kind"), uint(fileID), c.PostForm("body"), timestamp)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "File not found")
+			return
+		}
+		common.RenderError(c, "Comments must be between 1 and 4000 characters")
+		return
+	}
+
+	if err := h.repo.CreateComment(comment); err != nil {
+		common.RenderError(c, "Failed to save comment")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// ResolveComment handles POST /projects/:id/comments/:commentId/resolve to
+// tick off or reopen a comment
+func (h *ProjectHandler) ResolveComment(c *gin.Context) {
+	project, comment, role, ok := h.authorizeComment(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if comment.UserID != userID && !role.CanWrite() {
+		common.RenderErrorStatus(c, http.StatusForbidden, "You do not have permission to resolve this comment")
+		return
+	}
+
+	resolved := c.PostForm("resolved") != "false"
+	if err := h.repo.ResolveComment(project.ID, comment.ID, userID, resolved); err != nil {
+		common.RenderError(c, "Failed to update comment")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// DeleteComment handles POST /projects/:id/comments/:commentId/delete
+func (h *ProjectHandler) DeleteComment(c *gin.Context) {
+	project, comment, role, ok := h.authorizeComment(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if comment.UserID != userID && role != domain.RoleOwner {
+		common.RenderErrorStatus(c, http.StatusForbidden, "You do not have permission to delete this comment")
+		return
+	}
+
+	if err := h.repo.DeleteComment(project.ID, comment.ID); err != nil {
+		common.RenderError(c, "Failed to delete comment")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// authorizeComment resolves the project and comment named in the URL for a
+// caller allowed to comment, returning the caller's role on the project
+func (h *ProjectHandler) authorizeComment(c *gin.Context) (*domain.Project, *domain.Comment, domain.ProjectRole, bool) {
+	project, ok := h.authorizeProject(c, authz.ActionComment)
+	if !ok {
+		return nil, nil, "", false
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid comment ID")
+		return nil, nil, "", false
+	}
+
+	comment, err := h.repo.FindComment(project.ID, uint(commentID))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "Comment not found")
+			return nil, nil, "", false
+		}
+		common.RenderError(c, "Failed to load comment")
+		return nil, nil, "", false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	role, err := h.authz.Role(userID, project)
+	if err != nil {
+		common.RenderError(c, "Failed to check permissions")
+		return nil, nil, "", false
+	}
+
+	return project, comment, role, true
+}
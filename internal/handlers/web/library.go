@@ -0,0 +1,270 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// maxLibraryRequestSize leaves room for multipart framing around a maximum-size file
+const maxLibraryRequestSize = domain.MaxFileSize + 1024*1024
+
+// Library handles GET /library to browse the user's sample library by
+// folder and tag
+func (h *ProjectHandler) Library(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	folder, err := domain.NormalizeFolder(c.Query("folder"))
+	if err != nil {
+		common.RenderError(c, err.Error())
+		return
+	}
+	tag := domain.NormalizeTagName(c.Query("tag"))
+
+	items, err := h.repo.FindLibraryItems(userID, folder, tag)
+	if err != nil {
+		common.RenderError(c, "Failed to load library")
+		return
+	}
+	folders, err := h.repo.FindLibraryFolders(userID)
+	if err != nil {
+		common.RenderError(c, "Failed to load library")
+		return
+	}
+
+	common.Render(c, gin.H{
+		"content":        "library",
+		"items":          items,
+		"folders":        folders,
+		"folder":         folder,
+		"tag":            tag,
+		"formatFileSize": formatFileSize,
+	})
+}
+
+// UploadLibraryItem handles POST /library to add an audio file to the
+// user's library
+func (h *ProjectHandler) UploadLibraryItem(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	if !limitRequestBody(c, maxLibraryRequestSize) {
+		common.RenderError(c, "File too large")
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		common.RenderError(c, "Choose a file to upload")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		common.RenderError(c, "Failed to read file")
+		return
+	}
+	defer file.Close()
+
+	fileInfo, filePath, err := h.storage.UploadFile(header.Filename, file)
+	if err != nil {
+		common.RenderError(c, "Failed to upload file")
+		return
+	}
+
+	item, err := domain.NewLibraryItem(userID, c.PostForm("folder"), fileInfo, filePath)
+	if err != nil {
+		h.discardUpload(h.repo, filePath)
+		common.RenderError(c, err.Error())
+		return
+	}
+
+	tx, err := h.repo.Begin()
+	if err != nil {
+		h.discardUpload(h.repo, filePath)
+		common.RenderError(c, "Failed to save file")
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.CreateLibraryItem(item)
+	if err == nil {
+		err = tx.SetLibraryItemTags(item.ID, strings.Split(c.PostForm("tags"), ","))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		tx.Rollback()
+		h.discardUpload(h.repo, filePath)
+		if errors.Is(err, domain.ErrTooManyTags) {
+			common.RenderError(c, err.Error())
+			return
+		}
+		common.RenderError(c, "Failed to save file")
+		return
+	}
+
+	common.HandleRedirect(c, libraryURL(item.Folder))
+}
+
+// UpdateLibraryItem handles POST /library/:itemId/update to move an item to
+// another folder and replace its tags
+func (h *ProjectHandler) UpdateLibraryItem(c *gin.Context) {
+	item, ok := h.findLibraryItem(c)
+	if !ok {
+		return
+	}
+
+	err := h.repo.MoveLibraryItem(item.UserID, item.ID, c.PostForm("folder"))
+	if err == nil {
+		err = h.repo.SetLibraryItemTags(item.ID, strings.Split(c.PostForm("tags"), ","))
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFolder) || errors.Is(err, domain.ErrTooManyTags) {
+			common.RenderError(c, err.Error())
+			return
+		}
+		common.RenderError(c, "Failed to update library item")
+		return
+	}
+
+	common.HandleRedirect(c, libraryURL(c.Query("folder")))
+}
+
+// DeleteLibraryItem handles POST /library/:itemId/delete. If the item is
+// attached to projects, the projects are listed with a warning unless the
+// form confirms with force; they keep their copies of the sample either way.
+func (h *ProjectHandler) DeleteLibraryItem(c *gin.Context) {
+	item, ok := h.findLibraryItem(c)
+	if !ok {
+		return
+	}
+
+	if c.PostForm("force") != "true" {
+		projects, err := h.repo.FindLibraryItemUsage(item.ID)
+		if err != nil {
+			common.RenderError(c, "Failed to check library item usage")
+			return
+		}
+		if len(projects) > 0 {
+			c.HTML(http.StatusOK, "library-delete-warning", gin.H{
+				"item":     item,
+				"projects": projects,
+			})
+			return
+		}
+	}
+
+	if err := h.repo.DeleteLibraryItem(item.UserID, item.ID); err != nil {
+		common.RenderError(c, "Failed to delete library item")
+		return
+	}
+	h.discardUpload(h.repo, item.FilePath)
+
+	common.HandleRedirect(c, libraryURL(c.Query("folder")))
+}
+
+// AttachLibraryItems handles POST /projects/:id/library to add the checked
+// library items to a project as samples
+func (h *ProjectHandler) AttachLibraryItems(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	var items []domain.LibraryItem
+	for _, value := range c.PostFormArray("item_ids") {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			common.RenderError(c, "Invalid library item ID")
+			return
+		}
+		item, err := h.repo.FindLibraryItem(userID, uint(id))
+		if err != nil {
+			if errors.Is(err, common.ErrNotFound) {
+				common.RenderErrorStatus(c, http.StatusNotFound, "Library item not found")
+				return
+			}
+			common.RenderError(c, "Failed to load library item")
+			return
+		}
+		items = append(items, *item)
+	}
+	if len(items) == 0 {
+		common.RenderError(c, "Choose samples from your library")
+		return
+	}
+
+	tx, err := h.repo.Begin()
+	if err != nil {
+		common.RenderError(c, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	attached, err := tx.AttachLibraryItems(project.ID, items)
+	if err != nil {
+		if errors.Is(err, domain.ErrTooManySamples) || errors.Is(err, domain.ErrProjectTooLarge) {
+			common.RenderError(c, err.Error())
+			return
+		}
+		common.RenderError(c, "Failed to add samples")
+		return
+	}
+
+	if attached > 0 {
+		message := fmt.Sprintf("Added %d samples from library", attached)
+		if attached == 1 {
+			message = "Added 1 sample from library"
+		}
+		if _, err := tx.CreateRevision(project.ID, userID, message); err != nil {
+			common.RenderError(c, "Failed to record revision")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		common.RenderError(c, "Failed to add samples")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// findLibraryItem resolves the user's library item named in the URL
+func (h *ProjectHandler) findLibraryItem(c *gin.Context) (*domain.LibraryItem, bool) {
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid library item ID")
+		return nil, false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	item, err := h.repo.FindLibraryItem(userID, uint(itemID))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "Library item not found")
+			return nil, false
+		}
+		common.RenderError(c, "Failed to load library item")
+		return nil, false
+	}
+
+	return item, true
+}
+
+// libraryURL returns the library page for a folder
+func libraryURL(folder string) string {
+	if folder == "" {
+		return "/library"
+	}
+	return "/library?" + url.Values{"folder": {folder}}.Encode()
+}
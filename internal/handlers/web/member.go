@@ -0,0 +1,184 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/email"
+	"dawhub/internal/middleware"
+	"dawhub/internal/repository"
+	"dawhub/pkg/common"
+)
+
+// MemberHandler handles web requests for project collaborators
+type MemberHandler struct {
+	repo         domain.ProjectRepository
+	users        *repository.UserRepository
+	authz        *authz.Authorizer
+	emailService *email.ResendService
+}
+
+// NewMemberHandler creates a new web member handler instance
+func NewMemberHandler(repo domain.ProjectRepository, users *repository.UserRepository, emailService *email.ResendService) *MemberHandler {
+	return &MemberHandler{
+		repo:         repo,
+		users:        users,
+		authz:        authz.NewAuthorizer(repo),
+		emailService: emailService,
+	}
+}
+
+// Invite handles POST /projects/:id/members to email an invite by username or email
+func (h *MemberHandler) Invite(c *gin.Context) {
+	project, ok := authorizeProject(c, h.authz, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	role := domain.ProjectRole(c.PostForm("role"))
+	if !domain.IsValidMemberRole(role) {
+		common.RenderError(c, "Role must be editor or viewer")
+		return
+	}
+
+	// Registered users are invited at their account address
+	identifier := strings.TrimSpace(c.PostForm("identifier"))
+	invitee, err := h.users.GetByUsername(identifier)
+	if err != nil && strings.Contains(identifier, "@") {
+		invitee, err = h.users.GetByEmail(identifier)
+	}
+	address := identifier
+	registered := err == nil
+	switch {
+	case registered:
+		address = invitee.Email
+		if invitee.ID == project.UserID {
+			common.RenderError(c, "The owner cannot be invited")
+			return
+		}
+		if _, err := h.repo.FindMember(project.ID, invitee.ID); err == nil {
+			common.RenderErrorStatus(c, http.StatusConflict, "User is already a member")
+			return
+		}
+	case !strings.Contains(identifier, "@"):
+		common.RenderErrorStatus(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	inviterID, _ := middleware.CurrentUserID(c)
+	invite, token, err := domain.NewProjectInvite(project.ID, address, role, inviterID)
+	if err != nil {
+		common.RenderError(c, "Failed to create invite")
+		return
+	}
+	if registered {
+		invite.SetInvitee(invitee)
+	}
+	if err := h.repo.CreateInvite(invite); err != nil {
+		common.RenderError(c, "Failed to create invite")
+		return
+	}
+
+	inviterName := "A DawHub user"
+	if inviter, err := h.users.GetByID(inviterID); err == nil {
+		inviterName = inviter.Username
+	}
+	if err := h.emailService.SendProjectInviteEmail(address, inviterName, project.Name, role, token); err != nil {
+		if err := h.repo.DeleteInvite(project.ID, invite.ID); err != nil {
+			log.Printf("Failed to delete unsent invite %d: %v", invite.ID, err)
+		}
+		common.RenderError(c, "Failed to send invite email")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// Remove handles POST /projects/:id/members/:userId/remove. The owner can
+// remove anyone; members can leave on their own.
+func (h *MemberHandler) Remove(c *gin.Context) {
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid user ID")
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	action := authz.ActionManage
+	if userID == uint(memberID) {
+		action = authz.ActionRead
+	}
+	project, ok := authorizeProject(c, h.authz, action)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RemoveMember(project.ID, uint(memberID)); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "Member not found")
+			return
+		}
+		common.RenderError(c, "Failed to remove member")
+		return
+	}
+
+	// Someone who left a private project can no longer see it
+	if userID == uint(memberID) {
+		common.HandleRedirect(c, "/projects")
+		return
+	}
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// RevokeInvite handles POST /projects/:id/invites/:inviteId/revoke
+func (h *MemberHandler) RevokeInvite(c *gin.Context) {
+	project, ok := authorizeProject(c, h.authz, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.ParseUint(c.Param("inviteId"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid invite ID")
+		return
+	}
+
+	if err := h.repo.DeleteInvite(project.ID, uint(inviteID)); err != nil {
+		common.RenderError(c, "Failed to revoke invite")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// Accept handles GET /invites/:token, the link in the invite email
+func (h *MemberHandler) Accept(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		common.RenderError(c, "User must be logged in")
+		return
+	}
+
+	member, err := h.repo.AcceptInvite(domain.HashInviteToken(c.Param("token")), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInviteInvalid):
+			common.RenderErrorStatus(c, http.StatusNotFound, "This invite is invalid or has expired")
+		case errors.Is(err, domain.ErrAlreadyMember):
+			common.RenderErrorStatus(c, http.StatusConflict, "You already own this project")
+		default:
+			common.RenderError(c, "Failed to accept invite")
+		}
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", member.ProjectID))
+}
@@ -2,7 +2,8 @@ package web
 
 import (
 	"archive/zip"
-	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,15 +16,28 @@ import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 
+	"dawhub/internal/authz"
 	"dawhub/internal/domain"
+	"dawhub/internal/gc"
+	"dawhub/internal/middleware"
 	"dawhub/pkg/common"
 )
 
+// maxProjectRequestSize leaves room for multipart framing around a maximum-size project
+const maxProjectRequestSize = domain.MaxProjectSize + 1024*1024
+
+// maxManifestSize bounds how much of an archive manifest is read
+const maxManifestSize = 1024 * 1024
+
+// dashboardTagLimit is how many popular tags the dashboard shows
+const dashboardTagLimit = 12
+
 // Stats represents the dashboard statistics
 type Stats struct {
 	ProjectCount int
 	SampleCount  int
 	UserCount    int
+	Tags         []domain.TagCount // Most used tags on public projects
 }
 
 // HomeData represents all data needed for the home page
@@ -36,14 +50,19 @@ type HomeData struct {
 // ProjectHandler handles web interface requests for project operations
 type ProjectHandler struct {
 	repo    domain.ProjectRepository
+	authz   *authz.Authorizer
 	storage domain.StorageService
+
+	gracePeriod time.Duration // Released objects modified this recently are left to the collector
 }
 
 // NewProjectHandler creates a new web project handler instance
-func NewProjectHandler(repo domain.ProjectRepository, storage domain.StorageService) *ProjectHandler {
+func NewProjectHandler(repo domain.ProjectRepository, storage domain.StorageService, gracePeriod time.Duration) *ProjectHandler {
 	return &ProjectHandler{
-		repo:    repo,
-		storage: storage,
+		repo:        repo,
+		authz:       authz.NewAuthorizer(repo),
+		storage:     storage,
+		gracePeriod: gracePeriod,
 	}
 }
 
@@ -73,15 +92,20 @@ func (h *ProjectHandler) getHomeData() (HomeData, error) {
 		return data, fmt.Errorf("failed to fetch projects: %v", err)
 	}
 
+	tags, err := h.repo.FindTagCounts(dashboardTagLimit)
+	if err != nil {
+		return data, fmt.Errorf("failed to fetch tags: %v", err)
+	}
+
 	data.Stats = Stats{
 		ProjectCount: len(projects),
+		Tags:         tags,
 	}
 
-	// Get last 6 trending projects
-	if len(projects) > 6 {
-		data.TrendingProjects = projects[:6]
-	} else {
-		data.TrendingProjects = projects
+	// Rank by recent stars and downloads rather than age
+	data.TrendingProjects, err = h.repo.FindTrending(6)
+	if err != nil {
+		return data, fmt.Errorf("failed to fetch trending projects: %v", err)
 	}
 
 	// Get last 4 recent projects
@@ -104,23 +128,46 @@ func (h *ProjectHandler) List(c *gin.Context) {
 		return
 	}
 
-	// Fetch only user's projects
-	projects, err := h.repo.FindByUserID(userID.(uint))
+	query := c.Request.URL.Query()
+	opts, err := domain.ParseProjectListOptions(query)
+	if err != nil {
+		common.RenderError(c, err.Error())
+		return
+	}
+	opts.UserID = userID.(uint)
+
+	// Fetch a page of the user's own projects and those shared with them
+	page, err := h.repo.ListProjects(opts)
 	if err != nil {
 		common.RenderError(c, "Failed to fetch projects")
 		return
 	}
 
-	common.Render(c, gin.H{
-		"content":  "projects",
-		"projects": projects,
-	})
+	data := gin.H{
+		"content":      "projects",
+		"projects":     page.Items,
+		"filters":      query,
+		"userID":       opts.UserID,
+		"contentTypes": domain.DAWFileTypes,
+	}
+	if page.NextCursor != "" {
+		query.Set("cursor", page.NextCursor)
+		data["nextPage"] = "/projects?" + query.Encode()
+	}
+
+	// Infinite scroll requests only need the next cards
+	if opts.Cursor != "" && common.IsHtmx(c) {
+		c.HTML(http.StatusOK, "project-cards", data)
+		return
+	}
+	common.Render(c, data)
 }
 
 // New handles GET /projects/new to display project creation form
 func (h *ProjectHandler) New(c *gin.Context) {
 	common.Render(c, gin.H{
-		"content": "new",
+		"content":   "new",
+		"tagPicker": newTagPicker(nil),
 	})
 }
 
@@ -134,7 +181,12 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Parse form data
+	if !limitRequestBody(c, maxProjectRequestSize) {
+		common.RenderError(c, "Upload exceeds the project size limit")
+		return
+	}
+
+	// Parse form data; large parts are spooled to disk, not memory
 	form, err := c.MultipartForm()
 	if err != nil {
 		common.RenderError(c, "Invalid form data")
@@ -166,6 +218,15 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if err := tx.SetProjectTags(project.ID, formTags(c)); err != nil {
+		if errors.Is(err, domain.ErrTooManyTags) {
+			common.RenderError(c, "A project can have at most 20 tags")
+			return
+		}
+		common.RenderError(c, "Failed to save tags")
+		return
+	}
+
 	// Handle main project file
 	mainFile, err := c.FormFile("mainFile")
 	if err != nil {
@@ -181,7 +242,7 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 	defer file.Close()
 
 	// Upload main file
-	fileInfo, filePath, err := h.storage.UploadFile(project.ID, mainFile.Filename, file)
+	fileInfo, filePath, err := h.storage.UploadFile(mainFile.Filename, file)
 	if err != nil {
 		common.RenderError(c, "Failed to upload main file")
 		return
@@ -200,8 +261,7 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 	}
 
 	if err := tx.AddMainFile(project.ID, projectFile); err != nil {
-		// Cleanup uploaded file
-		h.storage.DeleteFile(filePath)
+		h.discardUpload(tx, filePath)
 		common.RenderError(c, "Failed to save main file info")
 		return
 	}
@@ -215,7 +275,7 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 			}
 			defer file.Close()
 
-			fileInfo, filePath, err := h.storage.UploadFile(project.ID, sampleFile.Filename, file)
+			fileInfo, filePath, err := h.storage.UploadFile(sampleFile.Filename, file)
 			if err != nil {
 				continue
 			}
@@ -233,13 +293,18 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 			}
 
 			if err := tx.AddSampleFile(project.ID, sample); err != nil {
-				// Cleanup uploaded file
-				h.storage.DeleteFile(filePath)
+				h.discardUpload(tx, filePath)
 				continue
 			}
 		}
 	}
 
+	// Record the initial state as the first revision
+	if _, err := tx.CreateRevision(project.ID, userID.(uint), "Initial version"); err != nil {
+		common.RenderError(c, "Failed to record revision")
+		return
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		common.RenderError(c, "Failed to save project")
@@ -263,56 +328,102 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 
 // Show handles GET /projects/:id to display a specific project
 func (h *ProjectHandler) Show(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.repo.FindRevisions(project.ID)
+	if err != nil {
+		h.renderError(c, "Failed to load project history")
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	role, err := h.authz.Role(userID, project)
 	if err != nil {
-		h.renderError(c, "Invalid project ID")
+		h.renderError(c, "Failed to load collaborators")
 		return
 	}
 
-	project, err := h.repo.FindByID(uint(id))
+	var members []domain.ProjectMember
+	var invites []domain.ProjectInvite
+	var shareLinks []domain.ShareLink
+	if role != "" {
+		if members, err = h.repo.FindMembers(project.ID); err != nil {
+			h.renderError(c, "Failed to load collaborators")
+			return
+		}
+	}
+	if role == domain.RoleOwner {
+		if invites, err = h.repo.FindPendingInvites(project.ID); err != nil {
+			h.renderError(c, "Failed to load collaborators")
+			return
+		}
+		if shareLinks, err = h.repo.FindShareLinks(project.ID); err != nil {
+			h.renderError(c, "Failed to load share links")
+			return
+		}
+	}
+
+	comments, err := h.repo.FindComments(project.ID)
 	if err != nil {
-		h.renderError(c, "Project not found")
+		h.renderError(c, "Failed to load comments")
 		return
 	}
 
+	starred := false
+	if userID != 0 {
+		if starred, err = h.repo.IsStarred(project.ID, userID); err != nil {
+			h.renderError(c, "Failed to load project")
+			return
+		}
+	}
+
+	// Writers can attach samples from their own library
+	var libraryItems []domain.LibraryItem
+	if role.CanWrite() {
+		if libraryItems, err = h.repo.FindLibraryItems(userID, "", ""); err != nil {
+			h.renderError(c, "Failed to load library")
+			return
+		}
+	}
+
 	common.Render(c, gin.H{
 		"content":        "show",
 		"project":        project,
+		"revisions":      revisions,
+		"userID":         userID,
+		"role":           string(role),
+		"canWrite":       role.CanWrite(),
+		"members":        members,
+		"invites":        invites,
+		"shareLinks":     shareLinks,
+		"libraryItems":   libraryItems,
+		"commentsFor":    commentThreads(project, comments, userID, role),
+		"star":           starButton{ProjectID: project.ID, Starred: starred, Count: project.StarCount},
 		"formatFileSize": formatFileSize,
 	})
 }
 
 // Edit handles GET /projects/:id/edit to display project edit form
 func (h *ProjectHandler) Edit(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		common.RenderError(c, "Invalid project ID")
-		return
-	}
-
-	project, err := h.repo.FindByID(uint(id))
-	if err != nil {
-		common.RenderError(c, "Project not found")
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
 		return
 	}
 
 	common.Render(c, gin.H{
-		"content": "edit",
-		"project": project,
+		"content":   "edit",
+		"project":   project,
+		"tagPicker": newTagPicker(project),
 	})
 }
 
 // Update handles POST /projects/:id/update to modify existing project
 func (h *ProjectHandler) Update(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		common.RenderError(c, "Invalid project ID")
-		return
-	}
-
-	project, err := h.repo.FindByID(uint(id))
-	if err != nil {
-		common.RenderError(c, "Project not found")
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
 		return
 	}
 
@@ -326,97 +437,35 @@ func (h *ProjectHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if err := h.repo.SetProjectTags(project.ID, formTags(c)); err != nil {
+		if errors.Is(err, domain.ErrTooManyTags) {
+			common.RenderError(c, "A project can have at most 20 tags")
+			return
+		}
+		common.RenderError(c, "Failed to update tags")
+		return
+	}
+
 	// After successful update, redirect to the show page
 	redirectUrl := fmt.Sprintf("/projects/%d", project.ID)
 	common.HandleRedirect(c, redirectUrl)
 }
 
-// Delete handles POST /projects/:id/delete to remove a project
+// Delete handles POST /projects/:id/delete to move a project to the trash
 func (h *ProjectHandler) Delete(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		common.RenderError(c, "Invalid project ID")
-		return
-	}
-
-	// Start transaction
-	tx, err := h.repo.Begin()
-	if err != nil {
-		common.RenderError(c, "Failed to start transaction")
-		return
-	}
-	defer tx.Rollback()
-
-	// Get project with all associated files
-	project, err := h.repo.FindByID(uint(id))
-	if err != nil {
-		common.RenderError(c, "Project not found")
+	project, ok := h.authorizeProject(c, authz.ActionDelete)
+	if !ok {
 		return
 	}
 
-	// Delete all sample files from storage first
-	for _, sample := range project.SampleFiles {
-		if err := h.storage.DeleteFile(sample.FilePath); err != nil {
-			log.Printf("Failed to delete sample file %s: %v", sample.FilePath, err)
-			// Continue deletion even if one file fails
-		}
-	}
-
-	// Delete main project file from storage if it exists
-	if project.MainFile != nil && project.MainFile.FilePath != "" {
-		if err := h.storage.DeleteFile(project.MainFile.FilePath); err != nil {
-			log.Printf("Failed to delete main file %s: %v", project.MainFile.FilePath, err)
-			// Continue deletion even if main file fails
-		}
-	}
-
-	// Delete all sample files from database
-	if err := tx.RemoveSampleFiles(project.ID, nil); err != nil {
-		common.RenderError(c, "Failed to delete sample files")
-		return
-	}
-
-	// Delete main file from database if it exists
-	if project.MainFileID != nil {
-		sqlDB := tx.DB() // Get the underlying *gorm.DB
-		if err := sqlDB.Exec("UPDATE projects SET main_file_id = NULL WHERE id = ?", project.ID).Error; err != nil {
-			common.RenderError(c, "Failed to unlink main file")
-			return
-		}
-
-		if err := sqlDB.Delete(&domain.ProjectFile{}, *project.MainFileID).Error; err != nil {
-			common.RenderError(c, "Failed to delete main file record")
-			return
-		}
-	}
-
-	// Finally delete the project
-	if err := tx.Delete(project.ID); err != nil {
+	// The project waits in the trash until it is restored or purged
+	if err := h.repo.Delete(project.ID); err != nil {
 		common.RenderError(c, "Failed to delete project")
 		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		common.RenderError(c, "Failed to complete deletion")
-		return
-	}
-
-	// For HTMX requests, we'll either redirect or render the projects list
-	if common.IsHtmx(c) {
-		c.Header("HX-Redirect", "/projects")
-		projects, err := h.repo.FindAll()
-		if err != nil {
-			common.RenderError(c, "Failed to fetch projects")
-			return
-		}
-		common.Render(c, gin.H{
-			"content":  "projects",
-			"projects": projects,
-		})
-	} else {
-		c.Redirect(http.StatusFound, "/projects")
-	}
+	// HTMX follows HX-Redirect to the caller's own project list
+	common.HandleRedirect(c, "/projects")
 }
 
 func (h *ProjectHandler) Import(c *gin.Context) {
@@ -426,25 +475,38 @@ func (h *ProjectHandler) Import(c *gin.Context) {
 }
 
 func (h *ProjectHandler) HandleImport(c *gin.Context) {
-	// Get multipart form
-	file, _, err := c.Request.FormFile("projectZip")
+	// Get logged in user ID
+	session := sessions.Default(c)
+	userID := session.Get("user_id")
+	if userID == nil {
+		h.renderError(c, "User must be logged in")
+		return
+	}
+
+	if !limitRequestBody(c, maxProjectRequestSize) {
+		h.renderError(c, "Upload exceeds the project size limit")
+		return
+	}
+
+	// Get multipart form; large parts are spooled to disk, not memory
+	file, header, err := c.Request.FormFile("projectZip")
 	if err != nil {
 		h.renderError(c, "No file uploaded")
 		return
 	}
 	defer file.Close()
 
-	// Read zip file
-	data, err := io.ReadAll(file)
+	// Process zip file directly from the spooled upload
+	reader, err := zip.NewReader(file, header.Size)
 	if err != nil {
-		h.renderError(c, "Failed to read zip file")
+		h.renderError(c, "Invalid zip file")
 		return
 	}
 
-	// Process zip file
-	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	// Archives exported by dawhub carry a manifest with the original names and hashes
+	manifest, err := readArchiveManifest(reader)
 	if err != nil {
-		h.renderError(c, "Invalid zip file")
+		h.renderError(c, "Invalid archive manifest")
 		return
 	}
 
@@ -453,6 +515,18 @@ func (h *ProjectHandler) HandleImport(c *gin.Context) {
 	var sampleFiles []*zip.File
 
 	for _, f := range reader.File {
+		if manifest != nil {
+			entry, ok := manifest.Entry(f.Name)
+			switch {
+			case !ok:
+			case entry.Kind == domain.RevisionFileMain:
+				mainFile = f
+			default:
+				sampleFiles = append(sampleFiles, f)
+			}
+			continue
+		}
+
 		ext := filepath.Ext(f.Name)
 		if isProjectFile(ext) {
 			mainFile = f
@@ -472,6 +546,12 @@ func (h *ProjectHandler) HandleImport(c *gin.Context) {
 		Description: fmt.Sprintf("Imported project with %d samples", len(sampleFiles)),
 		Version:     "1.0",
 		IsPublic:    c.PostForm("visibility") == "public",
+		UserID:      userID.(uint),
+	}
+	if manifest != nil {
+		project.Name = manifest.Name
+		project.Description = manifest.Description
+		project.Version = manifest.Version
 	}
 
 	// Start transaction
@@ -488,18 +568,24 @@ func (h *ProjectHandler) HandleImport(c *gin.Context) {
 		return
 	}
 
-	// Process and upload the main file
-	mainFileContent, err := readZipFile(mainFile)
+	// Stream the main file out of the archive
+	mainFileContent, err := mainFile.Open()
 	if err != nil {
 		h.renderError(c, "Failed to read project file")
 		return
 	}
 
-	fileInfo, filePath, err := h.storage.UploadFile(project.ID, mainFile.Name, bytes.NewReader(mainFileContent))
+	fileInfo, filePath, err := h.storage.UploadFile(archiveFilename(manifest, mainFile), mainFileContent)
+	mainFileContent.Close()
 	if err != nil {
 		h.renderError(c, "Failed to upload project file")
 		return
 	}
+	if !matchesManifest(manifest, mainFile, fileInfo) {
+		h.discardUpload(tx, filePath)
+		h.renderError(c, "Project file does not match the archive manifest")
+		return
+	}
 
 	// Create ProjectFile record
 	projectFile := &domain.ProjectFile{
@@ -514,24 +600,30 @@ func (h *ProjectHandler) HandleImport(c *gin.Context) {
 	}
 
 	if err := tx.AddMainFile(project.ID, projectFile); err != nil {
-		h.storage.DeleteFile(filePath)
+		h.discardUpload(tx, filePath)
 		h.renderError(c, "Failed to save project file info")
 		return
 	}
 
 	// Upload sample files
 	for _, sampleFile := range sampleFiles {
-		content, err := readZipFile(sampleFile)
+		content, err := sampleFile.Open()
 		if err != nil {
 			log.Printf("Failed to read sample file %s: %v", sampleFile.Name, err)
 			continue
 		}
 
-		fileInfo, filePath, err := h.storage.UploadFile(project.ID, sampleFile.Name, bytes.NewReader(content))
+		fileInfo, filePath, err := h.storage.UploadFile(archiveFilename(manifest, sampleFile), content)
+		content.Close()
 		if err != nil {
 			log.Printf("Failed to upload sample file %s: %v", sampleFile.Name, err)
 			continue
 		}
+		if !matchesManifest(manifest, sampleFile, fileInfo) {
+			h.discardUpload(tx, filePath)
+			h.renderError(c, fmt.Sprintf("Sample %s does not match the archive manifest", sampleFile.Name))
+			return
+		}
 
 		sample := &domain.SampleFile{
 			ProjectID: project.ID,
@@ -546,12 +638,18 @@ func (h *ProjectHandler) HandleImport(c *gin.Context) {
 		}
 
 		if err := tx.AddSampleFile(project.ID, sample); err != nil {
-			h.storage.DeleteFile(filePath)
+			h.discardUpload(tx, filePath)
 			log.Printf("Failed to save sample file info %s: %v", sampleFile.Name, err)
 			continue
 		}
 	}
 
+	// Record the imported state as the first revision
+	if _, err := tx.CreateRevision(project.ID, project.UserID, "Imported from zip"); err != nil {
+		h.renderError(c, "Failed to record revision")
+		return
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		h.renderError(c, "Failed to save project")
@@ -565,6 +663,51 @@ func (h *ProjectHandler) HandleImport(c *gin.Context) {
 	})
 }
 
+// readArchiveManifest returns the archive's manifest, or nil if it has none
+func readArchiveManifest(reader *zip.Reader) (*domain.ArchiveManifest, error) {
+	for _, f := range reader.File {
+		if f.Name != domain.ArchiveManifestName {
+			continue
+		}
+
+		content, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer content.Close()
+
+		var manifest domain.ArchiveManifest
+		if err := json.NewDecoder(io.LimitReader(content, maxManifestSize)).Decode(&manifest); err != nil {
+			return nil, err
+		}
+		if manifest.Format > domain.ArchiveManifestFormat {
+			return nil, fmt.Errorf("unsupported manifest format %d", manifest.Format)
+		}
+		return &manifest, nil
+	}
+
+	return nil, nil
+}
+
+// archiveFilename is the original name of an archived file, as recorded in the manifest
+func archiveFilename(manifest *domain.ArchiveManifest, f *zip.File) string {
+	if manifest != nil {
+		if entry, ok := manifest.Entry(f.Name); ok && entry.Filename != "" {
+			return entry.Filename
+		}
+	}
+	return f.Name
+}
+
+// matchesManifest reports whether an imported file has the hash the manifest recorded
+func matchesManifest(manifest *domain.ArchiveManifest, f *zip.File, fileInfo domain.FileInfo) bool {
+	if manifest == nil {
+		return true
+	}
+	entry, ok := manifest.Entry(f.Name)
+	return !ok || entry.Hash == "" || entry.Hash == fileInfo.Hash
+}
+
 func isProjectFile(ext string) bool {
 	projectExts := map[string]bool{
 		".flp":   true, // FL Studio
@@ -587,13 +730,56 @@ func isAudioFile(ext string) bool {
 	return audioExts[ext]
 }
 
-func readZipFile(f *zip.File) ([]byte, error) {
-	rc, err := f.Open()
+// limitRequestBody rejects requests whose declared length exceeds limit and
+// caps the body so an undeclared length cannot exceed it either
+func limitRequestBody(c *gin.Context, limit int64) bool {
+	if c.Request.ContentLength > limit {
+		return false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	return true
+}
+
+// authorizeProject resolves the project named in the URL and checks that the
+// logged-in user may perform action on it, rendering a 400, 403 or 404 page otherwise
+func (h *ProjectHandler) authorizeProject(c *gin.Context, action authz.Action) (*domain.Project, bool) {
+	return authorizeProject(c, h.authz, action)
+}
+
+func authorizeProject(c *gin.Context, authorizer *authz.Authorizer, action authz.Action) (*domain.Project, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid project ID")
+		return nil, false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	project, err := authorizer.Authorize(userID, uint(id), action)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrForbidden):
+			common.RenderErrorStatus(c, http.StatusForbidden, "You do not have permission to change this project")
+		case errors.Is(err, common.ErrNotFound):
+			common.RenderErrorStatus(c, http.StatusNotFound, "Project not found")
+		default:
+			common.RenderErrorStatus(c, http.StatusInternalServerError, "Failed to load project")
+		}
+		return nil, false
+	}
+
+	return project, true
+}
+
+// discardUpload deletes an uploaded object unless another file row still references it
+func (h *ProjectHandler) discardUpload(repo domain.ProjectRepository, filePath string) {
+	orphans, err := repo.ReleaseBlobs([]string{filePath})
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to release upload %s: %v", filePath, err)
+		return
+	}
+	if _, err := gc.Sweep(h.storage, orphans, h.gracePeriod); err != nil {
+		log.Printf("Failed to delete upload %s: %v", filePath, err)
 	}
-	defer rc.Close()
-	return io.ReadAll(rc)
 }
 
 // renderError renders the error template with given message
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// Compare handles GET /projects/:id/compare to show what changed between two revisions
+func (h *ProjectHandler) Compare(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	fromNumber, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		common.RenderError(c, "Invalid 'from' revision")
+		return
+	}
+
+	toNumber, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		common.RenderError(c, "Invalid 'to' revision")
+		return
+	}
+
+	from, err := h.repo.FindRevision(project.ID, fromNumber)
+	if err != nil {
+		common.RenderError(c, "Revision not found")
+		return
+	}
+
+	to, err := h.repo.FindRevision(project.ID, toNumber)
+	if err != nil {
+		common.RenderError(c, "Revision not found")
+		return
+	}
+
+	diff := domain.DiffRevisions(from, to)
+
+	common.Render(c, gin.H{
+		"content":        "compare",
+		"project":        project,
+		"diff":           diff,
+		"sizeDelta":      formatSizeDelta(diff.SizeDelta),
+		"formatFileSize": formatFileSize,
+	})
+}
+
+// Restore handles POST /projects/:id/revisions/:number/restore to roll a project back
+func (h *ProjectHandler) Restore(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id")
+	if userID == nil {
+		common.RenderError(c, "User must be logged in")
+		return
+	}
+
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		common.RenderError(c, "Invalid revision number")
+		return
+	}
+
+	if _, err := h.repo.RestoreRevision(project.ID, number, userID.(uint)); err != nil {
+		if common.IsNotFound(err) {
+			common.RenderError(c, "Revision not found")
+			return
+		}
+		common.RenderError(c, "Failed to restore revision")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// formatSizeDelta renders a signed byte difference, e.g. "+1.2 MB"
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + formatFileSize(-delta)
+	}
+	return "+" + formatFileSize(delta)
+}
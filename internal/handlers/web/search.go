@@ -0,0 +1,81 @@
+package web
+
+import (
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// searchHit is a search result with its highlights ready for the template
+type searchHit struct {
+	domain.SearchResult
+	Name        template.HTML
+	Description template.HTML
+}
+
+// Search handles GET /search?q=&page= and lists the matching projects
+func (h *ProjectHandler) Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	data := gin.H{
+		"content": "search",
+		"title":   "Search",
+		"query":   text,
+		"hits":    []searchHit{},
+	}
+	if text == "" {
+		common.Render(c, data)
+		return
+	}
+	if len(text) > domain.MaxSearchLength {
+		common.RenderError(c, "Search query is too long")
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	results, err := h.repo.Search(domain.SearchQuery{
+		Text:   text,
+		UserID: userID,
+		Limit:  domain.DefaultSearchLimit,
+		Offset: (page - 1) * domain.DefaultSearchLimit,
+	})
+	if err != nil {
+		common.RenderError(c, "Failed to search projects")
+		return
+	}
+
+	// Highlights are escaped by the repository, so they can be rendered as is
+	hits := make([]searchHit, len(results.Results))
+	for i, result := range results.Results {
+		hits[i] = searchHit{
+			SearchResult: result,
+			Name:         template.HTML(result.NameHighlight),
+			Description:  template.HTML(result.DescriptionHighlight),
+		}
+	}
+
+	pageURL := func(n int) string {
+		return "/search?" + url.Values{"q": {text}, "page": {strconv.Itoa(n)}}.Encode()
+	}
+	data["hits"] = hits
+	data["total"] = results.Total
+	if page > 1 {
+		data["prevPage"] = pageURL(page - 1)
+	}
+	if int64(results.Offset+len(hits)) < results.Total {
+		data["nextPage"] = pageURL(page + 1)
+	}
+
+	common.Render(c, data)
+}
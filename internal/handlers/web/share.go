@@ -0,0 +1,150 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// Shared handles GET /s/:token, the read-only page behind a share link.
+// Visitors do not need an account.
+func (h *ProjectHandler) Shared(c *gin.Context) {
+	link, project, ok := h.resolveShare(c)
+	if !ok {
+		return
+	}
+
+	if !middleware.ShareUnlocked(c, link) {
+		c.HTML(http.StatusOK, "auth_layout", gin.H{
+			"content": "share_password",
+			"token":   c.Param("token"),
+		})
+		return
+	}
+
+	if err := h.repo.RecordShareLinkUse(link.ID); err != nil {
+		log.Printf("[WARN] Failed to record use of share link %d: %v", link.ID, err)
+	}
+
+	c.HTML(http.StatusOK, "auth_layout", gin.H{
+		"content":        "shared",
+		"project":        project,
+		"link":           link,
+		"token":          c.Param("token"),
+		"formatFileSize": formatFileSize,
+	})
+}
+
+// UnlockShared handles POST /s/:token to enter a share link's password
+func (h *ProjectHandler) UnlockShared(c *gin.Context) {
+	link, _, ok := h.resolveShare(c)
+	if !ok {
+		return
+	}
+
+	token := c.Param("token")
+	if !link.CheckPassword(c.PostForm("password")) {
+		c.HTML(http.StatusUnauthorized, "auth_layout", gin.H{
+			"content": "share_password",
+			"token":   token,
+			"error":   "Incorrect password",
+		})
+		return
+	}
+
+	if err := middleware.UnlockShare(c, link); err != nil {
+		common.RenderErrorStatus(c, http.StatusInternalServerError, "Failed to unlock link")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/s/"+token)
+}
+
+// CreateShareLink handles POST /projects/:id/shares and shows the new link
+// once; only its hash is kept
+func (h *ProjectHandler) CreateShareLink(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	days := 0
+	if value := c.PostForm("expires_in_days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil || days < 0 {
+			common.RenderError(c, "Invalid expiry")
+			return
+		}
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	link, token, err := domain.NewShareLink(project.ID, userID, domain.ShareLinkOptions{
+		Label:         c.PostForm("label"),
+		Password:      c.PostForm("password"),
+		AllowDownload: c.PostForm("allow_download") == "on",
+		ExpiresIn:     time.Duration(days) * 24 * time.Hour,
+	})
+	if err != nil {
+		common.RenderError(c, "Failed to create share link")
+		return
+	}
+	if err := h.repo.CreateShareLink(link); err != nil {
+		common.RenderError(c, "Failed to create share link")
+		return
+	}
+
+	c.HTML(http.StatusOK, "share-created", gin.H{
+		"link": link,
+		"path": "/s/" + token,
+	})
+}
+
+// RevokeShareLink handles POST /projects/:id/shares/:shareId/revoke
+func (h *ProjectHandler) RevokeShareLink(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionManage)
+	if !ok {
+		return
+	}
+
+	linkID, err := strconv.ParseUint(c.Param("shareId"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid share link ID")
+		return
+	}
+
+	if err := h.repo.RevokeShareLink(project.ID, uint(linkID)); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "Share link not found")
+			return
+		}
+		common.RenderError(c, "Failed to revoke share link")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// resolveShare looks up the link in the URL, rendering a 404 if it is
+// unknown, expired or revoked
+func (h *ProjectHandler) resolveShare(c *gin.Context) (*domain.ShareLink, *domain.Project, bool) {
+	link, project, err := h.authz.AuthorizeShare(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, domain.ErrShareLinkInvalid) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "This link is invalid or has expired")
+			return nil, nil, false
+		}
+		common.RenderErrorStatus(c, http.StatusInternalServerError, "Failed to load shared project")
+		return nil, nil, false
+	}
+	return link, project, true
+}
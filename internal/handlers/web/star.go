@@ -0,0 +1,136 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// exploreLimit is how many trending projects the explore page shows
+const exploreLimit = 30
+
+// starButton is what the "star-button" template needs
+type starButton struct {
+	ProjectID uint
+	Starred   bool
+	Count     int64
+}
+
+// starButtons returns a template function building the star button for a
+// project, given the IDs of the projects the viewer starred
+func starButtons(starred map[uint]bool) func(domain.Project) starButton {
+	return func(project domain.Project) starButton {
+		return starButton{
+			ProjectID: project.ID,
+			Starred:   starred[project.ID],
+			Count:     project.StarCount,
+		}
+	}
+}
+
+// Explore handles GET /explore to list public projects by recent activity
+func (h *ProjectHandler) Explore(c *gin.Context) {
+	projects, err := h.repo.FindTrending(exploreLimit)
+	if err != nil {
+		common.RenderError(c, "Failed to load projects")
+		return
+	}
+
+	starred, err := h.starredIDs(c)
+	if err != nil {
+		common.RenderError(c, "Failed to load projects")
+		return
+	}
+
+	common.Render(c, gin.H{
+		"content":    "explore",
+		"title":      "Explore",
+		"projects":   projects,
+		"starButton": starButtons(starred),
+	})
+}
+
+// Starred handles GET /starred to list the projects the user starred
+func (h *ProjectHandler) Starred(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	projects, err := h.repo.FindStarred(userID)
+	if err != nil {
+		common.RenderError(c, "Failed to load starred projects")
+		return
+	}
+
+	starred := make(map[uint]bool, len(projects))
+	for _, project := range projects {
+		starred[project.ID] = true
+	}
+
+	common.Render(c, gin.H{
+		"content":    "explore",
+		"title":      "Starred",
+		"projects":   projects,
+		"starButton": starButtons(starred),
+	})
+}
+
+// Star handles POST /projects/:id/star and re-renders the star button
+func (h *ProjectHandler) Star(c *gin.Context) {
+	h.setStarred(c, true)
+}
+
+// Unstar handles POST /projects/:id/unstar and re-renders the star button
+func (h *ProjectHandler) Unstar(c *gin.Context) {
+	h.setStarred(c, false)
+}
+
+func (h *ProjectHandler) setStarred(c *gin.Context, starred bool) {
+	project, ok := h.authorizeProject(c, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	var err error
+	if starred {
+		err = h.repo.StarProject(project.ID, userID)
+	} else {
+		err = h.repo.UnstarProject(project.ID, userID)
+	}
+	if err != nil {
+		common.RenderError(c, "Failed to update star")
+		return
+	}
+
+	if project, err = h.repo.FindByID(project.ID); err != nil {
+		common.RenderError(c, "Failed to update star")
+		return
+	}
+
+	c.HTML(http.StatusOK, "star-button", starButton{
+		ProjectID: project.ID,
+		Starred:   starred,
+		Count:     project.StarCount,
+	})
+}
+
+// starredIDs returns the set of projects the current user starred
+func (h *ProjectHandler) starredIDs(c *gin.Context) (map[uint]bool, error) {
+	starred := make(map[uint]bool)
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		return starred, nil
+	}
+
+	projects, err := h.repo.FindStarred(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		starred[project.ID] = true
+	}
+	return starred, nil
+}
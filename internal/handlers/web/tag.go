@@ -0,0 +1,117 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// tagGroup is one section of the curated taxonomy in the tag picker
+type tagGroup struct {
+	Label string
+	Names []string
+}
+
+// tagPicker is what the "tag-picker" template needs
+type tagPicker struct {
+	Groups   []tagGroup
+	Selected map[string]bool
+	Custom   string // Free-form tags, comma-separated
+}
+
+// newTagPicker builds the tag picker for a project's form. The project is
+// nil when creating one.
+func newTagPicker(project *domain.Project) tagPicker {
+	picker := tagPicker{
+		Groups: []tagGroup{
+			{Label: "Genre", Names: domain.TagTaxonomy[domain.TagGenre]},
+			{Label: "Mood", Names: domain.TagTaxonomy[domain.TagMood]},
+			{Label: "Instrument", Names: domain.TagTaxonomy[domain.TagInstrument]},
+		},
+		Selected: make(map[string]bool),
+	}
+	if project != nil {
+		for _, name := range project.TagNames() {
+			picker.Selected[name] = true
+		}
+		picker.Custom = project.FreeTagList()
+	}
+	return picker
+}
+
+// formTags returns the tags submitted by the tag picker: the checked
+// taxonomy tags and the comma-separated custom ones
+func formTags(c *gin.Context) []string {
+	return append(c.PostFormArray("tags"), strings.Split(c.PostForm("custom_tags"), ",")...)
+}
+
+// Tags handles GET /tags to list the tags in use on public projects
+func (h *ProjectHandler) Tags(c *gin.Context) {
+	tags, err := h.repo.FindTagCounts(0)
+	if err != nil {
+		common.RenderError(c, "Failed to load tags")
+		return
+	}
+
+	common.Render(c, gin.H{
+		"content": "tags",
+		"tags":    tags,
+	})
+}
+
+// Tag handles GET /tags/:name to list the public projects with a tag
+func (h *ProjectHandler) Tag(c *gin.Context) {
+	tag, err := h.repo.FindTag(c.Param("name"))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "Tag not found")
+			return
+		}
+		common.RenderError(c, "Failed to load tag")
+		return
+	}
+
+	query := c.Request.URL.Query()
+	opts, err := domain.ParseProjectListOptions(query)
+	if err != nil {
+		common.RenderError(c, err.Error())
+		return
+	}
+	opts.Public = true
+	opts.Tag = tag.Name
+
+	page, err := h.repo.ListProjects(opts)
+	if err != nil {
+		common.RenderError(c, "Failed to load projects")
+		return
+	}
+
+	starred, err := h.starredIDs(c)
+	if err != nil {
+		common.RenderError(c, "Failed to load projects")
+		return
+	}
+
+	data := gin.H{
+		"content":    "explore",
+		"title":      "#" + tag.Name,
+		"projects":   page.Items,
+		"starButton": starButtons(starred),
+	}
+	if page.NextCursor != "" {
+		query.Set("cursor", page.NextCursor)
+		data["nextPage"] = "/tags/" + tag.Name + "?" + query.Encode()
+	}
+
+	// Infinite scroll requests only need the next cards
+	if opts.Cursor != "" && common.IsHtmx(c) {
+		c.HTML(http.StatusOK, "explore-cards", data)
+		return
+	}
+	common.Render(c, data)
+}
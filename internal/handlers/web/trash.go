@@ -0,0 +1,223 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/authz"
+	"dawhub/internal/domain"
+	"dawhub/internal/gc"
+	"dawhub/internal/middleware"
+	"dawhub/pkg/common"
+)
+
+// Trash handles GET /trash to list the user's deleted projects and samples
+func (h *ProjectHandler) Trash(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	trash, err := h.repo.FindTrash(userID)
+	if err != nil {
+		common.RenderError(c, "Failed to load trash")
+		return
+	}
+
+	common.Render(c, gin.H{
+		"content":        "trash",
+		"trash":          trash,
+		"formatFileSize": formatFileSize,
+	})
+}
+
+// RestoreProject handles POST /trash/projects/:id/restore
+func (h *ProjectHandler) RestoreProject(c *gin.Context) {
+	project, ok := h.authorizeTrashedProject(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RestoreProject(project.ID); err != nil {
+		common.RenderError(c, "Failed to restore project")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// PurgeProject handles POST /trash/projects/:id/delete to delete a project
+// in the trash for good
+func (h *ProjectHandler) PurgeProject(c *gin.Context) {
+	project, ok := h.authorizeTrashedProject(c)
+	if !ok {
+		return
+	}
+
+	orphans, err := h.repo.PurgeProject(project.ID)
+	if err != nil {
+		common.RenderError(c, "Failed to delete project")
+		return
+	}
+	if _, err := gc.Sweep(h.storage, orphans, h.gracePeriod); err != nil {
+		log.Printf("Failed to delete project files: %v", err)
+	}
+
+	common.HandleRedirect(c, "/trash")
+}
+
+// RemoveSample handles POST /projects/:id/samples/:fileId/delete to move a
+// sample to the trash
+func (h *ProjectHandler) RemoveSample(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	fileID, err := strconv.ParseUint(c.Param("fileId"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid file ID")
+		return
+	}
+	var sample *domain.SampleFile
+	for i := range project.SampleFiles {
+		if project.SampleFiles[i].ID == uint(fileID) {
+			sample = &project.SampleFiles[i]
+			break
+		}
+	}
+	if sample == nil {
+		common.RenderErrorStatus(c, http.StatusNotFound, "File not found")
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	err = h.changeSample(project.ID, userID, fmt.Sprintf("Removed %s", sample.Filename), func(tx domain.ProjectRepository) error {
+		return tx.RemoveSampleFile(project.ID, sample.ID)
+	})
+	if err != nil {
+		common.RenderError(c, "Failed to remove file")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// RestoreSample handles POST /trash/projects/:id/samples/:fileId/restore
+func (h *ProjectHandler) RestoreSample(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionWrite)
+	if !ok {
+		return
+	}
+
+	sample, ok := h.findTrashedSample(c, project.ID)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	err := h.changeSample(project.ID, userID, fmt.Sprintf("Restored %s", sample.Filename), func(tx domain.ProjectRepository) error {
+		return tx.RestoreSampleFile(project.ID, sample.ID)
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrTooManySamples) || errors.Is(err, domain.ErrProjectTooLarge) {
+			common.RenderError(c, err.Error())
+			return
+		}
+		common.RenderError(c, "Failed to restore file")
+		return
+	}
+
+	common.HandleRedirect(c, fmt.Sprintf("/projects/%d", project.ID))
+}
+
+// PurgeSample handles POST /trash/projects/:id/samples/:fileId/delete to
+// delete a sample in the trash for good
+func (h *ProjectHandler) PurgeSample(c *gin.Context) {
+	project, ok := h.authorizeProject(c, authz.ActionDelete)
+	if !ok {
+		return
+	}
+
+	sample, ok := h.findTrashedSample(c, project.ID)
+	if !ok {
+		return
+	}
+
+	orphans, err := h.repo.PurgeSampleFile(project.ID, sample.ID)
+	if err != nil {
+		common.RenderError(c, "Failed to delete file")
+		return
+	}
+	if _, err := gc.Sweep(h.storage, orphans, h.gracePeriod); err != nil {
+		log.Printf("Failed to delete sample file: %v", err)
+	}
+
+	common.HandleRedirect(c, "/trash")
+}
+
+// changeSample applies a change to a project's samples and records it as a revision
+func (h *ProjectHandler) changeSample(projectID, userID uint, message string, change func(tx domain.ProjectRepository) error) error {
+	tx, err := h.repo.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+	if _, err := tx.CreateRevision(projectID, userID, message); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// authorizeTrashedProject resolves the trashed project named in the URL.
+// Only its owner may restore or purge it.
+func (h *ProjectHandler) authorizeTrashedProject(c *gin.Context) (*domain.Project, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid project ID")
+		return nil, false
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	project, err := h.repo.FindTrashedProject(uint(id))
+	if err == nil && project.UserID != userID {
+		err = common.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "Project not found in trash")
+			return nil, false
+		}
+		common.RenderError(c, "Failed to load project")
+		return nil, false
+	}
+
+	return project, true
+}
+
+// findTrashedSample resolves the project's trashed sample named in the URL
+func (h *ProjectHandler) findTrashedSample(c *gin.Context, projectID uint) (*domain.SampleFile, bool) {
+	fileID, err := strconv.ParseUint(c.Param("fileId"), 10, 32)
+	if err != nil {
+		common.RenderError(c, "Invalid file ID")
+		return nil, false
+	}
+
+	sample, err := h.repo.FindTrashedSample(projectID, uint(fileID))
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			common.RenderErrorStatus(c, http.StatusNotFound, "File not found in trash")
+			return nil, false
+		}
+		common.RenderError(c, "Failed to load file")
+		return nil, false
+	}
+
+	return sample, true
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+
+	"dawhub/internal/domain"
 )
 
 // For API routes - using JWT
@@ -84,3 +86,86 @@ func DualAuthMiddleware() gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+// CurrentUserID returns the authenticated user's ID from the request context.
+// JWT claims decode numbers as float64 while sessions store uint.
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+
+	switch id := value.(type) {
+	case uint:
+		return id, id != 0
+	case float64:
+		return uint(id), id > 0
+	default:
+		return 0, false
+	}
+}
+
+// UserLookup loads accounts for ActiveUser
+type UserLookup interface {
+	GetByID(id uint) (*domain.User, error)
+}
+
+// ActiveUser rejects requests from accounts disabled since they signed in,
+// and stores the account as "user" for later handlers. It must run after one
+// of the auth middlewares.
+func ActiveUser(users UserLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := CurrentUserID(c)
+		if !ok {
+			rejectInactive(c, "Authentication required")
+			return
+		}
+
+		user, err := users.GetByID(userID)
+		if err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if user.IsDisabled() {
+			session := sessions.Default(c)
+			session.Clear()
+			session.Save()
+			rejectInactive(c, "This account has been disabled")
+			return
+		}
+
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+func rejectInactive(c *gin.Context, message string) {
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+	} else {
+		c.Redirect(http.StatusSeeOther, "/")
+	}
+	c.Abort()
+}
+
+// CurrentUser returns the account stored by ActiveUser
+func CurrentUser(c *gin.Context) (*domain.User, bool) {
+	user, ok := c.Get("user")
+	if !ok {
+		return nil, false
+	}
+	u, ok := user.(*domain.User)
+	return u, ok
+}
+
+// AdminOnly hides routes from everyone but administrators. It must run
+// after ActiveUser.
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user, ok := CurrentUser(c); !ok || !user.IsAdmin {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.Next()
+	}
+}
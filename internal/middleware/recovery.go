@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns panics into 500 responses like gin.Recovery, except
+// http.ErrAbortHandler, which is passed on so net/http drops the connection.
+// Handlers use it to abort a response whose headers are already sent, such
+// as a download that fails its hash check partway through.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err interface{}) {
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	"dawhub/internal/domain"
+)
+
+// SharePasswordHeader lets API clients present a share link's password
+// without going through the unlock form
+const SharePasswordHeader = "X-Share-Password"
+
+// ShareUnlocked reports whether the visitor may use a share link, either
+// because it has no password or because they entered it earlier in this
+// session or sent it in SharePasswordHeader
+func ShareUnlocked(c *gin.Context, link *domain.ShareLink) bool {
+	if !link.HasPassword() {
+		return true
+	}
+	if unlocked, _ := sessions.Default(c).Get(shareSessionKey(link)).(bool); unlocked {
+		return true
+	}
+	if password := c.GetHeader(SharePasswordHeader); password != "" {
+		return link.CheckPassword(password)
+	}
+	return false
+}
+
+// UnlockShare remembers for the rest of the session that the visitor
+// entered the link's password
+func UnlockShare(c *gin.Context, link *domain.ShareLink) error {
+	session := sessions.Default(c)
+	session.Set(shareSessionKey(link), true)
+	return session.Save()
+}
+
+func shareSessionKey(link *domain.ShareLink) string {
+	return fmt.Sprintf("share_unlocked_%d", link.ID)
+}
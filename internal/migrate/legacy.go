@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+)
+
+// baselineVersion is the migration matching the schema AutoMigrate built
+// before versioned migrations
+const baselineVersion = 1
+
+// adoptLegacySchema prepares a database created by AutoMigrate for the
+// baseline's idempotent statements. The last release before versioned
+// migrations brought such databases up to the baseline on every start, so
+// only indexes it would have dropped need attention. Fresh databases are left
+// alone.
+func adoptLegacySchema(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable(&domain.Project{}) {
+		return nil
+	}
+
+	// File paths are content-addressed and shared, so drop the old unique indexes
+	return dropLegacyFilePathIndexes(tx)
+}
+
+func dropLegacyFilePathIndexes(db *gorm.DB) error {
+	legacy := map[interface{}]string{
+		&domain.ProjectFile{}: "idx_project_files_file_path",
+		&domain.SampleFile{}:  "idx_sample_files_file_path",
+	}
+	for model, name := range legacy {
+		if !db.Migrator().HasIndex(model, name) {
+			continue
+		}
+		if err := db.Migrator().DropIndex(model, name); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+// Package migrate applies the versioned SQL migrations that define the
+// database schema. Migrations live in migrations/<dialect> as
+// <version>_<name>.up.sql and <version>_<name>.down.sql, and the versions
+// applied to a database are recorded in its schema_migrations table.
+package migrate
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+var (
+	// ErrSchemaTooNew is returned when the database has migrations applied
+	// that this build doesn't know, usually because a newer release ran
+	ErrSchemaTooNew = errors.New("database schema is newer than this build")
+	// ErrChecksumMismatch is returned when an applied migration was edited
+	// after it ran
+	ErrChecksumMismatch = errors.New("applied migration does not match this build")
+	// ErrPending is returned by Verify when migrations are waiting to be applied
+	ErrPending = errors.New("database schema has pending migrations")
+	// ErrIrreversible is returned when rolling back a migration without a down script
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+)
+
+// lockID keys the Postgres advisory lock that serializes migrations across
+// processes starting at the same time
+const lockID = 7341203
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // Empty if the migration can't be rolled back
+	Checksum string // SHA-256 of Up
+}
+
+// String returns the migration's file name prefix, e.g. "0001_baseline"
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status is a migration along with when it was applied, if it was
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	Version   int64 `gorm:"primaryKey"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	checksum text NOT NULL,
+	applied_at timestamp NOT NULL
+)`
+
+// Migrator applies the migrations for one database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New creates a migrator for the database's dialect
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations returns every migration known to this build, oldest first
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Verify checks that every applied migration is known to this build and
+// unchanged, and that none are pending
+func (m *Migrator) Verify() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPending, pending[0])
+	}
+	return nil
+}
+
+// Pending returns the migrations not yet applied, oldest first. It fails if
+// the applied ones don't match this build.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.check(applied); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order. Each one runs in its own
+// transaction together with its schema_migrations row, so a failure leaves
+// the schema at the previous version rather than half applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		ran, err := m.apply(migration)
+		if err != nil {
+			return done, fmt.Errorf("migration %s failed: %w", migration, err)
+		}
+		if ran {
+			log.Printf("Applied migration %s", migration)
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// Down rolls back the given number of most recently applied migrations
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.check(applied); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("%w: %s", ErrIrreversible, migration)
+		}
+		if err := m.revert(migration); err != nil {
+			return done, fmt.Errorf("rollback of %s failed: %w", migration, err)
+		}
+		log.Printf("Rolled back migration %s", migration)
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// apply runs a migration unless another process applied it first
+func (m *Migrator) apply(migration Migration) (bool, error) {
+	ran := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := m.lock(tx); err != nil {
+			return err
+		}
+		if err := tx.Exec(createTable).Error; err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+
+		var count int64
+		if err := tx.Model(&appliedMigration{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if migration.Version == baselineVersion {
+			if err := adoptLegacySchema(tx); err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return err
+		}
+		ran = true
+		return tx.Create(&appliedMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Checksum:  migration.Checksum,
+			AppliedAt: time.Now().UTC(),
+		}).Error
+	})
+	return ran, err
+}
+
+// revert runs a migration's down script and forgets it was applied
+func (m *Migrator) revert(migration Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := m.lock(tx); err != nil {
+			return err
+		}
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err
+		}
+		return tx.Where("version = ?", migration.Version).Delete(&appliedMigration{}).Error
+	})
+}
+
+// lock serializes migrations for the rest of the transaction. SQLite
+// transactions already hold the database's write lock.
+func (m *Migrator) lock(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockID).Error; err != nil {
+		return fmt.Errorf("failed to lock schema: %w", err)
+	}
+	return nil
+}
+
+// applied returns the schema_migrations rows by version
+func (m *Migrator) applied() (map[int64]appliedMigration, error) {
+	applied := make(map[int64]appliedMigration)
+	if !m.db.Migrator().HasTable(&appliedMigration{}) {
+		return applied, nil
+	}
+
+	var rows []appliedMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// check refuses databases with migrations this build doesn't know or
+// migrations that changed since they were applied
+func (m *Migrator) check(applied map[int64]appliedMigration) error {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, version := range versions {
+		row := applied[version]
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("%w: unknown migration %04d_%s is applied", ErrSchemaTooNew, row.Version, row.Name)
+		}
+		if row.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, migration)
+		}
+	}
+	return nil
+}
+
+// load reads the embedded migrations for a dialect, oldest first
+func load(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for database %q", dialect)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := cutDirection(entry.Name())
+		if !ok {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up script", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func cutDirection(filename string) (string, string, bool) {
+	for _, direction := range []string{"up", "down"} {
+		if base, ok := strings.CutSuffix(filename, "."+direction+".sql"); ok {
+			return base, direction, true
+		}
+	}
+	return "", "", false
+}
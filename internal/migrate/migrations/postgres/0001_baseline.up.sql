@@ -0,0 +1,322 @@
+-- Baseline: the schema as AutoMigrate left it before versioned migrations.
+-- Every statement is idempotent so the baseline can also be applied over a
+-- database created by AutoMigrate.
+
+CREATE TABLE IF NOT EXISTS users (
+	id bigserial,
+	username text NOT NULL,
+	email text NOT NULL,
+	password text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_users_username UNIQUE (username),
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS beta_users (
+	id bigserial,
+	email text NOT NULL,
+	is_subscribed boolean,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_beta_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS project_files (
+	id bigserial,
+	size bigint NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at timestamptz NOT NULL,
+	file_path text NOT NULL,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_project_files_hash ON project_files (hash);
+CREATE INDEX IF NOT EXISTS idx_project_files_blob ON project_files (file_path);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id bigserial,
+	name text,
+	description text,
+	version text,
+	is_public boolean,
+	created_at timestamptz,
+	updated_at timestamptz,
+	user_id bigint,
+	deleted_at timestamptz,
+	main_file_id bigint,
+	total_size bigint NOT NULL DEFAULT 0,
+	star_count bigint NOT NULL DEFAULT 0,
+	download_count bigint NOT NULL DEFAULT 0,
+	trending_score decimal NOT NULL DEFAULT 0,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_projects_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_projects_main_file FOREIGN KEY (main_file_id) REFERENCES project_files (id)
+);
+CREATE INDEX IF NOT EXISTS idx_projects_trending_score ON projects (trending_score);
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects (deleted_at);
+
+CREATE TABLE IF NOT EXISTS sample_files (
+	id bigserial,
+	project_id bigint,
+	file_path text NOT NULL,
+	size bigint NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at timestamptz NOT NULL,
+	library_item_id bigint,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_projects_sample_files FOREIGN KEY (project_id) REFERENCES projects (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sample_files_deleted_at ON sample_files (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_sample_files_library_item_id ON sample_files (library_item_id);
+CREATE INDEX IF NOT EXISTS idx_sample_files_hash ON sample_files (hash);
+CREATE INDEX IF NOT EXISTS idx_sample_files_blob ON sample_files (file_path);
+
+CREATE TABLE IF NOT EXISTS project_revisions (
+	id bigserial,
+	project_id bigint NOT NULL,
+	number bigint NOT NULL,
+	user_id bigint NOT NULL,
+	message text,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_project_revisions_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_revision_number ON project_revisions (project_id, number);
+
+CREATE TABLE IF NOT EXISTS revision_files (
+	id bigserial,
+	revision_id bigint NOT NULL,
+	kind text NOT NULL,
+	file_path text NOT NULL,
+	size bigint NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at timestamptz NOT NULL,
+	library_item_id bigint,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_project_revisions_files FOREIGN KEY (revision_id) REFERENCES project_revisions (id)
+);
+CREATE INDEX IF NOT EXISTS idx_revision_files_revision_id ON revision_files (revision_id);
+CREATE INDEX IF NOT EXISTS idx_revision_files_hash ON revision_files (hash);
+CREATE INDEX IF NOT EXISTS idx_revision_files_file_path ON revision_files (file_path);
+CREATE INDEX IF NOT EXISTS idx_revision_files_library_item_id ON revision_files (library_item_id);
+
+CREATE TABLE IF NOT EXISTS blobs (
+	file_path text,
+	hash text,
+	size bigint NOT NULL,
+	content_type text,
+	ref_count bigint NOT NULL DEFAULT 0,
+	created_at timestamptz,
+	PRIMARY KEY (file_path)
+);
+CREATE INDEX IF NOT EXISTS idx_blobs_hash ON blobs (hash);
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+	id varchar(32),
+	project_id bigint NOT NULL,
+	user_id bigint NOT NULL,
+	filename text NOT NULL,
+	kind text NOT NULL,
+	content_type text NOT NULL,
+	message text,
+	size bigint NOT NULL,
+	hash varchar(64),
+	upload_offset bigint NOT NULL DEFAULT 0,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	staging_path text NOT NULL,
+	storage_upload_id text NOT NULL,
+	part_count bigint NOT NULL DEFAULT 0,
+	hash_state bytea,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions (expires_at);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_project_id ON upload_sessions (project_id);
+
+CREATE TABLE IF NOT EXISTS project_members (
+	id bigserial,
+	project_id bigint NOT NULL,
+	user_id bigint NOT NULL,
+	role varchar(16) NOT NULL,
+	invited_by bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_project_members_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_member ON project_members (project_id, user_id);
+
+CREATE TABLE IF NOT EXISTS project_invites (
+	id bigserial,
+	project_id bigint NOT NULL,
+	email text NOT NULL,
+	user_id bigint,
+	role varchar(16) NOT NULL,
+	token_hash varchar(64) NOT NULL,
+	invited_by bigint NOT NULL,
+	expires_at timestamptz NOT NULL,
+	accepted_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_project_invites_invitee FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_invites_token_hash ON project_invites (token_hash);
+CREATE INDEX IF NOT EXISTS idx_project_invites_project_id ON project_invites (project_id);
+CREATE INDEX IF NOT EXISTS idx_project_invites_user_id ON project_invites (user_id);
+
+CREATE TABLE IF NOT EXISTS share_links (
+	id bigserial,
+	project_id bigint NOT NULL,
+	token_hash varchar(64) NOT NULL,
+	label text,
+	password_hash text,
+	allow_download boolean NOT NULL DEFAULT false,
+	expires_at timestamptz,
+	revoked_at timestamptz,
+	use_count bigint NOT NULL DEFAULT 0,
+	last_used_at timestamptz,
+	created_by bigint NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_share_links_token_hash ON share_links (token_hash);
+CREATE INDEX IF NOT EXISTS idx_share_links_project_id ON share_links (project_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id bigserial,
+	project_id bigint NOT NULL,
+	file_kind varchar(16) NOT NULL,
+	file_id bigint NOT NULL,
+	parent_id bigint,
+	user_id bigint NOT NULL,
+	body text NOT NULL,
+	offset_seconds decimal,
+	resolved_at timestamptz,
+	resolved_by bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_comments_replies FOREIGN KEY (parent_id) REFERENCES comments (id)
+);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comment_file ON comments (project_id, file_kind, file_id);
+
+CREATE TABLE IF NOT EXISTS project_stars (
+	user_id bigint,
+	project_id bigint,
+	created_at timestamptz,
+	PRIMARY KEY (user_id, project_id)
+);
+CREATE INDEX IF NOT EXISTS idx_project_stars_project_id ON project_stars (project_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id bigserial,
+	name varchar(32) NOT NULL,
+	kind varchar(16) NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_tags_kind ON tags (kind);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+CREATE TABLE IF NOT EXISTS project_tags (
+	project_id bigint,
+	tag_id bigint,
+	PRIMARY KEY (project_id, tag_id),
+	CONSTRAINT fk_project_tags_project FOREIGN KEY (project_id) REFERENCES projects (id),
+	CONSTRAINT fk_project_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS library_items (
+	id bigserial,
+	user_id bigint NOT NULL,
+	folder text NOT NULL DEFAULT '',
+	file_path text NOT NULL,
+	size bigint NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at timestamptz NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_library_items_hash ON library_items (hash);
+CREATE INDEX IF NOT EXISTS idx_library_items_blob ON library_items (file_path);
+CREATE INDEX IF NOT EXISTS idx_library_items_folder ON library_items (folder);
+CREATE INDEX IF NOT EXISTS idx_library_items_user_id ON library_items (user_id);
+
+CREATE TABLE IF NOT EXISTS library_item_tags (
+	library_item_id bigint,
+	tag_id bigint,
+	PRIMARY KEY (library_item_id, tag_id),
+	CONSTRAINT fk_library_item_tags_library_item FOREIGN KEY (library_item_id) REFERENCES library_items (id),
+	CONSTRAINT fk_library_item_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+-- Full-text search. projects.search_vector is a weighted tsvector over the
+-- project's name, owner, sample filenames and description. Triggers keep it
+-- current: the projects trigger rebuilds the vector, and changes to samples or
+-- usernames reset it on the affected projects so that trigger runs again.
+-- Samples in the trash are left out.
+-- Filenames are split on _, - and . so "Kick_01.wav" matches "kick".
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS search_vector tsvector;
+CREATE INDEX IF NOT EXISTS idx_projects_search_vector ON projects USING GIN (search_vector);
+
+CREATE OR REPLACE FUNCTION projects_search_vector_build() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('simple', coalesce(NEW.name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce((SELECT username FROM users WHERE id = NEW.user_id), '')), 'B') ||
+		setweight(to_tsvector('simple', coalesce((
+			SELECT string_agg(regexp_replace(filename, '[_.\-]+', ' ', 'g'), ' ')
+			FROM sample_files WHERE project_id = NEW.id AND deleted_at IS NULL), '')), 'C') ||
+		setweight(to_tsvector('simple', coalesce(NEW.description, '')), 'D');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS projects_search_vector_build ON projects;
+CREATE TRIGGER projects_search_vector_build
+	BEFORE INSERT OR UPDATE OF name, description, user_id, search_vector ON projects
+	FOR EACH ROW EXECUTE FUNCTION projects_search_vector_build();
+
+CREATE OR REPLACE FUNCTION sample_files_search_vector_reset() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		UPDATE projects SET search_vector = NULL WHERE id = OLD.project_id;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		UPDATE projects SET search_vector = NULL WHERE id = NEW.project_id;
+	END IF;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS sample_files_search_vector_reset ON sample_files;
+CREATE TRIGGER sample_files_search_vector_reset
+	AFTER INSERT OR UPDATE OF filename, project_id, deleted_at OR DELETE ON sample_files
+	FOR EACH ROW EXECUTE FUNCTION sample_files_search_vector_reset();
+
+CREATE OR REPLACE FUNCTION users_search_vector_reset() RETURNS trigger AS $$
+BEGIN
+	UPDATE projects SET search_vector = NULL WHERE user_id = NEW.id;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS users_search_vector_reset ON users;
+CREATE TRIGGER users_search_vector_reset
+	AFTER UPDATE OF username ON users
+	FOR EACH ROW EXECUTE FUNCTION users_search_vector_reset();
+
+-- Build vectors for rows that predate the triggers
+UPDATE projects SET search_vector = NULL WHERE search_vector IS NULL;
@@ -0,0 +1,6 @@
+DROP TABLE signup_invites;
+
+ALTER TABLE beta_users DROP COLUMN invited_at;
+
+ALTER TABLE users DROP COLUMN disabled_at;
+ALTER TABLE users DROP COLUMN is_admin;
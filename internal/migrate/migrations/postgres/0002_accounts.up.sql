@@ -0,0 +1,17 @@
+-- Admin accounts, disabled accounts and signup invites for beta users
+ALTER TABLE users ADD COLUMN is_admin boolean NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN disabled_at timestamptz;
+
+ALTER TABLE beta_users ADD COLUMN invited_at timestamptz;
+
+CREATE TABLE signup_invites (
+	id bigserial,
+	email text NOT NULL,
+	token_hash varchar(64) NOT NULL,
+	expires_at timestamptz NOT NULL,
+	accepted_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX idx_signup_invites_email ON signup_invites (email);
+CREATE UNIQUE INDEX idx_signup_invites_token_hash ON signup_invites (token_hash);
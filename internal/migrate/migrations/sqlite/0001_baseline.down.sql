@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS library_item_tags;
+DROP TABLE IF EXISTS library_items;
+DROP TABLE IF EXISTS project_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS project_stars;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS share_links;
+DROP TABLE IF EXISTS project_invites;
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS upload_sessions;
+DROP TABLE IF EXISTS blobs;
+DROP TABLE IF EXISTS revision_files;
+DROP TABLE IF EXISTS project_revisions;
+DROP TABLE IF EXISTS sample_files;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS project_files;
+DROP TABLE IF EXISTS beta_users;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,253 @@
+-- Baseline: the schema as AutoMigrate left it before versioned migrations.
+-- Every statement is idempotent so the baseline can also be applied over a
+-- database created by AutoMigrate.
+
+CREATE TABLE IF NOT EXISTS users (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	username text NOT NULL,
+	email text NOT NULL,
+	password text NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT uni_users_username UNIQUE (username),
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS beta_users (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	email text NOT NULL,
+	is_subscribed numeric,
+	created_at datetime,
+	CONSTRAINT uni_beta_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS project_files (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	size integer NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at datetime NOT NULL,
+	file_path text NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_project_files_hash ON project_files (hash);
+CREATE INDEX IF NOT EXISTS idx_project_files_blob ON project_files (file_path);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text,
+	description text,
+	version text,
+	is_public numeric,
+	created_at datetime,
+	updated_at datetime,
+	user_id integer,
+	deleted_at datetime,
+	main_file_id integer,
+	total_size integer NOT NULL DEFAULT 0,
+	star_count integer NOT NULL DEFAULT 0,
+	download_count integer NOT NULL DEFAULT 0,
+	trending_score real NOT NULL DEFAULT 0,
+	CONSTRAINT fk_projects_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_projects_main_file FOREIGN KEY (main_file_id) REFERENCES project_files (id)
+);
+CREATE INDEX IF NOT EXISTS idx_projects_trending_score ON projects (trending_score);
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects (deleted_at);
+
+CREATE TABLE IF NOT EXISTS sample_files (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	project_id integer,
+	file_path text NOT NULL,
+	size integer NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at datetime NOT NULL,
+	library_item_id integer,
+	deleted_at datetime,
+	CONSTRAINT fk_projects_sample_files FOREIGN KEY (project_id) REFERENCES projects (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sample_files_deleted_at ON sample_files (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_sample_files_library_item_id ON sample_files (library_item_id);
+CREATE INDEX IF NOT EXISTS idx_sample_files_hash ON sample_files (hash);
+CREATE INDEX IF NOT EXISTS idx_sample_files_blob ON sample_files (file_path);
+
+CREATE TABLE IF NOT EXISTS project_revisions (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	project_id integer NOT NULL,
+	number integer NOT NULL,
+	user_id integer NOT NULL,
+	message text,
+	created_at datetime,
+	CONSTRAINT fk_project_revisions_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_revision_number ON project_revisions (project_id, number);
+
+CREATE TABLE IF NOT EXISTS revision_files (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	revision_id integer NOT NULL,
+	kind text NOT NULL,
+	file_path text NOT NULL,
+	size integer NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at datetime NOT NULL,
+	library_item_id integer,
+	CONSTRAINT fk_project_revisions_files FOREIGN KEY (revision_id) REFERENCES project_revisions (id)
+);
+CREATE INDEX IF NOT EXISTS idx_revision_files_revision_id ON revision_files (revision_id);
+CREATE INDEX IF NOT EXISTS idx_revision_files_hash ON revision_files (hash);
+CREATE INDEX IF NOT EXISTS idx_revision_files_file_path ON revision_files (file_path);
+CREATE INDEX IF NOT EXISTS idx_revision_files_library_item_id ON revision_files (library_item_id);
+
+CREATE TABLE IF NOT EXISTS blobs (
+	file_path text,
+	hash text,
+	size integer NOT NULL,
+	content_type text,
+	ref_count integer NOT NULL DEFAULT 0,
+	created_at datetime,
+	PRIMARY KEY (file_path)
+);
+CREATE INDEX IF NOT EXISTS idx_blobs_hash ON blobs (hash);
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+	id text,
+	project_id integer NOT NULL,
+	user_id integer NOT NULL,
+	filename text NOT NULL,
+	kind text NOT NULL,
+	content_type text NOT NULL,
+	message text,
+	size integer NOT NULL,
+	hash text,
+	upload_offset integer NOT NULL DEFAULT 0,
+	expires_at datetime NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	staging_path text NOT NULL,
+	storage_upload_id text NOT NULL,
+	part_count integer NOT NULL DEFAULT 0,
+	hash_state blob,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions (expires_at);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_project_id ON upload_sessions (project_id);
+
+CREATE TABLE IF NOT EXISTS project_members (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	project_id integer NOT NULL,
+	user_id integer NOT NULL,
+	role text NOT NULL,
+	invited_by integer,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_project_members_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_member ON project_members (project_id, user_id);
+
+CREATE TABLE IF NOT EXISTS project_invites (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	project_id integer NOT NULL,
+	email text NOT NULL,
+	user_id integer,
+	role text NOT NULL,
+	token_hash text NOT NULL,
+	invited_by integer NOT NULL,
+	expires_at datetime NOT NULL,
+	accepted_at datetime,
+	created_at datetime,
+	CONSTRAINT fk_project_invites_invitee FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_invites_token_hash ON project_invites (token_hash);
+CREATE INDEX IF NOT EXISTS idx_project_invites_project_id ON project_invites (project_id);
+CREATE INDEX IF NOT EXISTS idx_project_invites_user_id ON project_invites (user_id);
+
+CREATE TABLE IF NOT EXISTS share_links (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	project_id integer NOT NULL,
+	token_hash text NOT NULL,
+	label text,
+	password_hash text,
+	allow_download numeric NOT NULL DEFAULT false,
+	expires_at datetime,
+	revoked_at datetime,
+	use_count integer NOT NULL DEFAULT 0,
+	last_used_at datetime,
+	created_by integer NOT NULL,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_share_links_token_hash ON share_links (token_hash);
+CREATE INDEX IF NOT EXISTS idx_share_links_project_id ON share_links (project_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	project_id integer NOT NULL,
+	file_kind text NOT NULL,
+	file_id integer NOT NULL,
+	parent_id integer,
+	user_id integer NOT NULL,
+	body text NOT NULL,
+	offset_seconds real,
+	resolved_at datetime,
+	resolved_by integer,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_comments_replies FOREIGN KEY (parent_id) REFERENCES comments (id)
+);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comment_file ON comments (project_id, file_kind, file_id);
+
+CREATE TABLE IF NOT EXISTS project_stars (
+	user_id integer,
+	project_id integer,
+	created_at datetime,
+	PRIMARY KEY (user_id, project_id)
+);
+CREATE INDEX IF NOT EXISTS idx_project_stars_project_id ON project_stars (project_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	kind text NOT NULL,
+	created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_tags_kind ON tags (kind);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+CREATE TABLE IF NOT EXISTS project_tags (
+	project_id integer,
+	tag_id integer,
+	PRIMARY KEY (project_id, tag_id),
+	CONSTRAINT fk_project_tags_project FOREIGN KEY (project_id) REFERENCES projects (id),
+	CONSTRAINT fk_project_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS library_items (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	folder text NOT NULL DEFAULT '',
+	file_path text NOT NULL,
+	size integer NOT NULL,
+	filename text NOT NULL,
+	content_type text NOT NULL,
+	hash text,
+	uploaded_at datetime NOT NULL,
+	created_at datetime,
+	updated_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_library_items_hash ON library_items (hash);
+CREATE INDEX IF NOT EXISTS idx_library_items_blob ON library_items (file_path);
+CREATE INDEX IF NOT EXISTS idx_library_items_folder ON library_items (folder);
+CREATE INDEX IF NOT EXISTS idx_library_items_user_id ON library_items (user_id);
+
+CREATE TABLE IF NOT EXISTS library_item_tags (
+	library_item_id integer,
+	tag_id integer,
+	PRIMARY KEY (library_item_id, tag_id),
+	CONSTRAINT fk_library_item_tags_library_item FOREIGN KEY (library_item_id) REFERENCES library_items (id),
+	CONSTRAINT fk_library_item_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
@@ -0,0 +1,16 @@
+-- Admin accounts, disabled accounts and signup invites for beta users
+ALTER TABLE users ADD COLUMN is_admin numeric NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN disabled_at datetime;
+
+ALTER TABLE beta_users ADD COLUMN invited_at datetime;
+
+CREATE TABLE signup_invites (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	email text NOT NULL,
+	token_hash text NOT NULL,
+	expires_at datetime NOT NULL,
+	accepted_at datetime,
+	created_at datetime
+);
+CREATE INDEX idx_signup_invites_email ON signup_invites (email);
+CREATE UNIQUE INDEX idx_signup_invites_token_hash ON signup_invites (token_hash);
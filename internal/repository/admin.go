@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// TransferProject hands a project to another user. The new owner's
+// membership, if any, is dropped since owners don't need one, and samples
+// stop pointing at the previous owner's library.
+func (r *ProjectRepository) TransferProject(projectID, userID uint) error {
+	if projectID == 0 || userID == 0 {
+		return common.ErrInvalidID
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var project domain.Project
+		if err := tx.First(&project, projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrNotFound
+			}
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		if project.UserID == userID {
+			return nil
+		}
+
+		if err := tx.Model(&project).UpdateColumn("user_id", userID).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		if err := tx.Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&domain.ProjectMember{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		if err := tx.Unscoped().Model(&domain.SampleFile{}).
+			Where("project_id = ? AND library_item_id IS NOT NULL", projectID).
+			UpdateColumn("library_item_id", nil).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		return nil
+	})
+}
+
+// RecalculateSize recomputes a project's stored total size from its files
+// and returns it
+func (r *ProjectRepository) RecalculateSize(projectID uint) (int64, error) {
+	size, err := r.GetProjectSize(projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.db.Model(&domain.Project{}).
+		Where("id = ?", projectID).
+		UpdateColumn("total_size", size).Error; err != nil {
+		return 0, fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+	}
+	return size, nil
+}
+
+// FindBlobs returns every stored object the database knows about, by path
+func (r *ProjectRepository) FindBlobs() ([]domain.Blob, error) {
+	var blobs []domain.Blob
+	if err := r.db.Order("file_path").Find(&blobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch blobs: %v", err)
+	}
+	return blobs, nil
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"dawhub/internal/domain"
+)
+
+// blobReferenceTables lists every table whose rows point at a stored object
+var blobReferenceTables = []interface{}{
+	&domain.ProjectFile{},
+	&domain.SampleFile{},
+	&domain.RevisionFile{},
+	&domain.LibraryItem{},
+}
+
+// referenceBatchSize bounds the number of paths per IN query
+const referenceBatchSize = 500
+
+// ReleaseBlobs recounts references to the given objects and forgets those no
+// longer referenced, returning their paths so the caller can remove them from storage
+func (r *ProjectRepository) ReleaseBlobs(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	var orphans []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		orphans, err = releaseBlobs(tx, paths)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orphans, nil
+}
+
+// FindUnreferencedPaths returns the given paths that no file row points at
+func (r *ProjectRepository) FindUnreferencedPaths(paths []string) ([]string, error) {
+	counts, err := r.CountBlobReferences(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var unreferenced []string
+	for _, path := range paths {
+		if counts[path] == 0 {
+			unreferenced = append(unreferenced, path)
+		}
+	}
+	return unreferenced, nil
+}
+
+// CountBlobReferences counts the file rows pointing at each of the given
+// paths, which is what their blobs' reference counts should hold
+func (r *ProjectRepository) CountBlobReferences(paths []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(paths))
+	for start := 0; start < len(paths); start += referenceBatchSize {
+		end := start + referenceBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+
+		batch, err := countBlobReferences(r.db, paths[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for path, count := range batch {
+			counts[path] = count
+		}
+	}
+
+	return counts, nil
+}
+
+// releaseBlobs recounts references to the given objects within tx, forgets
+// those no longer referenced and returns their paths
+func releaseBlobs(tx *gorm.DB, paths []string) ([]string, error) {
+	counts, err := recountBlobs(tx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for path, count := range counts {
+		if count > 0 {
+			continue
+		}
+		if err := tx.Delete(&domain.Blob{}, "file_path = ?", path).Error; err != nil {
+			return nil, fmt.Errorf("failed to delete blob %s: %v", path, err)
+		}
+		orphans = append(orphans, path)
+	}
+	return orphans, nil
+}
+
+// recountBlobs refreshes the stored reference count of each path from the
+// referencing tables and returns the new counts. The blob rows stay locked
+// until tx ends, so a transaction adding a reference either commits before
+// the count or waits for it.
+func recountBlobs(tx *gorm.DB, paths []string) (map[string]int64, error) {
+	if err := lockBlobs(tx, paths); err != nil {
+		return nil, err
+	}
+
+	counts, err := countBlobReferences(tx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	for path, count := range counts {
+		if err := tx.Model(&domain.Blob{}).
+			Where("file_path = ?", path).
+			UpdateColumn("ref_count", count).Error; err != nil {
+			return nil, fmt.Errorf("failed to update blob reference count: %v", err)
+		}
+	}
+
+	return counts, nil
+}
+
+// lockBlobs locks the blob rows of the given paths against retainBlob's
+// upsert, in key order so concurrent callers cannot deadlock
+func lockBlobs(tx *gorm.DB, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(paths))
+	sorted := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			sorted = append(sorted, path)
+		}
+	}
+	sort.Strings(sorted)
+
+	var locked []string
+	if err := tx.Model(&domain.Blob{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("file_path IN ?", sorted).
+		Order("file_path").
+		Pluck("file_path", &locked).Error; err != nil {
+		return fmt.Errorf("failed to lock blobs: %v", err)
+	}
+	return nil
+}
+
+// countBlobReferences counts the rows pointing at each path. Rows in the
+// trash count too, so their objects survive until they are purged.
+func countBlobReferences(db *gorm.DB, paths []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(paths) == 0 {
+		return counts, nil
+	}
+
+	for _, path := range paths {
+		counts[path] = 0
+	}
+	unique := make([]string, 0, len(counts))
+	for path := range counts {
+		unique = append(unique, path)
+	}
+
+	for _, model := range blobReferenceTables {
+		var rows []struct {
+			FilePath string
+			Count    int64
+		}
+		if err := db.Unscoped().Model(model).
+			Select("file_path, COUNT(*) AS count").
+			Where("file_path IN ?", unique).
+			Group("file_path").
+			Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to count blob references: %v", err)
+		}
+		for _, row := range rows {
+			counts[row.FilePath] += row.Count
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+func (r *ProjectRepository) CreateComment(comment *domain.Comment) error {
+	if err := r.db.Create(comment).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+	}
+	return nil
+}
+
+// FindComments returns a project's top-level comments with their replies,
+// ordered by position in the file and then by age
+func (r *ProjectRepository) FindComments(projectID uint) ([]domain.Comment, error) {
+	var comments []domain.Comment
+	err := r.db.
+		Preload("User").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Replies.User").
+		Where("project_id = ? AND parent_id IS NULL", projectID).
+		Order("offset_seconds IS NULL, offset_seconds ASC, created_at ASC").
+		Find(&comments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %v", err)
+	}
+	return comments, nil
+}
+
+func (r *ProjectRepository) FindComment(projectID, commentID uint) (*domain.Comment, error) {
+	var comment domain.Comment
+	if err := r.db.Where("project_id = ? AND id = ?", projectID, commentID).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch comment: %v", err)
+	}
+	return &comment, nil
+}
+
+// ResolveComment marks a comment resolved by userID, or reopens it
+func (r *ProjectRepository) ResolveComment(projectID, commentID, userID uint, resolved bool) error {
+	updates := map[string]interface{}{"resolved_at": nil, "resolved_by": nil}
+	if resolved {
+		updates = map[string]interface{}{"resolved_at": time.Now(), "resolved_by": userID}
+	}
+
+	result := r.db.Model(&domain.Comment{}).
+		Where("project_id = ? AND id = ?", projectID, commentID).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteComment removes a comment along with its replies
+func (r *ProjectRepository) DeleteComment(projectID, commentID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ? AND parent_id = ?", projectID, commentID).Delete(&domain.Comment{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		result := tx.Where("project_id = ? AND id = ?", projectID, commentID).Delete(&domain.Comment{})
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return common.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// deleteComments removes every comment on a project
+func deleteComments(tx *gorm.DB, projectID uint) error {
+	if err := tx.Where("project_id = ?", projectID).Delete(&domain.Comment{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	return nil
+}
+
+// repointComments moves the comments on a file row that has been replaced,
+// such as by a new main file or a restore, to the row replacing it
+func repointComments(tx *gorm.DB, projectID uint, kind string, from, to uint) error {
+	if err := tx.Model(&domain.Comment{}).
+		Where("project_id = ? AND file_kind = ? AND file_id = ?", projectID, kind, from).
+		UpdateColumn("file_id", to).Error; err != nil {
+		return fmt.Errorf("failed to move comments: %v", err)
+	}
+	return nil
+}
@@ -2,28 +2,56 @@ package repository
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
 	"dawhub/internal/config"
-	"dawhub/internal/domain"
+	"dawhub/internal/migrate"
 )
 
-// NewDB creates a new database connection
+// NewDB connects to the database and brings its schema up to date as
+// cfg.Migrate says: "auto" applies pending migrations, "verify" refuses to
+// start until they have been applied separately. Both refuse a schema newer
+// than this build.
 func NewDB(cfg config.DBConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		cfg.Host,
-		cfg.User,
-		cfg.Password,
-		cfg.Name,
-		cfg.Port,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Migrate {
+	case "auto", "":
+		_, err = migrator.Up()
+	case "verify":
+		err = migrator.Verify()
+	default:
+		err = fmt.Errorf("unknown migration mode %q", cfg.Migrate)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open connects to the database without touching its schema
+func Open(cfg config.DBConfig) (*gorm.DB, error) {
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
@@ -43,10 +71,34 @@ func NewDB(cfg config.DBConfig) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	// Auto-migrate schemas
-	if err := db.AutoMigrate(&domain.Project{}, &domain.SampleFile{}, &domain.User{}, &domain.BetaUser{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
-	}
-
 	return db, nil
 }
+
+// openDialector picks the database engine named by cfg.Driver
+func openDialector(cfg config.DBConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "postgres", "":
+		dsn := fmt.Sprintf(
+			"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+			cfg.Host,
+			cfg.User,
+			cfg.Password,
+			cfg.Name,
+			cfg.Port,
+			cfg.SSLMode,
+		)
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+		// Foreign keys are off and LIKE ignores case by default in SQLite;
+		// turn both around to match Postgres. Transactions take the write
+		// lock up front so concurrent writers wait their turn instead of
+		// failing when they upgrade from a read.
+		dsn := cfg.Path + "?_pragma=foreign_keys(1)&_pragma=case_sensitive_like(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(10000)&_txlock=immediate"
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
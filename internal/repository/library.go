@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// libraryItemTag is a row of the library_item_tags join table
+type libraryItemTag struct {
+	LibraryItemID uint `gorm:"primaryKey"`
+	TagID         uint `gorm:"primaryKey"`
+}
+
+func (libraryItemTag) TableName() string {
+	return "library_item_tags"
+}
+
+func (r *ProjectRepository) CreateLibraryItem(item *domain.LibraryItem) error {
+	if err := r.db.Omit("Tags").Create(item).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+	}
+	return nil
+}
+
+// FindLibraryItems lists a user's library, optionally limited to a folder
+// (including its subfolders) and a tag
+func (r *ProjectRepository) FindLibraryItems(userID uint, folder, tag string) ([]domain.LibraryItem, error) {
+	query := r.db.Preload("Tags").Where("user_id = ?", userID)
+	if folder != "" {
+		query = query.Where("folder = ? OR folder LIKE ? ESCAPE '\\'", folder, escapeLike(folder)+"/%")
+	}
+	if tag != "" {
+		query = query.Where("id IN (?)", r.db.Table("library_item_tags").
+			Select("library_item_tags.library_item_id").
+			Joins("JOIN tags ON tags.id = library_item_tags.tag_id").
+			Where("tags.name = ?", domain.NormalizeTagName(tag)))
+	}
+
+	var items []domain.LibraryItem
+	if err := query.Order("folder").Order("filename").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch library: %v", err)
+	}
+	return items, nil
+}
+
+// FindLibraryItem returns an item from the user's library
+func (r *ProjectRepository) FindLibraryItem(userID, itemID uint) (*domain.LibraryItem, error) {
+	var item domain.LibraryItem
+	if err := r.db.Preload("Tags").Where("id = ? AND user_id = ?", itemID, userID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch library item: %v", err)
+	}
+	return &item, nil
+}
+
+// FindLibraryFolders returns the folders in use in a user's library, sorted
+func (r *ProjectRepository) FindLibraryFolders(userID uint) ([]string, error) {
+	var folders []string
+	if err := r.db.Model(&domain.LibraryItem{}).
+		Where("user_id = ? AND folder <> ''", userID).
+		Distinct("folder").
+		Order("folder").
+		Pluck("folder", &folders).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch library folders: %v", err)
+	}
+	return folders, nil
+}
+
+// MoveLibraryItem moves an item to another folder of the user's library
+func (r *ProjectRepository) MoveLibraryItem(userID, itemID uint, folder string) error {
+	folder, err := domain.NormalizeFolder(folder)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.Model(&domain.LibraryItem{}).
+		Where("id = ? AND user_id = ?", itemID, userID).
+		Update("folder", folder)
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// SetLibraryItemTags replaces a library item's tags
+func (r *ProjectRepository) SetLibraryItemTags(itemID uint, names []string) error {
+	tags, err := domain.NewTags(names)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("library_item_id = ?", itemID).Delete(&libraryItemTag{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+
+		ids, err := upsertTags(tx, tags)
+		if err != nil {
+			return err
+		}
+
+		rows := make([]libraryItemTag, len(ids))
+		for i, id := range ids {
+			rows[i] = libraryItemTag{LibraryItemID: itemID, TagID: id}
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		return nil
+	})
+}
+
+// FindLibraryItemUsage returns the projects a library item is attached to
+func (r *ProjectRepository) FindLibraryItemUsage(itemID uint) ([]domain.Project, error) {
+	var projects []domain.Project
+	if err := r.db.
+		Where("id IN (?)", r.db.Model(&domain.SampleFile{}).Select("project_id").Where("library_item_id = ?", itemID)).
+		Order("name").
+		Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %v", err)
+	}
+	return projects, nil
+}
+
+// DeleteLibraryItem removes an item from the user's library. Projects it was
+// attached to keep their samples, which reference the stored object on
+// their own; they are only unlinked from the library.
+func (r *ProjectRepository) DeleteLibraryItem(userID, itemID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var item domain.LibraryItem
+		if err := tx.Where("id = ? AND user_id = ?", itemID, userID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrNotFound
+			}
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		return deleteLibraryItems(tx, []domain.LibraryItem{item})
+	})
+}
+
+// RemoveLibrary deletes a user's whole library, when their account is deleted
+func (r *ProjectRepository) RemoveLibrary(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var items []domain.LibraryItem
+		if err := tx.Where("user_id = ?", userID).Find(&items).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		return deleteLibraryItems(tx, items)
+	})
+}
+
+// AttachLibraryItems adds library items to a project as samples, skipping
+// those already attached, and returns how many were added. The samples
+// count toward the project's size like uploaded ones.
+func (r *ProjectRepository) AttachLibraryItems(projectID uint, items []domain.LibraryItem) (int, error) {
+	if projectID == 0 || len(items) == 0 {
+		return 0, fmt.Errorf("%w: invalid input", common.ErrUpdateFailed)
+	}
+
+	attached := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		if err := tx.Model(&domain.SampleFile{}).
+			Where("project_id = ? AND library_item_id IS NOT NULL", projectID).
+			Pluck("library_item_id", &existing).Error; err != nil {
+			return fmt.Errorf("failed to fetch sample files: %v", err)
+		}
+		present := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			present[id] = true
+		}
+
+		var files []domain.SampleFile
+		var added int64
+		for _, item := range items {
+			if present[item.ID] {
+				continue
+			}
+			present[item.ID] = true
+			files = append(files, item.SampleFile(projectID))
+			added += item.Size
+		}
+		if len(files) == 0 {
+			return nil
+		}
+
+		size, err := r.WithTx(tx).GetProjectSize(projectID)
+		if err != nil {
+			return err
+		}
+		if size+added > domain.MaxProjectSize {
+			return domain.ErrProjectTooLarge
+		}
+
+		if err := r.WithTx(tx).AddSampleFiles(projectID, files); err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Project{}).
+			Where("id = ?", projectID).
+			UpdateColumn("total_size", size+added).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+
+		attached = len(files)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return attached, nil
+}
+
+// deleteLibraryItems deletes library items and their tags, unlinks the
+// samples attached from them and recounts references to their objects.
+// Objects left unreferenced are removed by the garbage collector.
+func deleteLibraryItems(tx *gorm.DB, items []domain.LibraryItem) error {
+	ids := make([]uint, len(items))
+	paths := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+		paths[i] = item.FilePath
+	}
+
+	if err := tx.Unscoped().Model(&domain.SampleFile{}).
+		Where("library_item_id IN ?", ids).
+		UpdateColumn("library_item_id", nil).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+	}
+	if err := tx.Where("library_item_id IN ?", ids).Delete(&libraryItemTag{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	if err := tx.Delete(&domain.LibraryItem{}, ids).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+
+	_, err := recountBlobs(tx, paths)
+	return err
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+
+	"dawhub/internal/domain"
+)
+
+// ListProjects returns one page of the projects the user owns or collaborates
+// on, or of all public projects. Pages are keyed on the sort column and ID,
+// so rows created between requests never shift or repeat results.
+func (r *ProjectRepository) ListProjects(opts domain.ProjectListOptions) (*domain.ProjectPage, error) {
+	query := r.db.Model(&domain.Project{}).Preload("Tags")
+	if opts.Public {
+		query = query.Where("projects.is_public = ?", true)
+	} else {
+		query = query.Where(r.db.Where("projects.user_id = ?", opts.UserID).
+			Or("projects.id IN (?)", r.db.Model(&domain.ProjectMember{}).Select("project_id").Where("user_id = ?", opts.UserID)))
+	}
+
+	switch opts.Visibility {
+	case domain.VisibilityPublic:
+		query = query.Where("projects.is_public = ?", true)
+	case domain.VisibilityPrivate:
+		query = query.Where("projects.is_public = ?", false)
+	}
+	if opts.Version != "" {
+		query = query.Where("projects.version = ?", opts.Version)
+	}
+	if opts.ContentType != "" {
+		query = query.Where("projects.main_file_id IN (?)", r.db.Model(&domain.ProjectFile{}).Select("id").Where("content_type = ?", opts.ContentType))
+	}
+	if opts.OwnerID != 0 {
+		query = query.Where("projects.user_id = ?", opts.OwnerID)
+	}
+	if opts.Tag != "" {
+		query = query.Where("projects.id IN (?)", r.db.Table("project_tags").
+			Select("project_tags.project_id").
+			Joins("JOIN tags ON tags.id = project_tags.tag_id").
+			Where("tags.name = ?", opts.Tag))
+	}
+
+	column := "projects." + opts.Sort.Column()
+	direction, comparison := "DESC", "<"
+	if opts.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+
+	if opts.Cursor != "" {
+		cursor, value, err := opts.DecodeCursor()
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND projects.id %[2]s ?))", column, comparison),
+			value, value, cursor.ID,
+		)
+	}
+
+	// One extra row tells whether another page follows
+	var projects []domain.Project
+	if err := query.
+		Order(fmt.Sprintf("%s %s, projects.id %s", column, direction, direction)).
+		Limit(opts.Limit + 1).
+		Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %v", err)
+	}
+
+	page := &domain.ProjectPage{Items: projects, Limit: opts.Limit}
+	if len(projects) > opts.Limit {
+		page.Items = projects[:opts.Limit]
+		page.NextCursor = opts.CursorAfter(page.Items[len(page.Items)-1])
+	}
+	return page, nil
+}
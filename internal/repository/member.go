@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// FindAccessible returns the projects a user owns or collaborates on
+func (r *ProjectRepository) FindAccessible(userID uint) ([]domain.Project, error) {
+	var projects []domain.Project
+	err := r.db.
+		Where("user_id = ?", userID).
+		Or("id IN (?)", r.db.Model(&domain.ProjectMember{}).Select("project_id").Where("user_id = ?", userID)).
+		Find(&projects).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %v", err)
+	}
+	return projects, nil
+}
+
+// FindMember returns a user's membership of a project
+func (r *ProjectRepository) FindMember(projectID, userID uint) (*domain.ProjectMember, error) {
+	var member domain.ProjectMember
+	if err := r.db.Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch member: %v", err)
+	}
+	return &member, nil
+}
+
+// FindMembers lists a project's collaborators, oldest first
+func (r *ProjectRepository) FindMembers(projectID uint) ([]domain.ProjectMember, error) {
+	var members []domain.ProjectMember
+	if err := r.db.Preload("User").
+		Where("project_id = ?", projectID).
+		Order("created_at ASC").
+		Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %v", err)
+	}
+	return members, nil
+}
+
+func (r *ProjectRepository) UpdateMemberRole(projectID, userID uint, role domain.ProjectRole) error {
+	result := r.db.Model(&domain.ProjectMember{}).
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ProjectRepository) RemoveMember(projectID, userID uint) error {
+	result := r.db.Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&domain.ProjectMember{})
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// RemoveMemberships drops a user from every project they collaborate on
+func (r *ProjectRepository) RemoveMemberships(userID uint) error {
+	if err := r.db.Where("user_id = ?", userID).Delete(&domain.ProjectMember{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	return nil
+}
+
+func (r *ProjectRepository) CreateInvite(invite *domain.ProjectInvite) error {
+	if err := r.db.Omit("Invitee").Create(invite).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+	}
+	return nil
+}
+
+// FindPendingInvites lists a project's invites that can still be accepted
+func (r *ProjectRepository) FindPendingInvites(projectID uint) ([]domain.ProjectInvite, error) {
+	var invites []domain.ProjectInvite
+	if err := r.db.Preload("Invitee").
+		Where("project_id = ? AND accepted_at IS NULL AND expires_at > ?", projectID, time.Now()).
+		Order("created_at ASC").
+		Find(&invites).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch invites: %v", err)
+	}
+	return invites, nil
+}
+
+func (r *ProjectRepository) DeleteInvite(projectID, inviteID uint) error {
+	result := r.db.Where("project_id = ? AND id = ?", projectID, inviteID).Delete(&domain.ProjectInvite{})
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// AcceptInvite redeems the invite with the given token hash for userID,
+// granting their membership or raising an existing one to the invite's role;
+// a lower role leaves it as it is. Each invite can be used once, and one
+// addressed to a registered user only by that user.
+func (r *ProjectRepository) AcceptInvite(tokenHash string, userID uint) (*domain.ProjectMember, error) {
+	var member domain.ProjectMember
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var invite domain.ProjectInvite
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&invite).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrInviteInvalid
+			}
+			return fmt.Errorf("failed to fetch invite: %v", err)
+		}
+		if !invite.IsPending() {
+			return domain.ErrInviteInvalid
+		}
+		if invite.UserID != nil && *invite.UserID != userID {
+			return domain.ErrInviteInvalid
+		}
+
+		var project domain.Project
+		if err := tx.First(&project, invite.ProjectID).Error; err != nil {
+			return domain.ErrInviteInvalid
+		}
+		if project.UserID == userID {
+			return domain.ErrAlreadyMember
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("project_id = ? AND user_id = ?", invite.ProjectID, userID).
+			First(&member).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			member = domain.ProjectMember{
+				ProjectID: invite.ProjectID,
+				UserID:    userID,
+				Role:      invite.Role,
+				InvitedBy: invite.InvitedBy,
+			}
+			if err := tx.Create(&member).Error; err != nil {
+				return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to fetch member: %v", err)
+		case !member.Role.Includes(invite.Role):
+			if err := tx.Model(&member).Update("role", invite.Role).Error; err != nil {
+				return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+			}
+		}
+
+		now := time.Now()
+		if err := tx.Model(&invite).Update("accepted_at", now).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// deleteAccessGrants removes every member, invite and share link of a project
+func deleteAccessGrants(tx *gorm.DB, projectID uint) error {
+	if err := tx.Where("project_id = ?", projectID).Delete(&domain.ProjectMember{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	if err := tx.Where("project_id = ?", projectID).Delete(&domain.ProjectInvite{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	if err := tx.Where("project_id = ?", projectID).Delete(&domain.ShareLink{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	return nil
+}
@@ -25,7 +25,8 @@ func (r *ProjectRepository) Create(project *domain.Project) error {
 		return fmt.Errorf("%w: project is nil", common.ErrCreateFailed)
 	}
 
-	result := r.db.Create(project)
+	// Tags are set separately through SetProjectTags
+	result := r.db.Omit("Tags").Create(project)
 	if result.Error != nil {
 		return fmt.Errorf("%w: %v", common.ErrCreateFailed, result.Error)
 	}
@@ -75,6 +76,7 @@ func (r *ProjectRepository) FindByID(id uint) (*domain.Project, error) {
 	result := r.db.
 		Preload("MainFile").
 		Preload("SampleFiles").
+		Preload("Tags").
 		First(&project, id)
 
 	if result.Error != nil {
@@ -92,7 +94,7 @@ func (r *ProjectRepository) Update(project *domain.Project) error {
 		return fmt.Errorf("%w: invalid project", common.ErrUpdateFailed)
 	}
 
-	result := r.db.Save(project)
+	result := r.db.Omit("Tags").Save(project)
 	if result.Error != nil {
 		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
 	}
@@ -100,16 +102,16 @@ func (r *ProjectRepository) Update(project *domain.Project) error {
 	return nil
 }
 
+// Delete moves a project to the trash. Its files, history and collaborators
+// stay until it is restored or purged.
 func (r *ProjectRepository) Delete(id uint) error {
 	if id == 0 {
 		return common.ErrInvalidID
 	}
 
-	result := r.db.Delete(&domain.Project{}, id)
-	if result.Error != nil {
-		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, result.Error)
+	if err := r.db.Delete(&domain.Project{}, id).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
 	}
-
 	return nil
 }
 
@@ -129,24 +131,35 @@ func (r *ProjectRepository) AddMainFile(projectID uint, file *domain.ProjectFile
 			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
 		}
 
-		// Delete existing main file if any
-		if project.MainFileID != nil {
-			if err := tx.Delete(&domain.ProjectFile{}, *project.MainFileID).Error; err != nil {
-				return fmt.Errorf("failed to delete existing main file: %v", err)
-			}
-		}
-
 		// Create new file
 		if err := tx.Create(file).Error; err != nil {
 			return fmt.Errorf("failed to create main file: %v", err)
 		}
 
 		// Update project's main file ID
+		previousID := project.MainFileID
 		project.MainFileID = &file.ID
 		if err := tx.Save(&project).Error; err != nil {
 			return fmt.Errorf("failed to update project main file: %v", err)
 		}
 
+		// Delete the replaced main file row; its object stays shared by revisions
+		if previousID != nil {
+			var previous domain.ProjectFile
+			if err := tx.First(&previous, *previousID).Error; err != nil {
+				return fmt.Errorf("failed to load existing main file: %v", err)
+			}
+			if err := tx.Delete(&previous).Error; err != nil {
+				return fmt.Errorf("failed to delete existing main file: %v", err)
+			}
+			if err := repointComments(tx, projectID, domain.RevisionFileMain, previous.ID, file.ID); err != nil {
+				return err
+			}
+			if _, err := recountBlobs(tx, []string{previous.FilePath}); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 }
@@ -186,21 +199,23 @@ func (r *ProjectRepository) AddSampleFile(projectID uint, file *domain.SampleFil
 	})
 }
 
-// RemoveSampleFile removes a single sample file from the project
+// RemoveSampleFile moves a sample file to the trash
 func (r *ProjectRepository) RemoveSampleFile(projectID uint, fileID uint) error {
 	if projectID == 0 || fileID == 0 {
 		return common.ErrInvalidID
 	}
 
-	result := r.db.Where("project_id = ? AND id = ?", projectID, fileID).Delete(&domain.SampleFile{})
-	if result.Error != nil {
-		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return common.ErrNotFound
-	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("project_id = ? AND id = ?", projectID, fileID).Delete(&domain.SampleFile{})
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return common.ErrNotFound
+		}
 
-	return nil
+		return refreshProjectSize(tx, projectID)
+	})
 }
 
 // GetProjectSize calculates the total size of all project files
@@ -209,26 +224,25 @@ func (r *ProjectRepository) GetProjectSize(projectID uint) (int64, error) {
 		return 0, common.ErrInvalidID
 	}
 
-	var totalSize int64
-
 	// Sum main file size
-	mainFileSize := r.db.Model(&domain.Project{}).
-		Select("COALESCE((SELECT size FROM project_files WHERE id = projects.main_file_id), 0)").
-		Where("projects.id = ?", projectID)
+	var mainFileSize int64
+	if err := r.db.Model(&domain.ProjectFile{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("id IN (?)", r.db.Model(&domain.Project{}).Select("main_file_id").Where("id = ?", projectID)).
+		Scan(&mainFileSize).Error; err != nil {
+		return 0, fmt.Errorf("failed to calculate project size: %v", err)
+	}
 
 	// Sum sample files size
-	sampleFilesSize := r.db.Model(&domain.SampleFile{}).
+	var sampleFilesSize int64
+	if err := r.db.Model(&domain.SampleFile{}).
 		Select("COALESCE(SUM(size), 0)").
-		Where("project_id = ?", projectID)
-
-	err := r.db.Raw("SELECT (?) + (?) as total_size", mainFileSize, sampleFilesSize).
-		Scan(&totalSize).Error
-
-	if err != nil {
+		Where("project_id = ?", projectID).
+		Scan(&sampleFilesSize).Error; err != nil {
 		return 0, fmt.Errorf("failed to calculate project size: %v", err)
 	}
 
-	return totalSize, nil
+	return mainFileSize + sampleFilesSize, nil
 }
 
 // AddSampleFiles adds multiple sample files in a single transaction
@@ -261,13 +275,20 @@ func (r *ProjectRepository) AddSampleFiles(projectID uint, files []domain.Sample
 	})
 }
 
-// RemoveSampleFiles removes multiple sample files in a single transaction
+// RemoveSampleFiles moves multiple sample files to the trash in a single
+// transaction; nil fileIDs removes them all
 func (r *ProjectRepository) RemoveSampleFiles(projectID uint, fileIDs []uint) error {
-	query := r.db.Where("project_id = ?", projectID)
-	if fileIDs != nil {
-		query = query.Where("id IN ?", fileIDs)
-	}
-	return query.Delete(&domain.SampleFile{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("project_id = ?", projectID)
+		if fileIDs != nil {
+			query = query.Where("id IN ?", fileIDs)
+		}
+		if err := query.Delete(&domain.SampleFile{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		return refreshProjectSize(tx, projectID)
+	})
 }
 
 func (r *ProjectRepository) WithTx(tx *gorm.DB) domain.ProjectRepository {
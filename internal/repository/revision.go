@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// CreateRevision snapshots the project's current main file and samples as a new revision
+func (r *ProjectRepository) CreateRevision(projectID uint, userID uint, message string) (*domain.ProjectRevision, error) {
+	if projectID == 0 || userID == 0 {
+		return nil, fmt.Errorf("%w: invalid input", common.ErrCreateFailed)
+	}
+
+	var revision domain.ProjectRevision
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the project row so concurrent saves get sequential numbers
+		var project domain.Project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("MainFile").
+			Preload("SampleFiles").
+			First(&project, projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrNotFound
+			}
+			return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+		}
+
+		var latest int
+		if err := tx.Model(&domain.ProjectRevision{}).
+			Select("COALESCE(MAX(number), 0)").
+			Where("project_id = ?", projectID).
+			Scan(&latest).Error; err != nil {
+			return fmt.Errorf("failed to determine revision number: %v", err)
+		}
+
+		revision = domain.ProjectRevision{
+			ProjectID: projectID,
+			Number:    latest + 1,
+			UserID:    userID,
+			Message:   message,
+			Files:     domain.NewRevisionFiles(&project),
+		}
+
+		if err := tx.Omit("User").Create(&revision).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// FindRevisions lists a project's revisions, newest first
+func (r *ProjectRepository) FindRevisions(projectID uint) ([]domain.ProjectRevision, error) {
+	if projectID == 0 {
+		return nil, common.ErrInvalidID
+	}
+
+	var revisions []domain.ProjectRevision
+	result := r.db.
+		Preload("User").
+		Preload("Files").
+		Where("project_id = ?", projectID).
+		Order("number DESC").
+		Find(&revisions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch revisions: %v", result.Error)
+	}
+
+	return revisions, nil
+}
+
+// FindRevision retrieves a single revision by its per-project number
+func (r *ProjectRepository) FindRevision(projectID uint, number int) (*domain.ProjectRevision, error) {
+	if projectID == 0 || number <= 0 {
+		return nil, common.ErrInvalidID
+	}
+
+	var revision domain.ProjectRevision
+	result := r.db.
+		Preload("User").
+		Preload("Files").
+		Where("project_id = ? AND number = ?", projectID, number).
+		First(&revision)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch revision: %v", result.Error)
+	}
+
+	return &revision, nil
+}
+
+// DeleteRevisions removes a project's revision history
+func (r *ProjectRepository) DeleteRevisions(projectID uint) error {
+	if projectID == 0 {
+		return common.ErrInvalidID
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		revisionIDs := tx.Model(&domain.ProjectRevision{}).Select("id").Where("project_id = ?", projectID)
+
+		var paths []string
+		if err := tx.Model(&domain.RevisionFile{}).Where("revision_id IN (?)", revisionIDs).Pluck("file_path", &paths).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		if err := tx.Where("revision_id IN (?)", revisionIDs).Delete(&domain.RevisionFile{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+		if err := tx.Where("project_id = ?", projectID).Delete(&domain.ProjectRevision{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		_, err := recountBlobs(tx, paths)
+		return err
+	})
+}
+
+// RestoreRevision makes an earlier revision's files current again and records
+// the restore as a new revision. Stored objects are reused, never re-uploaded.
+func (r *ProjectRepository) RestoreRevision(projectID uint, number int, userID uint) (*domain.ProjectRevision, error) {
+	if projectID == 0 || number <= 0 || userID == 0 {
+		return nil, fmt.Errorf("%w: invalid input", common.ErrUpdateFailed)
+	}
+
+	var restored *domain.ProjectRevision
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var project domain.Project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrNotFound
+			}
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+
+		txRepo := &ProjectRepository{db: tx}
+		target, err := txRepo.FindRevision(projectID, number)
+		if err != nil {
+			return err
+		}
+
+		// Drop the current file rows; the objects stay in storage. Samples
+		// already in the trash stay there.
+		var current []domain.SampleFile
+		if err := tx.Where("project_id = ?", projectID).Find(&current).Error; err != nil {
+			return fmt.Errorf("failed to load sample files: %v", err)
+		}
+		replaced := make([]string, 0, len(current)+1)
+		for _, sample := range current {
+			replaced = append(replaced, sample.FilePath)
+		}
+		if err := tx.Unscoped().Where("project_id = ? AND deleted_at IS NULL", projectID).Delete(&domain.SampleFile{}).Error; err != nil {
+			return fmt.Errorf("failed to clear sample files: %v", err)
+		}
+		var previousMainID uint
+		if project.MainFileID != nil {
+			previousMainID = *project.MainFileID
+			var mainFile domain.ProjectFile
+			if err := tx.First(&mainFile, *project.MainFileID).Error; err != nil {
+				return fmt.Errorf("failed to load main file: %v", err)
+			}
+			replaced = append(replaced, mainFile.FilePath)
+
+			if err := tx.Model(&project).UpdateColumn("main_file_id", nil).Error; err != nil {
+				return fmt.Errorf("failed to unlink main file: %v", err)
+			}
+			if err := tx.Delete(&mainFile).Error; err != nil {
+				return fmt.Errorf("failed to delete main file: %v", err)
+			}
+		}
+
+		// Recreate rows pointing at the revision's objects
+		if main := target.MainFile(); main != nil {
+			projectFile := domain.ProjectFile{
+				FileMetadata: main.FileMetadata,
+				FilePath:     main.FilePath,
+			}
+			if err := tx.Create(&projectFile).Error; err != nil {
+				return fmt.Errorf("failed to restore main file: %v", err)
+			}
+			if err := tx.Model(&project).UpdateColumn("main_file_id", projectFile.ID).Error; err != nil {
+				return fmt.Errorf("failed to link main file: %v", err)
+			}
+			if previousMainID != 0 {
+				if err := repointComments(tx, projectID, domain.RevisionFileMain, previousMainID, projectFile.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if samples := target.SampleFiles(); len(samples) > 0 {
+			linked, err := ownedLibraryItems(tx, project.UserID, samples)
+			if err != nil {
+				return err
+			}
+
+			files := make([]domain.SampleFile, 0, len(samples))
+			for _, sample := range samples {
+				file := domain.SampleFile{
+					ProjectID:    projectID,
+					FilePath:     sample.FilePath,
+					FileMetadata: sample.FileMetadata,
+				}
+				if sample.LibraryItemID != nil && linked[*sample.LibraryItemID] {
+					file.LibraryItemID = sample.LibraryItemID
+				}
+				files = append(files, file)
+			}
+			if err := tx.Create(&files).Error; err != nil {
+				return fmt.Errorf("failed to restore sample files: %v", err)
+			}
+
+			// Comments follow a sample to its new row when the same file,
+			// under the same name, comes back
+			previous := make(map[string][]uint)
+			for _, sample := range current {
+				key := sample.FilePath + "/" + sample.Filename
+				previous[key] = append(previous[key], sample.ID)
+			}
+			for _, file := range files {
+				key := file.FilePath + "/" + file.Filename
+				if ids := previous[key]; len(ids) > 0 {
+					if err := repointComments(tx, projectID, domain.RevisionFileSample, ids[0], file.ID); err != nil {
+						return err
+					}
+					previous[key] = ids[1:]
+				}
+			}
+		}
+
+		if err := tx.Model(&project).UpdateColumn("total_size", target.CalculateTotalSize()).Error; err != nil {
+			return fmt.Errorf("failed to update project size: %v", err)
+		}
+
+		if _, err := recountBlobs(tx, replaced); err != nil {
+			return err
+		}
+
+		restored, err = txRepo.CreateRevision(projectID, userID, fmt.Sprintf("Restored revision %d", number))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// ownedLibraryItems returns which of the library items recorded on samples
+// still exist and belong to userID. Revisions are never rewritten, so links
+// to items deleted since, or owned by a previous project owner, are dropped
+// on restore.
+func ownedLibraryItems(tx *gorm.DB, userID uint, samples []domain.RevisionFile) (map[uint]bool, error) {
+	var ids []uint
+	for _, sample := range samples {
+		if sample.LibraryItemID != nil {
+			ids = append(ids, *sample.LibraryItemID)
+		}
+	}
+	owned := make(map[uint]bool)
+	if len(ids) == 0 {
+		return owned, nil
+	}
+
+	var existing []uint
+	if err := tx.Model(&domain.LibraryItem{}).
+		Where("id IN ? AND user_id = ?", ids, userID).
+		Pluck("id", &existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to load library items: %v", err)
+	}
+	for _, id := range existing {
+		owned[id] = true
+	}
+	return owned, nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+)
+
+// searchRow is a project row along with the columns computed by the search query
+type searchRow struct {
+	domain.Project
+	Rank                 float64
+	NameHighlight        string
+	DescriptionHighlight string
+}
+
+// Search finds the projects visible to the user that match the query text,
+// best matches first. Postgres matches against projects.search_vector, which
+// triggers from the baseline migration keep current; other databases fall
+// back to substring matching.
+func (r *ProjectRepository) Search(query domain.SearchQuery) (*domain.SearchResults, error) {
+	results := &domain.SearchResults{Results: []domain.SearchResult{}, Limit: query.Limit, Offset: query.Offset}
+
+	text := strings.TrimSpace(query.Text)
+	if text == "" {
+		return results, nil
+	}
+
+	if r.db.Dialector.Name() == "postgres" {
+		return r.searchFullText(text, query, results)
+	}
+	return r.searchSubstring(text, query, results)
+}
+
+func (r *ProjectRepository) searchFullText(text string, query domain.SearchQuery, results *domain.SearchResults) (*domain.SearchResults, error) {
+	tsquery := gorm.Expr("websearch_to_tsquery('simple', ?)", text)
+	base := r.db.Model(&domain.Project{}).
+		Where("projects.search_vector @@ ?", tsquery).
+		Where(visibleTo(r.db, query.UserID)).
+		Session(&gorm.Session{})
+
+	if err := base.Count(&results.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to search projects: %v", err)
+	}
+	if results.Total == 0 {
+		return results, nil
+	}
+
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s", domain.HighlightStart, domain.HighlightStop)
+	var rows []searchRow
+	err := base.
+		Select(
+			"projects.*, ts_rank(projects.search_vector, ?) AS rank, "+
+				"ts_headline('simple', projects.name, ?, ?) AS name_highlight, "+
+				"ts_headline('simple', coalesce(projects.description, ''), ?, ?) AS description_highlight",
+			tsquery,
+			tsquery, options+", HighlightAll=true",
+			tsquery, options+", MaxFragments=2, MinWords=5, MaxWords=20",
+		).
+		Order("rank DESC").
+		Order("projects.created_at DESC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search projects: %v", err)
+	}
+
+	samples, err := r.matchingSamples(rows, "to_tsvector('simple', regexp_replace(filename, '[_.\\-]+', ' ', 'g')) @@ ?", tsquery)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		results.Results = append(results.Results, domain.SearchResult{
+			Project:              row.Project,
+			Rank:                 row.Rank,
+			NameHighlight:        domain.HighlightHTML(row.NameHighlight),
+			DescriptionHighlight: domain.HighlightHTML(row.DescriptionHighlight),
+			MatchingSamples:      samples[row.ID],
+		})
+	}
+	return results, nil
+}
+
+// searchSubstring matches every word of the query anywhere in the searched
+// fields, for databases without full-text search
+func (r *ProjectRepository) searchSubstring(text string, query domain.SearchQuery, results *domain.SearchResults) (*domain.SearchResults, error) {
+	terms := strings.Fields(text)
+	base := r.db.Model(&domain.Project{}).Where(visibleTo(r.db, query.UserID))
+	for _, term := range terms {
+		pattern := "%" + escapeLike(strings.ToLower(term)) + "%"
+		base = base.Where(
+			r.db.Where("LOWER(projects.name) LIKE ? ESCAPE '\\'", pattern).
+				Or("LOWER(projects.description) LIKE ? ESCAPE '\\'", pattern).
+				Or("projects.user_id IN (?)", r.db.Model(&domain.User{}).Select("id").Where("LOWER(username) LIKE ? ESCAPE '\\'", pattern)).
+				Or("projects.id IN (?)", r.db.Model(&domain.SampleFile{}).Select("project_id").Where("LOWER(filename) LIKE ? ESCAPE '\\'", pattern)),
+		)
+	}
+	base = base.Session(&gorm.Session{})
+
+	if err := base.Count(&results.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to search projects: %v", err)
+	}
+	if results.Total == 0 {
+		return results, nil
+	}
+
+	var projects []domain.Project
+	if err := base.Order("projects.created_at DESC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to search projects: %v", err)
+	}
+
+	for _, project := range projects {
+		var samples []string
+		if err := r.db.Model(&domain.SampleFile{}).Where("project_id = ?", project.ID).Pluck("filename", &samples).Error; err != nil {
+			return nil, fmt.Errorf("failed to search projects: %v", err)
+		}
+
+		var matching []string
+		for _, sample := range samples {
+			if marked := domain.MarkTerms(sample, terms); marked != sample {
+				matching = append(matching, sample)
+			}
+		}
+
+		results.Results = append(results.Results, domain.SearchResult{
+			Project:              project,
+			NameHighlight:        domain.HighlightHTML(domain.MarkTerms(project.Name, terms)),
+			DescriptionHighlight: domain.HighlightHTML(domain.MarkTerms(project.Description, terms)),
+			MatchingSamples:      matching,
+		})
+	}
+	return results, nil
+}
+
+// matchingSamples returns, per project, the sample filenames matching condition
+func (r *ProjectRepository) matchingSamples(rows []searchRow, condition string, args ...interface{}) (map[uint][]string, error) {
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	var samples []domain.SampleFile
+	if err := r.db.Select("project_id", "filename").
+		Where("project_id IN ?", ids).
+		Where(condition, args...).
+		Order("filename").
+		Find(&samples).Error; err != nil {
+		return nil, fmt.Errorf("failed to search samples: %v", err)
+	}
+
+	matches := make(map[uint][]string)
+	for _, sample := range samples {
+		matches[sample.ProjectID] = append(matches[sample.ProjectID], sample.Filename)
+	}
+	return matches, nil
+}
+
+// visibleTo restricts a query to projects the user may read: public ones,
+// their own and those they collaborate on
+func visibleTo(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("projects.is_public = ?", true).
+		Or("projects.user_id = ?", userID).
+		Or("projects.id IN (?)", db.Model(&domain.ProjectMember{}).Select("project_id").Where("user_id = ?", userID))
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+func (r *ProjectRepository) CreateShareLink(link *domain.ShareLink) error {
+	if err := r.db.Create(link).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+	}
+	return nil
+}
+
+// FindShareLinks lists every link of a project, newest first, including
+// expired and revoked ones so their usage stays visible
+func (r *ProjectRepository) FindShareLinks(projectID uint) ([]domain.ShareLink, error) {
+	var links []domain.ShareLink
+	if err := r.db.Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch share links: %v", err)
+	}
+	return links, nil
+}
+
+// FindShareLink returns the active link with the given token hash
+func (r *ProjectRepository) FindShareLink(tokenHash string) (*domain.ShareLink, error) {
+	var link domain.ShareLink
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrShareLinkInvalid
+		}
+		return nil, fmt.Errorf("failed to fetch share link: %v", err)
+	}
+	if !link.IsActive() {
+		return nil, domain.ErrShareLinkInvalid
+	}
+	return &link, nil
+}
+
+// RevokeShareLink disables a link immediately. Revoked links are kept for
+// their usage history.
+func (r *ProjectRepository) RevokeShareLink(projectID, linkID uint) error {
+	result := r.db.Model(&domain.ShareLink{}).
+		Where("project_id = ? AND id = ? AND revoked_at IS NULL", projectID, linkID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// RecordShareLinkUse counts one visit or download through a link
+func (r *ProjectRepository) RecordShareLinkUse(linkID uint) error {
+	err := r.db.Model(&domain.ShareLink{}).
+		Where("id = ?", linkID).
+		Updates(map[string]interface{}{
+			"use_count":    gorm.Expr("use_count + 1"),
+			"last_used_at": time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+	}
+	return nil
+}
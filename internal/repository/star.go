@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// StarProject stars a project for a user. Starring twice is a no-op.
+func (r *ProjectRepository) StarProject(projectID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&domain.ProjectStar{UserID: userID, ProjectID: projectID})
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", common.ErrCreateFailed, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return recordActivity(tx, projectID, "star_count", 1, domain.TrendingWeightStar)
+	})
+}
+
+// UnstarProject removes a user's star. The trending score keeps the activity;
+// it fades out on its own.
+func (r *ProjectRepository) UnstarProject(projectID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND project_id = ?", userID, projectID).Delete(&domain.ProjectStar{})
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return recordActivity(tx, projectID, "star_count", -1, 0)
+	})
+}
+
+func (r *ProjectRepository) IsStarred(projectID, userID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&domain.ProjectStar{}).
+		Where("user_id = ? AND project_id = ?", userID, projectID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check star: %v", err)
+	}
+	return count > 0, nil
+}
+
+// FindStarred returns the projects a user starred that they can still see,
+// most recently starred first
+func (r *ProjectRepository) FindStarred(userID uint) ([]domain.Project, error) {
+	var projects []domain.Project
+	err := r.db.
+		Joins("JOIN project_stars ON project_stars.project_id = projects.id AND project_stars.user_id = ?", userID).
+		Where(visibleTo(r.db, userID)).
+		Order("project_stars.created_at DESC").
+		Find(&projects).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch starred projects: %v", err)
+	}
+	return projects, nil
+}
+
+// RemoveStars drops every star a user gave, keeping project counts in step
+func (r *ProjectRepository) RemoveStars(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var projectIDs []uint
+		if err := tx.Model(&domain.ProjectStar{}).Where("user_id = ?", userID).Pluck("project_id", &projectIDs).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+		if len(projectIDs) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&domain.Project{}).
+			Where("id IN ?", projectIDs).
+			UpdateColumn("star_count", gorm.Expr("star_count - 1")).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.ProjectStar{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+		return nil
+	})
+}
+
+// RecordDownload counts a download towards a project's totals and trending score
+func (r *ProjectRepository) RecordDownload(projectID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return recordActivity(tx, projectID, "download_count", 1, domain.TrendingWeightDownload)
+	})
+}
+
+// FindTrending returns the public projects with the most recent activity
+func (r *ProjectRepository) FindTrending(limit int) ([]domain.Project, error) {
+	var projects []domain.Project
+	if err := r.db.Preload("Tags").
+		Where("is_public = ?", true).
+		Order("trending_score DESC").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch trending projects: %v", err)
+	}
+	return projects, nil
+}
+
+// recordActivity adjusts a project's counter and, for a positive weight,
+// folds the activity into its trending score. The row is locked so
+// concurrent activity is not lost.
+func recordActivity(tx *gorm.DB, projectID uint, counter string, delta int, weight float64) error {
+	var project domain.Project
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "trending_score").
+		First(&project, projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrNotFound
+		}
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+	}
+
+	updates := map[string]interface{}{counter: gorm.Expr(counter+" + ?", delta)}
+	if weight > 0 {
+		updates["trending_score"] = domain.BumpTrendingScore(project.TrendingScore, weight, time.Now())
+	}
+
+	// UpdateColumns skips hooks and updated_at; activity is not an edit
+	if err := tx.Model(&domain.Project{}).Where("id = ?", projectID).UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+	}
+	return nil
+}
+
+// deleteStars removes every star on a project
+func deleteStars(tx *gorm.DB, projectID uint) error {
+	if err := tx.Where("project_id = ?", projectID).Delete(&domain.ProjectStar{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	return nil
+}
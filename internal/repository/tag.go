@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// projectTag is a row of the project_tags join table. It is written directly
+// rather than through GORM associations, which would save the project and
+// run its hooks.
+type projectTag struct {
+	ProjectID uint `gorm:"primaryKey"`
+	TagID     uint `gorm:"primaryKey"`
+}
+
+func (projectTag) TableName() string {
+	return "project_tags"
+}
+
+// SetProjectTags replaces a project's tags, creating tags that don't exist yet
+func (r *ProjectRepository) SetProjectTags(projectID uint, names []string) error {
+	tags, err := domain.NewTags(names)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", projectID).Delete(&projectTag{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+
+		ids, err := upsertTags(tx, tags)
+		if err != nil {
+			return err
+		}
+
+		rows := make([]projectTag, len(ids))
+		for i, id := range ids {
+			rows[i] = projectTag{ProjectID: projectID, TagID: id}
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		return nil
+	})
+}
+
+// upsertTags creates the tags that don't exist yet and returns the IDs of all of them
+func upsertTags(tx *gorm.DB, tags []domain.Tag) ([]uint, error) {
+	if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&tags).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+	}
+
+	// IDs of tags that already existed aren't returned by the insert
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	var ids []uint
+	if err := tx.Model(&domain.Tag{}).Where("name IN ?", names).Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %v", err)
+	}
+	return ids, nil
+}
+
+func (r *ProjectRepository) FindTag(name string) (*domain.Tag, error) {
+	var tag domain.Tag
+	if err := r.db.Where("name = ?", domain.NormalizeTagName(name)).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch tag: %v", err)
+	}
+	return &tag, nil
+}
+
+// FindTagCounts returns the tags on public projects, most used first. A limit
+// of zero returns them all.
+func (r *ProjectRepository) FindTagCounts(limit int) ([]domain.TagCount, error) {
+	query := r.db.Model(&domain.Tag{}).
+		Select("tags.*, COUNT(projects.id) AS project_count").
+		Joins("JOIN project_tags ON project_tags.tag_id = tags.id").
+		Joins("JOIN projects ON projects.id = project_tags.project_id AND projects.is_public = ? AND projects.deleted_at IS NULL", true).
+		Group("tags.id").
+		Order("project_count DESC").
+		Order("tags.name")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var counts []domain.TagCount
+	if err := query.Find(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %v", err)
+	}
+	return counts, nil
+}
+
+// deleteTags removes a project's tags. The tags themselves stay for other projects.
+func deleteTags(tx *gorm.DB, projectID uint) error {
+	if err := tx.Where("project_id = ?", projectID).Delete(&projectTag{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// FindTrash returns the projects the user deleted and the samples deleted
+// from their other projects, most recently deleted first
+func (r *ProjectRepository) FindTrash(userID uint) (*domain.Trash, error) {
+	trash := &domain.Trash{}
+	if err := r.db.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&trash.Projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch trash: %v", err)
+	}
+
+	if err := trashedSamples(r.db).
+		Where("projects.user_id = ? AND projects.deleted_at IS NULL", userID).
+		Order("sample_files.deleted_at DESC").
+		Find(&trash.Samples).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch trash: %v", err)
+	}
+
+	return trash, nil
+}
+
+// FindExpiredTrash returns everything deleted before the given time
+func (r *ProjectRepository) FindExpiredTrash(before time.Time) (*domain.Trash, error) {
+	trash := &domain.Trash{}
+	if err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Find(&trash.Projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch trash: %v", err)
+	}
+
+	if err := trashedSamples(r.db).
+		Where("sample_files.deleted_at < ?", before).
+		Find(&trash.Samples).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch trash: %v", err)
+	}
+
+	return trash, nil
+}
+
+// FindTrashedProject returns a project in the trash
+func (r *ProjectRepository) FindTrashedProject(projectID uint) (*domain.Project, error) {
+	var project domain.Project
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&project, projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch project: %v", err)
+	}
+	return &project, nil
+}
+
+// FindTrashedSample returns a sample of the project that is in the trash
+func (r *ProjectRepository) FindTrashedSample(projectID, fileID uint) (*domain.SampleFile, error) {
+	var file domain.SampleFile
+	if err := r.db.Unscoped().
+		Where("project_id = ? AND id = ? AND deleted_at IS NOT NULL", projectID, fileID).
+		First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch sample file: %v", err)
+	}
+	return &file, nil
+}
+
+// RestoreProject takes a project out of the trash
+func (r *ProjectRepository) RestoreProject(projectID uint) error {
+	result := r.db.Unscoped().Model(&domain.Project{}).
+		Where("id = ? AND deleted_at IS NOT NULL", projectID).
+		UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// RestoreSampleFile takes a sample out of the trash, provided the project
+// still has room for it
+func (r *ProjectRepository) RestoreSampleFile(projectID, fileID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := &ProjectRepository{db: tx}
+		file, err := txRepo.FindTrashedSample(projectID, fileID)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&domain.SampleFile{}).Where("project_id = ?", projectID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count sample files: %v", err)
+		}
+		if count >= domain.MaxSampleFiles {
+			return domain.ErrTooManySamples
+		}
+
+		size, err := txRepo.GetProjectSize(projectID)
+		if err != nil {
+			return err
+		}
+		if size+file.Size > domain.MaxProjectSize {
+			return domain.ErrProjectTooLarge
+		}
+
+		if err := tx.Unscoped().Model(file).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		return refreshProjectSize(tx, projectID)
+	})
+}
+
+// PurgeProject permanently deletes a project in the trash with its files,
+// history, collaborators, comments, stars and tags. It returns the paths of
+// the objects nothing references anymore so the caller can remove them from
+// storage.
+func (r *ProjectRepository) PurgeProject(projectID uint) ([]string, error) {
+	if projectID == 0 {
+		return nil, common.ErrInvalidID
+	}
+
+	var orphans []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var project domain.Project
+		if err := tx.Unscoped().Preload("MainFile").Where("deleted_at IS NOT NULL").First(&project, projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrNotFound
+			}
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		// Collect every object the project references, including its history
+		var paths []string
+		if err := tx.Unscoped().Model(&domain.SampleFile{}).
+			Where("project_id = ?", projectID).
+			Pluck("file_path", &paths).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+		var revisionPaths []string
+		if err := tx.Model(&domain.RevisionFile{}).
+			Where("revision_id IN (?)", tx.Model(&domain.ProjectRevision{}).Select("id").Where("project_id = ?", projectID)).
+			Pluck("file_path", &revisionPaths).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+		paths = append(paths, revisionPaths...)
+		if project.MainFile != nil {
+			paths = append(paths, project.MainFile.FilePath)
+		}
+
+		if err := (&ProjectRepository{db: tx}).DeleteRevisions(projectID); err != nil {
+			return err
+		}
+		if err := deleteAccessGrants(tx, projectID); err != nil {
+			return err
+		}
+		if err := deleteComments(tx, projectID); err != nil {
+			return err
+		}
+		if err := deleteStars(tx, projectID); err != nil {
+			return err
+		}
+		if err := deleteTags(tx, projectID); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("project_id = ?", projectID).Delete(&domain.SampleFile{}).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+		if project.MainFile != nil {
+			if err := tx.Unscoped().Model(&domain.Project{}).Where("id = ?", projectID).UpdateColumn("main_file_id", nil).Error; err != nil {
+				return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+			}
+			if err := tx.Delete(project.MainFile).Error; err != nil {
+				return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+			}
+		}
+		if err := tx.Unscoped().Delete(&project).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		var err error
+		orphans, err = releaseBlobs(tx, paths)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orphans, nil
+}
+
+// PurgeSampleFile permanently deletes a sample in the trash and returns the
+// path of its object if nothing else references it
+func (r *ProjectRepository) PurgeSampleFile(projectID, fileID uint) ([]string, error) {
+	var orphans []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		file, err := (&ProjectRepository{db: tx}).FindTrashedSample(projectID, fileID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Delete(file).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+		}
+
+		orphans, err = releaseBlobs(tx, []string{file.FilePath})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orphans, nil
+}
+
+// trashedSamples selects the samples in the trash with their project's name
+func trashedSamples(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().
+		Table("sample_files").
+		Select("sample_files.*, projects.name AS project_name").
+		Joins("JOIN projects ON projects.id = sample_files.project_id").
+		Where("sample_files.deleted_at IS NOT NULL")
+}
+
+// refreshProjectSize stores the project's size after its files changed
+func refreshProjectSize(tx *gorm.DB, projectID uint) error {
+	size, err := (&ProjectRepository{db: tx}).GetProjectSize(projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&domain.Project{}).
+		Where("id = ?", projectID).
+		UpdateColumn("total_size", size).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+type UploadSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadSessionRepository(db *gorm.DB) *UploadSessionRepository {
+	return &UploadSessionRepository{db: db}
+}
+
+func (r *UploadSessionRepository) Create(session *domain.UploadSession) error {
+	if err := r.db.Create(session).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+	}
+	return nil
+}
+
+func (r *UploadSessionRepository) FindByID(id string) (*domain.UploadSession, error) {
+	var session domain.UploadSession
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch upload session: %v", err)
+	}
+	return &session, nil
+}
+
+// Advance records an appended chunk, but only if no other request moved the
+// session past expectedOffset in the meantime
+func (r *UploadSessionRepository) Advance(session *domain.UploadSession, expectedOffset int64) error {
+	result := r.db.Model(&domain.UploadSession{}).
+		Where("id = ? AND upload_offset = ?", session.ID, expectedOffset).
+		Updates(map[string]interface{}{
+			"upload_offset": session.Offset,
+			"part_count":    session.PartCount,
+			"hash_state":    session.HashState,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUploadOffsetMismatch
+	}
+	return nil
+}
+
+func (r *UploadSessionRepository) Delete(id string) error {
+	if err := r.db.Where("id = ?", id).Delete(&domain.UploadSession{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrDeleteFailed, err)
+	}
+	return nil
+}
+
+// FindExpired lists sessions whose expiry is before the given time
+func (r *UploadSessionRepository) FindExpired(before time.Time) ([]domain.UploadSession, error) {
+	var sessions []domain.UploadSession
+	if err := r.db.Where("expires_at < ?", before).Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch expired upload sessions: %v", err)
+	}
+	return sessions, nil
+}
@@ -2,9 +2,13 @@ package repository
 
 import (
 	"dawhub/internal/domain"
+	"dawhub/pkg/common"
 	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -41,6 +45,17 @@ func (r *UserRepository) GetByUsername(username string) (*domain.User, error) {
 	return &user, nil
 }
 
+func (r *UserRepository) GetByEmail(email string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) Update(user *domain.User) error {
 	return r.db.Save(user).Error
 }
@@ -63,7 +78,7 @@ func (r *UserRepository) CreateBetaUser(betaUser *domain.BetaUser) error {
 
 func (r *UserRepository) GetBetaUserByEmail(email string) (*domain.BetaUser, error) {
 	var betaUser domain.BetaUser
-	if err := r.db.First(&betaUser, email).Error; err != nil {
+	if err := r.db.Where("email = ?", email).First(&betaUser).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("user not found")
 		}
@@ -83,3 +98,108 @@ func (r *UserRepository) GetAllBetaUsers(page, limit int) ([]domain.BetaUser, er
 func (r *UserRepository) CountBetaUsers(count *int64) error {
 	return r.db.Model(&domain.BetaUser{}).Count(count).Error
 }
+
+// SetAdmin grants or revokes administrator rights
+func (r *UserRepository) SetAdmin(id uint, admin bool) error {
+	return r.updateUser(id, "is_admin", admin)
+}
+
+// SetDisabled locks an account out, or lets it back in
+func (r *UserRepository) SetDisabled(id uint, disabled bool) error {
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+	return r.updateUser(id, "disabled_at", disabledAt)
+}
+
+func (r *UserRepository) updateUser(id uint, column string, value interface{}) error {
+	result := r.db.Model(&domain.User{}).Where("id = ?", id).Update(column, value)
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// FindUninvitedBetaUsers lists beta signups that haven't been sent a signup
+// invite yet, oldest first
+func (r *UserRepository) FindUninvitedBetaUsers() ([]domain.BetaUser, error) {
+	var betaUsers []domain.BetaUser
+	if err := r.db.Where("invited_at IS NULL").Order("created_at, id").Find(&betaUsers).Error; err != nil {
+		return nil, err
+	}
+	return betaUsers, nil
+}
+
+// MarkBetaUserInvited records that a beta user was sent a signup invite
+func (r *UserRepository) MarkBetaUserInvited(id uint) error {
+	if err := r.db.Model(&domain.BetaUser{}).Where("id = ?", id).Update("invited_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+	}
+	return nil
+}
+
+// CreateSignupInvite stores a new signup invite
+func (r *UserRepository) CreateSignupInvite(invite *domain.SignupInvite) error {
+	if err := r.db.Create(invite).Error; err != nil {
+		return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+	}
+	return nil
+}
+
+// FindSignupInvite returns the pending signup invite with the given token hash
+func (r *UserRepository) FindSignupInvite(tokenHash string) (*domain.SignupInvite, error) {
+	var invite domain.SignupInvite
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSignupInviteInvalid
+		}
+		return nil, fmt.Errorf("failed to fetch invite: %v", err)
+	}
+	if !invite.IsPending() {
+		return nil, domain.ErrSignupInviteInvalid
+	}
+	return &invite, nil
+}
+
+// AcceptSignupInvite creates the user's account with the invite's email
+// address. Each invite can be used once.
+func (r *UserRepository) AcceptSignupInvite(tokenHash string, user *domain.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var invite domain.SignupInvite
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&invite).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrSignupInviteInvalid
+			}
+			return fmt.Errorf("failed to fetch invite: %v", err)
+		}
+		if !invite.IsPending() {
+			return domain.ErrSignupInviteInvalid
+		}
+
+		user.Email = invite.Email
+		var taken int64
+		if err := tx.Model(&domain.User{}).
+			Where("username = ? OR LOWER(email) = LOWER(?)", user.Username, user.Email).
+			Count(&taken).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+		}
+		if taken > 0 {
+			return domain.ErrAccountExists
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrCreateFailed, err)
+		}
+
+		if err := tx.Model(&invite).Update("accepted_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("%w: %v", common.ErrUpdateFailed, err)
+		}
+		return nil
+	})
+}
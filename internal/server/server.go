@@ -3,6 +3,8 @@ package server
 import (
 	"fmt"
 	"html/template"
+	"net/http"
+	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -10,6 +12,7 @@ import (
 
 	"dawhub/internal/config"
 	"dawhub/internal/email"
+	"dawhub/internal/gc"
 	"dawhub/internal/handlers/api"
 	"dawhub/internal/handlers/web"
 	"dawhub/internal/middleware"
@@ -25,6 +28,13 @@ type Server struct {
 	projectWeb *web.ProjectHandler
 	authAPI    *api.AuthHandler
 	authWeb    *web.AuthHandler
+	memberAPI  *api.MemberHandler
+	memberWeb  *web.MemberHandler
+	users      middleware.UserLookup
+	collector  *gc.Collector
+	reaper     *gc.SessionReaper
+	purger     *gc.TrashPurger
+	files      http.Handler // Serves signed file URLs for backends without their own server
 }
 
 func New(cfg *config.Config) (*Server, error) {
@@ -35,7 +45,7 @@ func New(cfg *config.Config) (*Server, error) {
 	}
 
 	// Initialize storage
-	store, err := storage.NewMinioStorage(cfg.Minio)
+	store, files, err := storage.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -46,18 +56,26 @@ func New(cfg *config.Config) (*Server, error) {
 	// Initialize repositories
 	projectRepo := repository.NewProjectRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	uploadRepo := repository.NewUploadSessionRepository(db)
 
 	// Initialize handlers
-	projectAPI := api.NewProjectHandler(projectRepo, store)
-	projectWeb := web.NewProjectHandler(projectRepo, store)
+	projectAPI := api.NewProjectHandler(projectRepo, store, uploadRepo, cfg.Upload.SessionTTL, cfg.GC.GracePeriod)
+	projectWeb := web.NewProjectHandler(projectRepo, store, cfg.GC.GracePeriod)
 	authAPI := api.NewAuthHandler(userRepo)
 	authWeb := web.NewAuthHandler(userRepo, projectRepo, emailService)
+	memberAPI := api.NewMemberHandler(projectRepo, userRepo, emailService)
+	memberWeb := web.NewMemberHandler(projectRepo, userRepo, emailService)
+
+	// Initialize background jobs
+	collector := gc.NewCollector(projectRepo, store, cfg.GC.GracePeriod, cfg.Upload.SessionTTL)
+	reaper := gc.NewSessionReaper(uploadRepo, store)
+	purger := gc.NewTrashPurger(projectRepo, store, cfg.GC.TrashRetention, cfg.GC.GracePeriod)
 
 	// Initialize router
 	router := gin.New()
 
 	// Add essential middlewares
-	router.Use(gin.Recovery())                            // Recover from panics
+	router.Use(middleware.Recovery())                     // Recover from panics
 	router.Use(middleware.NewIPRateLimiter().RateLimit()) // Rate limiting
 	router.Use(middleware.BlockSuspiciousRequests())      // Block suspicious requests
 	router.Use(middleware.LogSuspiciousRequests())        // Log suspiciouis requests
@@ -79,6 +97,13 @@ func New(cfg *config.Config) (*Server, error) {
 		projectWeb: projectWeb,
 		authAPI:    authAPI,
 		authWeb:    authWeb,
+		memberAPI:  memberAPI,
+		memberWeb:  memberWeb,
+		users:      userRepo,
+		collector:  collector,
+		reaper:     reaper,
+		purger:     purger,
+		files:      files,
 	}, nil
 }
 
@@ -86,6 +111,14 @@ func (s *Server) setupRoutes() {
 	// Serve static files
 	s.router.Static("/static", "./static")
 
+	// Signed file URLs carry their own credentials
+	if s.files != nil {
+		files := gin.WrapH(s.files)
+		s.router.GET(storage.LocalFilesPath+"*path", files)
+		s.router.HEAD(storage.LocalFilesPath+"*path", files)
+		s.router.PUT(storage.LocalFilesPath+"*path", files)
+	}
+
 	// Set HTML templates
 	s.router.SetHTMLTemplate(s.templates)
 
@@ -94,32 +127,67 @@ func (s *Server) setupRoutes() {
 	s.router.POST("/login", s.authWeb.Login)
 	// s.router.GET("/register", s.authWeb.RegisterPage)
 	// s.router.POST("/register", s.authWeb.Register)
+	s.router.GET("/signup/:token", s.authWeb.SignupPage)
+	s.router.POST("/signup/:token", s.authWeb.Signup)
 	s.router.POST("/logout", s.authWeb.Logout)
 	s.router.GET("/health", s.authWeb.Health)
 	s.router.GET("/", s.authWeb.LandingPage)
 
+	// Share links are public; the token is the credential
+	s.router.GET("/s/:token", s.projectWeb.Shared)
+	s.router.POST("/s/:token", s.projectWeb.UnlockShared)
+
 	// Beta Signup Route
 	s.router.POST("/beta-signup", s.authWeb.BetaSignup)
 
 	// Protected web routes
 	web := s.router.Group("/")
-	web.Use(middleware.WebAuthMiddleware())
+	web.Use(middleware.WebAuthMiddleware(), middleware.ActiveUser(s.users))
 	{
 		web.GET("/dashboard", s.projectWeb.Home)
 		web.GET("/projects", s.projectWeb.List)
+		web.GET("/explore", s.projectWeb.Explore)
+		web.GET("/starred", s.projectWeb.Starred)
+		web.GET("/search", s.projectWeb.Search)
+		web.GET("/tags", s.projectWeb.Tags)
+		web.GET("/tags/:name", s.projectWeb.Tag)
+		web.GET("/library", s.projectWeb.Library)
+		web.POST("/library", s.projectWeb.UploadLibraryItem)
+		web.POST("/library/:itemId/update", s.projectWeb.UpdateLibraryItem)
+		web.POST("/library/:itemId/delete", s.projectWeb.DeleteLibraryItem)
+		web.GET("/trash", s.projectWeb.Trash)
+		web.POST("/trash/projects/:id/restore", s.projectWeb.RestoreProject)
+		web.POST("/trash/projects/:id/delete", s.projectWeb.PurgeProject)
+		web.POST("/trash/projects/:id/samples/:fileId/restore", s.projectWeb.RestoreSample)
+		web.POST("/trash/projects/:id/samples/:fileId/delete", s.projectWeb.PurgeSample)
 		web.GET("/projects/new", s.projectWeb.New)
 		web.POST("/projects/create", s.projectWeb.Create)
 		web.GET("/projects/:id", s.projectWeb.Show)
 		web.GET("/projects/:id/edit", s.projectWeb.Edit)
+		web.GET("/projects/:id/compare", s.projectWeb.Compare)
+		web.POST("/projects/:id/revisions/:number/restore", s.projectWeb.Restore)
 		web.POST("/projects/:id/update", s.projectWeb.Update)
 		web.POST("/projects/:id/delete", s.projectWeb.Delete)
+		web.POST("/projects/:id/members", s.memberWeb.Invite)
+		web.POST("/projects/:id/members/:userId/remove", s.memberWeb.Remove)
+		web.POST("/projects/:id/invites/:inviteId/revoke", s.memberWeb.RevokeInvite)
+		web.GET("/invites/:token", s.memberWeb.Accept)
+		web.POST("/projects/:id/shares", s.projectWeb.CreateShareLink)
+		web.POST("/projects/:id/shares/:shareId/revoke", s.projectWeb.RevokeShareLink)
+		web.POST("/projects/:id/star", s.projectWeb.Star)
+		web.POST("/projects/:id/unstar", s.projectWeb.Unstar)
+		web.POST("/projects/:id/library", s.projectWeb.AttachLibraryItems)
+		web.POST("/projects/:id/samples/:fileId/delete", s.projectWeb.RemoveSample)
+		web.POST("/projects/:id/comments", s.projectWeb.CreateComment)
+		web.POST("/projects/:id/comments/:commentId/resolve", s.projectWeb.ResolveComment)
+		web.POST("/projects/:id/comments/:commentId/delete", s.projectWeb.DeleteComment)
 		web.GET("/projects/import", s.projectWeb.Import)
 		web.POST("/projects/import", s.projectWeb.HandleImport)
 		web.GET("/settings", s.authWeb.SettingsPage)
 		web.POST("/settings/profile", s.authWeb.UpdateProfile)
 		web.POST("/settings/password", s.authWeb.UpdatePassword)
 		web.POST("/settings/delete-account", s.authWeb.DeleteAccount)
-		web.GET("/beta-users", s.authWeb.BetaUsersPage)
+		web.GET("/beta-users", middleware.AdminOnly(), s.authWeb.BetaUsersPage)
 	}
 
 	// API routes
@@ -131,22 +199,87 @@ func (s *Server) setupRoutes() {
 
 		// Protected API routes
 		protected := api.Group("")
-		protected.Use(middleware.APIAuthMiddleware())
+		protected.Use(middleware.APIAuthMiddleware(), middleware.ActiveUser(s.users))
 		{
 			protected.GET("/projects", s.projectAPI.List)
 			protected.POST("/projects", s.projectAPI.Create)
+			protected.GET("/projects/trending", s.projectAPI.Trending)
+			protected.GET("/starred", s.projectAPI.ListStarred)
+			protected.GET("/search", s.projectAPI.Search)
+			protected.GET("/tags", s.projectAPI.ListTags)
+			protected.GET("/tags/:name/projects", s.projectAPI.TagProjects)
+			protected.GET("/library", s.projectAPI.ListLibrary)
+			protected.POST("/library", s.projectAPI.UploadLibraryItem)
+			protected.PATCH("/library/:itemId", s.projectAPI.UpdateLibraryItem)
+			protected.DELETE("/library/:itemId", s.projectAPI.DeleteLibraryItem)
 			protected.GET("/projects/:id", s.projectAPI.Get)
 			protected.PUT("/projects/:id", s.projectAPI.Update)
 			protected.DELETE("/projects/:id", s.projectAPI.Delete)
+			protected.GET("/projects/:id/revisions", s.projectAPI.ListRevisions)
+			protected.GET("/projects/:id/revisions/:number", s.projectAPI.GetRevision)
+			protected.POST("/projects/:id/revisions/:number/restore", s.projectAPI.Restore)
+			protected.GET("/projects/:id/compare", s.projectAPI.Compare)
+			protected.GET("/projects/:id/members", s.memberAPI.List)
+			protected.POST("/projects/:id/members", s.memberAPI.Invite)
+			protected.PUT("/projects/:id/members/:userId", s.memberAPI.UpdateRole)
+			protected.DELETE("/projects/:id/members/:userId", s.memberAPI.Remove)
+			protected.DELETE("/projects/:id/invites/:inviteId", s.memberAPI.RevokeInvite)
+			protected.POST("/invites/:token/accept", s.memberAPI.Accept)
+			protected.GET("/projects/:id/shares", s.projectAPI.ListShareLinks)
+			protected.POST("/projects/:id/shares", s.projectAPI.CreateShareLink)
+			protected.DELETE("/projects/:id/shares/:shareId", s.projectAPI.RevokeShareLink)
+			protected.PUT("/projects/:id/star", s.projectAPI.Star)
+			protected.PUT("/projects/:id/tags", s.projectAPI.SetTags)
+			protected.POST("/projects/:id/library", s.projectAPI.AttachLibraryItems)
+			protected.DELETE("/projects/:id/samples/:fileId", s.projectAPI.RemoveSample)
+			protected.GET("/trash", s.projectAPI.ListTrash)
+			protected.POST("/trash/projects/:id/restore", s.projectAPI.RestoreProject)
+			protected.DELETE("/trash/projects/:id", s.projectAPI.PurgeProject)
+			protected.POST("/trash/projects/:id/samples/:fileId/restore", s.projectAPI.RestoreSample)
+			protected.DELETE("/trash/projects/:id/samples/:fileId", s.projectAPI.PurgeSample)
+			protected.DELETE("/projects/:id/star", s.projectAPI.Unstar)
+			protected.GET("/projects/:id/comments", s.projectAPI.ListComments)
+			protected.POST("/projects/:id/comments", s.projectAPI.CreateComment)
+			protected.PATCH("/projects/:id/comments/:commentId", s.projectAPI.ResolveComment)
+			protected.DELETE("/projects/:id/comments/:commentId", s.projectAPI.DeleteComment)
 		}
 
 		// Separate download route with dual auth
-		api.GET("/projects/:id/download", middleware.DualAuthMiddleware(), s.projectAPI.Download)
-		api.POST("/projects/:id/upload", middleware.DualAuthMiddleware(), s.projectAPI.Upload)
+		api.GET("/projects/:id/download", middleware.DualAuthMiddleware(), middleware.ActiveUser(s.users), s.projectAPI.Download)
+		api.HEAD("/projects/:id/download", middleware.DualAuthMiddleware(), middleware.ActiveUser(s.users), s.projectAPI.Download)
+		api.GET("/projects/:id/archive", middleware.DualAuthMiddleware(), middleware.ActiveUser(s.users), s.projectAPI.Archive)
+		api.POST("/projects/:id/upload", middleware.DualAuthMiddleware(), middleware.ActiveUser(s.users), s.projectAPI.Upload)
+
+		// Share link downloads need no account
+		api.GET("/shares/:token/download", s.projectAPI.SharedDownload)
+		api.HEAD("/shares/:token/download", s.projectAPI.SharedDownload)
+
+		// Resumable uploads
+		uploads := api.Group("")
+		uploads.Use(middleware.DualAuthMiddleware(), middleware.ActiveUser(s.users))
+		{
+			uploads.POST("/projects/:id/uploads", s.projectAPI.CreateUpload)
+			uploads.POST("/projects/:id/uploads/presign", s.projectAPI.PresignUpload)
+			uploads.HEAD("/uploads/:uploadId", s.projectAPI.UploadStatus)
+			uploads.GET("/uploads/:uploadId", s.projectAPI.UploadStatus)
+			uploads.PATCH("/uploads/:uploadId", s.projectAPI.AppendUpload)
+			uploads.POST("/uploads/:uploadId/complete", s.projectAPI.CompleteUpload)
+			uploads.POST("/uploads/:uploadId/finalize", s.projectAPI.FinalizeUpload)
+			uploads.DELETE("/uploads/:uploadId", s.projectAPI.AbortUpload)
+		}
 	}
 }
 
 func (s *Server) Start() error {
 	s.setupRoutes()
+
+	if s.config.GC.Interval > 0 {
+		go s.collector.RunPeriodically(s.config.GC.Interval, nil)
+	}
+	go s.reaper.RunPeriodically(time.Hour, nil)
+	if s.config.GC.TrashRetention > 0 {
+		go s.purger.RunPeriodically(time.Hour, nil)
+	}
+
 	return s.router.Run(":" + s.config.Server.Port)
 }
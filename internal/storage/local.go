@@ -0,0 +1,883 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dawhub/internal/config"
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// LocalFilesPath is where the app serves LocalStorage's signed URLs
+const LocalFilesPath = "/files/"
+
+// claimedPrefix holds direct uploads being finalized, out of reach of their
+// signed URLs. Claims left behind by a crash are collected like staged uploads.
+const claimedPrefix = "claimed/"
+
+// LocalStorage keeps files in a directory for single-box installs and offline
+// development. Objects live under objects/ with their metadata under meta/.
+// Every write goes to tmp/ first and is renamed into place, so readers never
+// see a partial file. Download and direct upload URLs are signed with a
+// secret and served by the app through ServeHTTP.
+type LocalStorage struct {
+	root    string
+	secret  []byte
+	baseURL string
+}
+
+// localMetadata is stored alongside each object
+type localMetadata struct {
+	ContentType string    `json:"content_type"`
+	Hash        string    `json:"hash,omitempty"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// localUpload records a multipart upload in progress
+type localUpload struct {
+	Path        string `json:"path"`
+	ContentType string `json:"content_type"`
+}
+
+func NewLocalStorage(cfg config.LocalStorageConfig) (*LocalStorage, error) {
+	if cfg.Root == "" || cfg.URLSecret == "" {
+		return nil, fmt.Errorf("local storage needs a root directory and a URL secret")
+	}
+
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage root: %w", err)
+	}
+
+	// Anything left in tmp/ is from a write interrupted by a crash
+	if err := os.RemoveAll(filepath.Join(root, "tmp")); err != nil {
+		return nil, fmt.Errorf("failed to clear temporary files: %w", err)
+	}
+	for _, dir := range []string{"objects", "meta", "parts", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+	}
+
+	return &LocalStorage{
+		root:    root,
+		secret:  []byte(cfg.URLSecret),
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+	}, nil
+}
+
+// UploadFile streams a file to disk in a single pass, hashing and
+// size-checking it on the way, and returns file info, path, and error
+func (s *LocalStorage) UploadFile(filename string, reader io.Reader) (domain.FileInfo, string, error) {
+	if filename == "" || reader == nil {
+		return domain.FileInfo{}, "", common.ErrInvalidInput
+	}
+
+	contentType := determineContentType(filename)
+	if !domain.IsAllowedFileType(contentType) {
+		log.Printf("[ERROR] Invalid content type: %s", contentType)
+		return domain.FileInfo{}, "", domain.ErrInvalidFileType
+	}
+
+	hash := sha256.New()
+	body := &sizeLimitedReader{reader: io.TeeReader(reader, hash), limit: domain.MaxFileSize}
+	stagingName := stagingObjectName()
+	err := s.writeObject(stagingName, localMetadata{ContentType: contentType, UploadedAt: time.Now()}, func(w io.Writer) error {
+		_, err := io.Copy(w, body)
+		return err
+	})
+	if err != nil {
+		if body.exceeded {
+			log.Printf("[ERROR] File too large: more than %d bytes", domain.MaxFileSize)
+			return domain.FileInfo{}, "", domain.ErrFileTooLarge
+		}
+		return domain.FileInfo{}, "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer s.removeObject(stagingName)
+
+	metadata := domain.FileMetadata{
+		Size:        body.read,
+		ContentType: contentType,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		UploadedAt:  time.Now(),
+	}
+	objectName, err := s.promoteStagedObject(stagingName, metadata)
+	if err != nil {
+		return domain.FileInfo{}, "", fmt.Errorf("upload failed: %w", err)
+	}
+
+	log.Printf("[INFO] File upload completed successfully - File: %s, Path: %s", filename, objectName)
+
+	return domain.FileInfo{
+		Size:        metadata.Size,
+		Filename:    filename,
+		ContentType: metadata.ContentType,
+		Hash:        metadata.Hash,
+	}, objectName, nil
+}
+
+// ValidateFile hashes and size-checks a file without keeping it in memory
+func (s *LocalStorage) ValidateFile(reader io.Reader, filename string) (domain.FileMetadata, error) {
+	return validateFile(reader, filename)
+}
+
+// ValidateFiles validates multiple files in parallel
+func (s *LocalStorage) ValidateFiles(files map[string]io.Reader) (map[string]domain.FileMetadata, error) {
+	return validateFiles(files)
+}
+
+// GetDownloadURL returns a signed URL the app serves the file from
+func (s *LocalStorage) GetDownloadURL(filepath string) (string, error) {
+	if filepath == "" {
+		return "", common.ErrInvalidInput
+	}
+
+	if _, err := s.GetFileMetadata(filepath); err != nil {
+		return "", err
+	}
+
+	return s.signedURL(http.MethodGet, filepath, time.Now().Add(presignedURLExpiry)), nil
+}
+
+// GetFile opens a file for reading. If the content no longer matches the hash
+// it was stored under, the read fails with domain.ErrInvalidHash before the
+// last of the file is returned.
+func (s *LocalStorage) GetFile(filepath string) (io.ReadCloser, domain.FileInfo, error) {
+	file, metadata, err := s.openObject(filepath)
+	if err != nil {
+		return nil, domain.FileInfo{}, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return newHashVerifiedReader(file, file.Name(), metadata.Hash), domain.FileInfo{
+		Size:        metadata.Size,
+		ContentType: metadata.ContentType,
+		Hash:        metadata.Hash,
+	}, nil
+}
+
+// GetFileRange retrieves the bytes from start to end inclusive of a file. The
+// whole file is checked against its hash first, failing with
+// domain.ErrInvalidHash if it no longer matches.
+func (s *LocalStorage) GetFileRange(filepath string, start, end int64) (io.ReadCloser, error) {
+	if filepath == "" || start < 0 || end < start {
+		return nil, common.ErrInvalidInput
+	}
+
+	file, metadata, err := s.openObject(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	if err := verifyFile(file, metadata.Hash); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("invalid range: %w", err)
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, end-start+1), file}, nil
+}
+
+// GetFileMetadata retrieves file metadata without reading the file
+func (s *LocalStorage) GetFileMetadata(filepath string) (domain.FileMetadata, error) {
+	objectPath, err := s.objectPath(filepath)
+	if err != nil {
+		return domain.FileMetadata{}, err
+	}
+
+	info, err := os.Stat(objectPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return domain.FileMetadata{}, fmt.Errorf("failed to get metadata: %w", domain.ErrFileNotFound)
+		}
+		return domain.FileMetadata{}, fmt.Errorf("failed to get metadata: %w", err)
+	}
+	metadata, err := s.readMetadata(filepath)
+	if err != nil {
+		return domain.FileMetadata{}, fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	return domain.FileMetadata{
+		Size:        info.Size(),
+		ContentType: metadata.ContentType,
+		Hash:        metadata.Hash,
+		UploadedAt:  metadata.UploadedAt,
+	}, nil
+}
+
+// DeleteFile removes a single file. Removing a missing file is not an error.
+func (s *LocalStorage) DeleteFile(filepath string) error {
+	if err := s.removeObject(filepath); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// DeleteFiles removes multiple files
+func (s *LocalStorage) DeleteFiles(filepaths []string) error {
+	for _, filepath := range filepaths {
+		if err := s.removeObject(filepath); err != nil {
+			return fmt.Errorf("failed to delete files: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListFiles lists every object under prefix
+func (s *LocalStorage) ListFiles(prefix string) ([]domain.StoredObject, error) {
+	objectsDir := filepath.Join(s.root, "objects")
+
+	// Only walk the directory the prefix points into
+	walkRoot := objectsDir
+	if dir := path.Dir(prefix); dir != "." {
+		if dirPath, err := s.objectPath(dir); err == nil {
+			walkRoot = dirPath
+		}
+	}
+
+	var objects []domain.StoredObject
+	err := filepath.WalkDir(walkRoot, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if name == walkRoot && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(objectsDir, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, domain.StoredObject{
+			Path:         key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return objects, nil
+}
+
+// StartMultipartUpload opens a multipart upload for filename under a fresh
+// staging key
+func (s *LocalStorage) StartMultipartUpload(filename string) (domain.MultipartUpload, error) {
+	contentType := determineContentType(filename)
+	if !domain.IsAllowedFileType(contentType) {
+		log.Printf("[ERROR] Invalid content type: %s", contentType)
+		return domain.MultipartUpload{}, domain.ErrInvalidFileType
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return domain.MultipartUpload{}, fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	upload := domain.MultipartUpload{
+		Path:        stagingObjectName(),
+		UploadID:    hex.EncodeToString(id),
+		ContentType: contentType,
+	}
+
+	data, err := json.Marshal(localUpload{Path: upload.Path, ContentType: contentType})
+	if err != nil {
+		return domain.MultipartUpload{}, fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	if err := s.writeFile(filepath.Join(s.partsDir(upload.UploadID), "upload.json"), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return domain.MultipartUpload{}, fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+
+	return upload, nil
+}
+
+// UploadPart stores one part of a multipart upload. Retrying a part number
+// replaces the previous attempt.
+func (s *LocalStorage) UploadPart(path, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	if path == "" || uploadID == "" || partNumber <= 0 || reader == nil {
+		return common.ErrInvalidInput
+	}
+	if _, err := s.findUpload(path, uploadID); err != nil {
+		return fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+
+	partPath := filepath.Join(s.partsDir(uploadID), strconv.Itoa(partNumber))
+	err := s.writeFile(partPath, func(w io.Writer) error {
+		if size < 0 {
+			_, err := io.Copy(w, reader)
+			return err
+		}
+		n, err := io.Copy(w, io.LimitReader(reader, size))
+		if err == nil && n != size {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+
+	return nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts, checks the result
+// against metadata and promotes it to its content-addressed key
+func (s *LocalStorage) CompleteMultipartUpload(path, uploadID string, metadata domain.FileMetadata) (string, error) {
+	upload, err := s.findUpload(path, uploadID)
+	if err != nil {
+		return "", fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	defer os.RemoveAll(s.partsDir(uploadID))
+
+	parts, err := s.listParts(uploadID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list parts: %w", err)
+	}
+
+	hash := sha256.New()
+	var size int64
+	err = s.writeObject(path, localMetadata{ContentType: upload.ContentType, UploadedAt: time.Now()}, func(w io.Writer) error {
+		for _, part := range parts {
+			n, err := copyFile(io.MultiWriter(w, hash), part)
+			size += n
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	defer s.removeObject(path)
+
+	// The key is derived from the hash, so a wrong one must never be stored
+	if size != metadata.Size {
+		log.Printf("[ERROR] Assembled upload size mismatch - Path: %s, Expected: %d, Actual: %d",
+			path, metadata.Size, size)
+		return "", domain.ErrUploadSizeMismatch
+	}
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if metadata.Hash != "" && actual != strings.ToLower(metadata.Hash) {
+		log.Printf("[ERROR] Assembled upload hash mismatch - Path: %s, Expected: %s, Actual: %s",
+			path, metadata.Hash, actual)
+		return "", domain.ErrInvalidHash
+	}
+
+	metadata.Hash = actual
+	objectName, err := s.promoteStagedObject(path, metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to promote upload: %w", err)
+	}
+
+	return objectName, nil
+}
+
+// AbortMultipartUpload discards an unfinished multipart upload and its parts
+func (s *LocalStorage) AbortMultipartUpload(path, uploadID string) error {
+	if _, err := s.findUpload(path, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	if err := os.RemoveAll(s.partsDir(uploadID)); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+// PresignUpload issues a signed URL the client can PUT filename to, under a
+// fresh staging key
+func (s *LocalStorage) PresignUpload(filename string, expiry time.Duration) (domain.PresignedUpload, error) {
+	if filename == "" || expiry <= 0 {
+		return domain.PresignedUpload{}, common.ErrInvalidInput
+	}
+
+	contentType := determineContentType(filename)
+	if !domain.IsAllowedFileType(contentType) {
+		log.Printf("[ERROR] Invalid content type: %s", contentType)
+		return domain.PresignedUpload{}, domain.ErrInvalidFileType
+	}
+
+	if expiry > maxPresignExpiry {
+		expiry = maxPresignExpiry
+	}
+
+	stagingName := stagingObjectName()
+	expiresAt := time.Now().Add(expiry)
+	return domain.PresignedUpload{
+		Path:        stagingName,
+		URL:         s.signedURL(http.MethodPut, stagingName, expiresAt),
+		ContentType: contentType,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// FinalizeUpload checks a file the client uploaded directly against the
+// expected size, content type and hash, then promotes it to its
+// content-addressed key. Files failing a check are removed.
+func (s *LocalStorage) FinalizeUpload(path string, expected domain.FileMetadata) (string, error) {
+	if path == "" || expected.Hash == "" {
+		return "", common.ErrInvalidInput
+	}
+
+	// The signed URL stays writable until it expires, so move the upload
+	// out of the client's reach before checking it
+	claimed, err := s.claimUpload(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", domain.ErrFileNotFound
+		}
+		return "", fmt.Errorf("failed to claim upload: %w", err)
+	}
+	defer s.removeObject(claimed)
+
+	info, err := s.GetFileMetadata(claimed)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat upload: %w", err)
+	}
+
+	switch {
+	case info.Size > domain.MaxFileSize:
+		log.Printf("[ERROR] File too large: %d bytes", info.Size)
+		return "", domain.ErrFileTooLarge
+	case info.Size != expected.Size:
+		log.Printf("[ERROR] Direct upload size mismatch - Path: %s, Expected: %d, Actual: %d",
+			path, expected.Size, info.Size)
+		return "", domain.ErrUploadSizeMismatch
+	case !domain.IsAllowedFileType(info.ContentType) || info.ContentType != expected.ContentType:
+		log.Printf("[ERROR] Invalid content type: %s", info.ContentType)
+		return "", domain.ErrInvalidFileType
+	}
+
+	objectPath, _ := s.objectPath(claimed)
+	hash := sha256.New()
+	if _, err := copyFile(hash, objectPath); err != nil {
+		return "", fmt.Errorf("failed to hash upload: %w", err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != strings.ToLower(expected.Hash) {
+		log.Printf("[ERROR] Direct upload hash mismatch - Path: %s, Expected: %s, Actual: %s",
+			path, expected.Hash, actual)
+		return "", domain.ErrInvalidHash
+	}
+
+	metadata := expected
+	metadata.Hash = strings.ToLower(expected.Hash)
+	objectName, err := s.promoteStagedObject(claimed, metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to promote upload: %w", err)
+	}
+
+	log.Printf("[INFO] Direct upload finalized - Path: %s", objectName)
+	return objectName, nil
+}
+
+// ImportFile stores content whose metadata is already known, such as a file
+// restored from a backup, under its content-addressed key. Content that
+// doesn't match the expected size and hash is rejected.
+func (s *LocalStorage) ImportFile(expected domain.FileMetadata, reader io.Reader) (string, error) {
+	if expected.Hash == "" || reader == nil {
+		return "", common.ErrInvalidInput
+	}
+
+	hash := sha256.New()
+	body := &sizeLimitedReader{reader: io.TeeReader(reader, hash), limit: expected.Size}
+	stagingName := stagingObjectName()
+	err := s.writeObject(stagingName, localMetadata{ContentType: expected.ContentType, UploadedAt: time.Now()}, func(w io.Writer) error {
+		_, err := io.Copy(w, body)
+		return err
+	})
+	if err != nil {
+		if body.exceeded {
+			return "", domain.ErrUploadSizeMismatch
+		}
+		return "", fmt.Errorf("import failed: %w", err)
+	}
+	defer s.removeObject(stagingName)
+
+	if body.read != expected.Size {
+		return "", domain.ErrUploadSizeMismatch
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != strings.ToLower(expected.Hash) {
+		log.Printf("[ERROR] Imported file hash mismatch - Expected: %s, Actual: %s", expected.Hash, actual)
+		return "", domain.ErrInvalidHash
+	}
+
+	metadata := expected
+	metadata.Hash = strings.ToLower(expected.Hash)
+	if metadata.UploadedAt.IsZero() {
+		metadata.UploadedAt = time.Now()
+	}
+	objectName, err := s.promoteStagedObject(stagingName, metadata)
+	if err != nil {
+		return "", fmt.Errorf("import failed: %w", err)
+	}
+	return objectName, nil
+}
+
+// ServeHTTP serves the signed URLs handed out by GetDownloadURL and
+// PresignUpload. Mount it at LocalFilesPath.
+func (s *LocalStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, LocalFilesPath)
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if !s.verifySignature(method, key, r.URL.Query()) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	switch method {
+	case http.MethodGet:
+		s.serveObject(w, r, key)
+	case http.MethodPut:
+		s.receiveUpload(w, r, key)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveObject answers a signed download, including range requests. The file
+// is checked against its hash before anything is sent.
+func (s *LocalStorage) serveObject(w http.ResponseWriter, r *http.Request, key string) {
+	file, metadata, err := s.openObject(key)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "failed to read file", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	if err := verifyFile(file, metadata.Hash); err != nil {
+		http.Error(w, "failed to read file", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", metadata.ContentType)
+	http.ServeContent(w, r, "", metadata.UploadedAt, file)
+}
+
+// receiveUpload stores the body of a signed direct upload. Only staging keys
+// can be written; the upload is checked and promoted by FinalizeUpload.
+func (s *LocalStorage) receiveUpload(w http.ResponseWriter, r *http.Request, key string) {
+	if !strings.HasPrefix(key, stagingPrefix) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	body := &sizeLimitedReader{reader: r.Body, limit: domain.MaxFileSize}
+	metadata := localMetadata{ContentType: r.Header.Get("Content-Type"), UploadedAt: time.Now()}
+	err := s.writeObject(key, metadata, func(w io.Writer) error {
+		_, err := io.Copy(w, body)
+		return err
+	})
+	if err != nil {
+		if body.exceeded {
+			http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		log.Printf("[ERROR] Direct upload failed - Path: %s: %v", key, err)
+		http.Error(w, "upload failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// signedURL returns the URL granting method on key until expires
+func (s *LocalStorage) signedURL(method, key string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{
+		"expires":   {exp},
+		"signature": {s.sign(method, key, exp)},
+	}
+	return s.baseURL + (&url.URL{Path: LocalFilesPath + key}).EscapedPath() + "?" + query.Encode()
+}
+
+func (s *LocalStorage) verifySignature(method, key string, query url.Values) bool {
+	exp := query.Get("expires")
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(query.Get("signature")), []byte(s.sign(method, key, exp)))
+}
+
+func (s *LocalStorage) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// promoteStagedObject moves a staged upload to its content-addressed key and
+// returns that key. Identical content is stored once, so an existing blob wins.
+func (s *LocalStorage) promoteStagedObject(stagingName string, metadata domain.FileMetadata) (string, error) {
+	objectName := blobObjectName(metadata.Hash)
+	objectPath, _ := s.objectPath(objectName)
+	// Refresh the modification time so the garbage collector's grace period
+	// covers the upload about to reference the blob
+	now := time.Now()
+	if err := os.Chtimes(objectPath, now, now); err == nil {
+		log.Printf("[INFO] Blob already stored, skipping copy - Path: %s", objectName)
+		return objectName, nil
+	}
+
+	if err := s.writeMetadata(objectName, localMetadata{
+		ContentType: metadata.ContentType,
+		Hash:        metadata.Hash,
+		UploadedAt:  metadata.UploadedAt,
+	}); err != nil {
+		return "", err
+	}
+	stagingPath, _ := s.objectPath(stagingName)
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(stagingPath, objectPath); err != nil {
+		return "", err
+	}
+
+	return objectName, nil
+}
+
+// claimUpload renames a direct upload to a key signed URLs cannot write,
+// returning the new key
+func (s *LocalStorage) claimUpload(key string) (string, error) {
+	objectPath, err := s.objectPath(key)
+	if err != nil {
+		return "", err
+	}
+	claimed := claimedPrefix + strings.TrimPrefix(key, stagingPrefix)
+	claimedPath, err := s.objectPath(claimed)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range []string{filepath.Dir(claimedPath), filepath.Dir(s.metadataPath(claimed))} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Rename(s.metadataPath(key), s.metadataPath(claimed)); err != nil {
+		return "", err
+	}
+	if err := os.Rename(objectPath, claimedPath); err != nil {
+		os.Remove(s.metadataPath(claimed))
+		return "", err
+	}
+	return claimed, nil
+}
+
+// openObject opens a stored object along with its metadata
+func (s *LocalStorage) openObject(key string) (*os.File, domain.FileMetadata, error) {
+	metadata, err := s.GetFileMetadata(key)
+	if err != nil {
+		return nil, domain.FileMetadata{}, err
+	}
+
+	objectPath, _ := s.objectPath(key)
+	file, err := os.Open(objectPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, domain.FileMetadata{}, domain.ErrFileNotFound
+		}
+		return nil, domain.FileMetadata{}, err
+	}
+	return file, metadata, nil
+}
+
+// objectPath maps a key to its file, rejecting keys that would escape the
+// objects directory
+func (s *LocalStorage) objectPath(key string) (string, error) {
+	if key == "" || path.Clean("/"+key) != "/"+key || strings.Contains(key, `\`) {
+		return "", common.ErrInvalidInput
+	}
+	return filepath.Join(s.root, "objects", filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStorage) metadataPath(key string) string {
+	return filepath.Join(s.root, "meta", filepath.FromSlash(key)+".json")
+}
+
+func (s *LocalStorage) partsDir(uploadID string) string {
+	return filepath.Join(s.root, "parts", uploadID)
+}
+
+// writeObject stores an object and its metadata. The metadata goes first so
+// an object is never visible without it.
+func (s *LocalStorage) writeObject(key string, metadata localMetadata, write func(w io.Writer) error) error {
+	objectPath, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeMetadata(key, metadata); err != nil {
+		return err
+	}
+	if err := s.writeFile(objectPath, write); err != nil {
+		os.Remove(s.metadataPath(key))
+		return err
+	}
+	return nil
+}
+
+// removeObject deletes an object and its metadata if they exist
+func (s *LocalStorage) removeObject(key string) error {
+	objectPath, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(objectPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(s.metadataPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) readMetadata(key string) (localMetadata, error) {
+	var metadata localMetadata
+	data, err := os.ReadFile(s.metadataPath(key))
+	if err != nil {
+		return metadata, err
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, err
+	}
+	return metadata, nil
+}
+
+func (s *LocalStorage) writeMetadata(key string, metadata localMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(s.metadataPath(key), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFile writes to a temporary file, syncs it and renames it over name
+func (s *LocalStorage) writeFile(name string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Join(s.root, "tmp"), "write-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// findUpload loads a multipart upload, checking it belongs to path
+func (s *LocalStorage) findUpload(path, uploadID string) (localUpload, error) {
+	var upload localUpload
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return upload, common.ErrInvalidInput
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.partsDir(uploadID), "upload.json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return upload, fmt.Errorf("no such upload %s", uploadID)
+		}
+		return upload, err
+	}
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return upload, err
+	}
+	if upload.Path != path {
+		return upload, fmt.Errorf("no such upload %s", uploadID)
+	}
+	return upload, nil
+}
+
+// listParts returns the files of an upload's parts in part number order
+func (s *LocalStorage) listParts(uploadID string) ([]string, error) {
+	entries, err := os.ReadDir(s.partsDir(uploadID))
+	if err != nil {
+		return nil, err
+	}
+
+	var numbers []int
+	for _, entry := range entries {
+		if number, err := strconv.Atoi(entry.Name()); err == nil {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+
+	parts := make([]string, len(numbers))
+	for i, number := range numbers {
+		parts[i] = filepath.Join(s.partsDir(uploadID), strconv.Itoa(number))
+	}
+	return parts, nil
+}
+
+// copyFile copies the named file to w
+func copyFile(w io.Writer, name string) (int64, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return io.Copy(w, file)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"dawhub/internal/config"
+	"dawhub/internal/domain"
+	"dawhub/internal/storage/storagetest"
+)
+
+func TestLocalStorage(t *testing.T) {
+	storagetest.RunContractTests(t, func(t *testing.T) domain.StorageService {
+		// Signed URLs point back at the store, so serve it before creating it
+		var store *LocalStorage
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			store.ServeHTTP(w, r)
+		}))
+		t.Cleanup(server.Close)
+
+		var err error
+		store, err = NewLocalStorage(config.LocalStorageConfig{
+			Root:      t.TempDir(),
+			URLSecret: "test secret",
+			BaseURL:   server.URL,
+		})
+		if err != nil {
+			t.Fatalf("NewLocalStorage: %v", err)
+		}
+		return store
+	}, corruptLocalObject)
+}
+
+// corruptLocalObject flips the first byte of an object's file
+func corruptLocalObject(t *testing.T, store domain.StorageService, path string) {
+	objectPath, err := store.(*LocalStorage).objectPath(path)
+	if err != nil {
+		t.Fatalf("objectPath: %v", err)
+	}
+	file, err := os.OpenFile(objectPath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("open %s: %v", objectPath, err)
+	}
+	defer file.Close()
+
+	b := make([]byte, 1)
+	if _, err := file.ReadAt(b, 0); err != nil {
+		t.Fatalf("read %s: %v", objectPath, err)
+	}
+	b[0] ^= 0xff
+	if _, err := file.WriteAt(b, 0); err != nil {
+		t.Fatalf("write %s: %v", objectPath, err)
+	}
+}
+
+// TestLocalCorruptedRanges checks the reads that need the whole file hashed
+// before they can start: ranges and signed URLs
+func TestLocalCorruptedRanges(t *testing.T) {
+	var store *LocalStorage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	var err error
+	store, err = NewLocalStorage(config.LocalStorageConfig{
+		Root:      t.TempDir(),
+		URLSecret: "test secret",
+		BaseURL:   server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	_, path, err := store.UploadFile("ride.wav", strings.NewReader("ride cymbal"))
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	corruptLocalObject(t, store, path)
+
+	if _, err := store.GetFileRange(path, 5, 7); !errors.Is(err, domain.ErrInvalidHash) {
+		t.Errorf("GetFileRange err = %v, want %v", err, domain.ErrInvalidHash)
+	}
+
+	url, err := store.GetDownloadURL(path)
+	if err != nil {
+		t.Fatalf("GetDownloadURL: %v", err)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("signed download status = %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+}
@@ -1,8 +1,8 @@
 package storage
 
 import (
-	"bytes"
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -10,7 +10,6 @@ import (
 	"log"
 	"path"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -25,10 +24,17 @@ const (
 	presignedURLExpiry = 24 * time.Hour
 	uploadTimeout      = 10 * time.Minute
 	downloadTimeout    = 5 * time.Minute
+
+	// streamPartSize bounds the memory used per streaming upload
+	streamPartSize = 16 * 1024 * 1024
+
+	// stagingPrefix holds uploads whose content hash is not known yet
+	stagingPrefix = "uploads/"
 )
 
 type MinioStorage struct {
 	client     *minio.Client
+	core       *minio.Core
 	bucketName string
 }
 
@@ -43,6 +49,7 @@ func NewMinioStorage(cfg config.MinioConfig) (*MinioStorage, error) {
 
 	storage := &MinioStorage{
 		client:     client,
+		core:       &minio.Core{Client: client},
 		bucketName: cfg.Bucket,
 	}
 
@@ -53,117 +60,73 @@ func NewMinioStorage(cfg config.MinioConfig) (*MinioStorage, error) {
 	return storage, nil
 }
 
-// UploadFile handles file upload and returns file info, path, and error
-func (s *MinioStorage) UploadFile(projectID uint, filename string, reader io.Reader) (domain.FileInfo, string, error) {
-	log.Printf("[DEBUG] Starting file upload - ProjectID: %d, Filename: %s", projectID, filename)
-
-	if projectID == 0 || filename == "" || reader == nil {
-		log.Printf("[ERROR] Invalid input - ProjectID: %d, Filename: %s, Reader nil: %v",
-			projectID, filename, reader == nil)
+// UploadFile streams a file to storage in a single pass, hashing and
+// size-checking it on the way, and returns file info, path, and error
+func (s *MinioStorage) UploadFile(filename string, reader io.Reader) (domain.FileInfo, string, error) {
+	if filename == "" || reader == nil {
+		log.Printf("[ERROR] Invalid input - Filename: %s, Reader nil: %v", filename, reader == nil)
 		return domain.FileInfo{}, "", common.ErrInvalidInput
 	}
 
-	// Read file into buffer for validation and upload
-	var buffer bytes.Buffer
-	tee := io.TeeReader(reader, &buffer)
-
-	// Generate metadata and validate
-	log.Printf("[DEBUG] Validating file %s", filename)
-	metadata, err := s.ValidateFile(tee, filename)
-	if err != nil {
-		log.Printf("[ERROR] File validation failed for %s: %v", filename, err)
-		return domain.FileInfo{}, "", fmt.Errorf("file validation failed: %w", err)
+	// Reject disallowed types before reading a single byte
+	contentType := determineContentType(filename)
+	if !domain.IsAllowedFileType(contentType) {
+		log.Printf("[ERROR] Invalid content type: %s", contentType)
+		return domain.FileInfo{}, "", domain.ErrInvalidFileType
 	}
-	log.Printf("[DEBUG] File validation successful - Size: %d, ContentType: %s",
-		metadata.Size, metadata.ContentType)
-
-	// Generate object name
-	objectName := s.generateObjectName(projectID, filename)
-	log.Printf("[DEBUG] Generated object name: %s", objectName)
 
 	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
 	defer cancel()
 
-	log.Printf("[DEBUG] Starting MinIO upload - Bucket: %s, Object: %s, Size: %d",
-		s.bucketName, objectName, metadata.Size)
+	// The hash (and so the final key) is only known once the stream ends, so
+	// stage the object under a temporary key first
+	hash := sha256.New()
+	body := &sizeLimitedReader{reader: io.TeeReader(reader, hash), limit: domain.MaxFileSize}
+	stagingName := stagingObjectName()
 
-	// Upload with metadata
-	_, err = s.client.PutObject(
-		ctx,
-		s.bucketName,
-		objectName,
-		&buffer,
-		metadata.Size,
-		minio.PutObjectOptions{
-			ContentType: metadata.ContentType,
-			UserMetadata: map[string]string{
-				"Filename":   metadata.Filename,
-				"Hash":       metadata.Hash,
-				"UploadedAt": metadata.UploadedAt.Format(time.RFC3339),
-			},
-		},
-	)
+	_, err := s.client.PutObject(ctx, s.bucketName, stagingName, body, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    streamPartSize,
+	})
 	if err != nil {
+		s.removeStagingObject(stagingName)
+		if body.exceeded {
+			log.Printf("[ERROR] File too large: more than %d bytes", domain.MaxFileSize)
+			return domain.FileInfo{}, "", domain.ErrFileTooLarge
+		}
 		log.Printf("[ERROR] MinIO upload failed: %v", err)
 		return domain.FileInfo{}, "", fmt.Errorf("upload failed: %w", err)
 	}
-	log.Printf("[DEBUG] MinIO upload successful")
+	defer s.removeStagingObject(stagingName)
+
+	metadata := domain.FileMetadata{
+		Size:        body.read,
+		ContentType: contentType,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		UploadedAt:  time.Now(),
+	}
 
-	// Create FileInfo from metadata
 	fileInfo := domain.FileInfo{
 		Size:        metadata.Size,
 		Filename:    filename,
 		ContentType: metadata.ContentType,
 		Hash:        metadata.Hash,
 	}
-	log.Printf("[DEBUG] Created FileInfo - Size: %d, Type: %s, Hash: %s",
-		fileInfo.Size, fileInfo.ContentType, fileInfo.Hash)
-
-	log.Printf("[INFO] File upload completed successfully - ProjectID: %d, File: %s, Path: %s",
-		projectID, filename, objectName)
-
-	return fileInfo, objectName, nil
-}
-
-func (s *MinioStorage) ValidateFile(reader io.Reader, filename string) (domain.FileMetadata, error) {
-	log.Printf("[DEBUG] Starting file validation")
-
-	var buffer bytes.Buffer
-	hash := sha256.New()
 
-	// Read file while calculating hash and size
-	log.Printf("[DEBUG] Reading file and calculating hash")
-	size, err := io.Copy(io.MultiWriter(&buffer, hash), reader)
+	objectName, err := s.promoteStagedObject(ctx, stagingName, "", metadata)
 	if err != nil {
-		log.Printf("[ERROR] Failed to read file: %v", err)
-		return domain.FileMetadata{}, fmt.Errorf("failed to process file: %w", err)
-	}
-	log.Printf("[DEBUG] File size: %d bytes", size)
-
-	// Check file size
-	if size > domain.MaxFileSize {
-		log.Printf("[ERROR] File too large: %d bytes (max: %d)", size, domain.MaxFileSize)
-		return domain.FileMetadata{}, domain.ErrFileTooLarge
+		log.Printf("[ERROR] Failed to promote staged upload: %v", err)
+		return domain.FileInfo{}, "", fmt.Errorf("upload failed: %w", err)
 	}
 
-	// Get file hash
-	fileHash := hex.EncodeToString(hash.Sum(nil))
-	log.Printf("[DEBUG] File hash: %s", fileHash)
+	log.Printf("[INFO] File upload completed successfully - File: %s, Path: %s", filename, objectName)
 
-	metadata := domain.FileMetadata{
-		Size:        size,
-		ContentType: determineContentType(filename),
-		Hash:        fileHash,
-		UploadedAt:  time.Now(),
-	}
-
-	if !domain.IsAllowedFileType(metadata.ContentType) {
-		log.Printf("[ERROR] Invalid content type: %s", metadata.ContentType)
-		return domain.FileMetadata{}, domain.ErrInvalidFileType
-	}
+	return fileInfo, objectName, nil
+}
 
-	log.Printf("[DEBUG] Validation successful - Size: %d, Type: %s", metadata.Size, metadata.ContentType)
-	return metadata, nil
+// ValidateFile hashes and size-checks a file without keeping it in memory
+func (s *MinioStorage) ValidateFile(reader io.Reader, filename string) (domain.FileMetadata, error) {
+	return validateFile(reader, filename)
 }
 
 // GetDownloadURL generates a presigned URL for file download
@@ -195,7 +158,9 @@ func (s *MinioStorage) GetDownloadURL(filepath string) (string, error) {
 	return presignedURL.String(), nil
 }
 
-// GetFile retrieves a file and its metadata
+// GetFile retrieves a file and its metadata. Like the local backend, the read
+// fails with domain.ErrInvalidHash before the end if the content doesn't
+// match the hash it was stored under.
 func (s *MinioStorage) GetFile(filepath string) (io.ReadCloser, domain.FileInfo, error) {
 	ctx := context.Background()
 
@@ -215,13 +180,32 @@ func (s *MinioStorage) GetFile(filepath string) (io.ReadCloser, domain.FileInfo,
 
 	fileInfo := domain.FileInfo{
 		Size:        objInfo.Size,
-		Filename:    objInfo.UserMetadata["Filename"],
 		ContentType: objInfo.ContentType,
 		Hash:        objInfo.UserMetadata["Hash"],
 	}
 	log.Printf("File info: %+v", fileInfo)
 
-	return obj, fileInfo, nil
+	return newHashVerifiedReader(obj, filepath, fileInfo.Hash), fileInfo, nil
+}
+
+// GetFileRange retrieves the bytes from start to end inclusive of a file
+func (s *MinioStorage) GetFileRange(filepath string, start, end int64) (io.ReadCloser, error) {
+	if filepath == "" || start < 0 || end < start {
+		return nil, common.ErrInvalidInput
+	}
+
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(start, end); err != nil {
+		return nil, fmt.Errorf("invalid range: %w", err)
+	}
+
+	obj, err := s.client.GetObject(context.Background(), s.bucketName, filepath, opts)
+	if err != nil {
+		log.Printf("Failed to get file range: %v", err)
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return obj, nil
 }
 
 // GetFileMetadata retrieves file metadata without downloading the file
@@ -238,7 +222,6 @@ func (s *MinioStorage) GetFileMetadata(filepath string) (domain.FileMetadata, er
 
 	return domain.FileMetadata{
 		Size:        objInfo.Size,
-		Filename:    objInfo.UserMetadata["Filename"],
 		ContentType: objInfo.ContentType,
 		Hash:        objInfo.UserMetadata["Hash"],
 		UploadedAt:  uploadedAt,
@@ -286,48 +269,159 @@ func (s *MinioStorage) DeleteFiles(filepaths []string) error {
 	return nil
 }
 
+// ListFiles lists every object under prefix
+func (s *MinioStorage) ListFiles(prefix string) ([]domain.StoredObject, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	var objects []domain.StoredObject
+	for obj := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", obj.Err)
+		}
+		objects = append(objects, domain.StoredObject{
+			Path:         obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
 // ValidateFiles validates multiple files in parallel
 func (s *MinioStorage) ValidateFiles(files map[string]io.Reader) (map[string]domain.FileMetadata, error) {
-	if len(files) == 0 {
-		return nil, nil
-	}
+	return validateFiles(files)
+}
 
-	results := make(map[string]domain.FileMetadata)
-	errors := make([]error, 0)
-	var mutex sync.Mutex
-	var wg sync.WaitGroup
+// ImportFile stores content whose metadata is already known, such as a file
+// restored from a backup, under its content-addressed key. Content that
+// doesn't match the expected size and hash is rejected.
+func (s *MinioStorage) ImportFile(expected domain.FileMetadata, reader io.Reader) (string, error) {
+	if expected.Hash == "" || reader == nil {
+		return "", common.ErrInvalidInput
+	}
 
-	for filename, reader := range files {
-		wg.Add(1)
-		go func(fname string, r io.Reader) {
-			defer wg.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
 
-			metadata, err := s.ValidateFile(r, filename)
-			mutex.Lock()
-			defer mutex.Unlock()
+	hash := sha256.New()
+	stagingName := stagingObjectName()
+	info, err := s.client.PutObject(ctx, s.bucketName, stagingName, io.TeeReader(reader, hash), expected.Size, minio.PutObjectOptions{
+		ContentType: expected.ContentType,
+		PartSize:    streamPartSize,
+	})
+	defer s.removeStagingObject(stagingName)
+	if err != nil {
+		return "", fmt.Errorf("import failed: %w", err)
+	}
 
-			if err != nil {
-				errors = append(errors, fmt.Errorf("failed to validate %s: %w", fname, err))
-				return
-			}
+	if info.Size != expected.Size {
+		return "", domain.ErrUploadSizeMismatch
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != strings.ToLower(expected.Hash) {
+		log.Printf("[ERROR] Imported file hash mismatch - Expected: %s, Actual: %s", expected.Hash, actual)
+		return "", domain.ErrInvalidHash
+	}
 
-			metadata.Filename = fname
-			results[fname] = metadata
-		}(filename, reader)
+	metadata := expected
+	metadata.Hash = strings.ToLower(expected.Hash)
+	if metadata.UploadedAt.IsZero() {
+		metadata.UploadedAt = time.Now()
+	}
+	objectName, err := s.promoteStagedObject(ctx, stagingName, "", metadata)
+	if err != nil {
+		return "", fmt.Errorf("import failed: %w", err)
 	}
+	return objectName, nil
+}
 
-	wg.Wait()
+// blobObjectName derives an object key from a file's SHA-256. User-facing
+// filenames live only in the database.
+func blobObjectName(hash string) string {
+	return fmt.Sprintf("blobs/%s/%s", hash[:2], hash)
+}
 
-	if len(errors) > 0 {
-		return nil, fmt.Errorf("validation errors: %v", errors)
+// stagingObjectName returns a temporary key for an upload in progress.
+// Staged objects that are never promoted are collected by the garbage collector.
+func stagingObjectName() string {
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s%d-%s", stagingPrefix, time.Now().UnixNano(), hex.EncodeToString(suffix))
+}
+
+// promoteStagedObject moves a staged upload to its content-addressed key and
+// returns that key. Identical content is stored once, so an existing blob wins.
+// A non-empty etag makes the copy fail if the staged object has changed since
+// it was checked.
+func (s *MinioStorage) promoteStagedObject(ctx context.Context, stagingName, etag string, metadata domain.FileMetadata) (string, error) {
+	objectName := blobObjectName(metadata.Hash)
+	if info, err := s.client.StatObject(ctx, s.bucketName, objectName, minio.StatObjectOptions{}); err == nil {
+		// Refresh the modification time so the garbage collector's grace
+		// period covers the upload about to reference the blob. If the blob
+		// was collected since the stat, fall through and store it again.
+		if err := s.touchObject(ctx, info); err == nil {
+			log.Printf("[INFO] Blob already stored, skipping copy - Path: %s", objectName)
+			return objectName, nil
+		}
+	}
+
+	// Copy server-side; no data passes through the app
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{
+			Bucket:          s.bucketName,
+			Object:          objectName,
+			ReplaceMetadata: true,
+			UserMetadata: map[string]string{
+				"Content-Type": metadata.ContentType,
+				"Hash":         metadata.Hash,
+				"UploadedAt":   metadata.UploadedAt.Format(time.RFC3339),
+			},
+		},
+		minio.CopySrcOptions{Bucket: s.bucketName, Object: stagingName, MatchETag: etag},
+	)
+	if err != nil {
+		return "", err
 	}
 
-	return results, nil
+	return objectName, nil
 }
 
-// Helper functions remain the same
-func (s *MinioStorage) generateObjectName(projectID uint, filename string) string {
-	return fmt.Sprintf("projects/%d/%s", projectID, sanitizeFilename(filename))
+// touchObject copies an object onto itself with its metadata unchanged,
+// which updates its modification time
+func (s *MinioStorage) touchObject(ctx context.Context, info minio.ObjectInfo) error {
+	userMetadata := make(map[string]string, len(info.UserMetadata)+1)
+	for key, value := range info.UserMetadata {
+		userMetadata[key] = value
+	}
+	userMetadata["Content-Type"] = info.ContentType
+
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{
+			Bucket:          s.bucketName,
+			Object:          info.Key,
+			ReplaceMetadata: true,
+			UserMetadata:    userMetadata,
+		},
+		minio.CopySrcOptions{Bucket: s.bucketName, Object: info.Key, MatchETag: info.ETag},
+	)
+	return err
+}
+
+// removeStagingObject deletes a staged upload, aborting any partial multipart state
+func (s *MinioStorage) removeStagingObject(objectName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	if err := s.client.RemoveIncompleteUpload(ctx, s.bucketName, objectName); err != nil {
+		log.Printf("[WARN] Failed to abort staged upload %s: %v", objectName, err)
+	}
+	if err := s.client.RemoveObject(ctx, s.bucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+		log.Printf("[WARN] Failed to remove staged upload %s: %v", objectName, err)
+	}
 }
 
 func (s *MinioStorage) ensureBucket() error {
@@ -349,10 +443,6 @@ func (s *MinioStorage) ensureBucket() error {
 	return nil
 }
 
-func sanitizeFilename(filename string) string {
-	return path.Base(path.Clean(filename))
-}
-
 func determineContentType(filename string) string {
 	ext := strings.ToLower(path.Ext(filename))
 	switch ext {
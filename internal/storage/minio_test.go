@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"dawhub/internal/config"
+	"dawhub/internal/domain"
+	"dawhub/internal/storage/storagetest"
+)
+
+// TestMinioStorage runs the contract against a real S3-compatible server. It
+// is skipped unless MINIO_TEST_ENDPOINT, MINIO_TEST_ACCESS_KEY and
+// MINIO_TEST_SECRET_KEY are set; each test gets its own bucket, which is
+// emptied and removed afterwards.
+func TestMinioStorage(t *testing.T) {
+	cfg := config.MinioConfig{
+		Endpoint:  os.Getenv("MINIO_TEST_ENDPOINT"),
+		AccessKey: os.Getenv("MINIO_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("MINIO_TEST_SECRET_KEY"),
+		UseSSL:    os.Getenv("MINIO_TEST_USE_SSL") == "true",
+	}
+	if cfg.Endpoint == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		t.Skip("MINIO_TEST_ENDPOINT, MINIO_TEST_ACCESS_KEY and MINIO_TEST_SECRET_KEY are not set")
+	}
+
+	storagetest.RunContractTests(t, func(t *testing.T) domain.StorageService {
+		cfg := cfg
+		cfg.Bucket = fmt.Sprintf("dawhub-test-%d", time.Now().UnixNano())
+		store, err := NewMinioStorage(cfg)
+		if err != nil {
+			t.Fatalf("NewMinioStorage: %v", err)
+		}
+		t.Cleanup(func() { removeTestBucket(t, store) })
+		return store
+	}, corruptMinioObject)
+}
+
+// corruptMinioObject rewrites an object with its first byte flipped, keeping
+// the metadata it was stored with
+func corruptMinioObject(t *testing.T, store domain.StorageService, path string) {
+	s := store.(*MinioStorage)
+	ctx := context.Background()
+	obj, err := s.client.GetObject(ctx, s.bucketName, path, minio.GetObjectOptions{})
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	content, err := io.ReadAll(obj)
+	obj.Close()
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	info, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{})
+	if err != nil {
+		t.Fatalf("StatObject: %v", err)
+	}
+
+	content[0] ^= 0xff
+	_, err = s.client.PutObject(ctx, s.bucketName, path, bytes.NewReader(content), int64(len(content)), minio.PutObjectOptions{
+		ContentType:  info.ContentType,
+		UserMetadata: info.UserMetadata,
+	})
+	if err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+}
+
+// removeTestBucket deletes everything a test left in its bucket, including
+// unfinished multipart uploads, then the bucket itself
+func removeTestBucket(t *testing.T, store *MinioStorage) {
+	ctx := context.Background()
+	for upload := range store.client.ListIncompleteUploads(ctx, store.bucketName, "", true) {
+		if upload.Err == nil {
+			store.client.RemoveIncompleteUpload(ctx, store.bucketName, upload.Key)
+		}
+	}
+	objects, err := store.ListFiles("")
+	if err != nil {
+		t.Errorf("failed to list test bucket: %v", err)
+		return
+	}
+	paths := make([]string, len(objects))
+	for i, obj := range objects {
+		paths[i] = obj.Path
+	}
+	if err := store.DeleteFiles(paths); err != nil {
+		t.Errorf("failed to empty test bucket: %v", err)
+		return
+	}
+	if err := store.client.RemoveBucket(ctx, store.bucketName); err != nil {
+		t.Errorf("failed to remove test bucket: %v", err)
+	}
+}
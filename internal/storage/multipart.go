@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// StartMultipartUpload opens a multipart upload for filename under a fresh
+// staging key
+func (s *MinioStorage) StartMultipartUpload(filename string) (domain.MultipartUpload, error) {
+	contentType := determineContentType(filename)
+	if !domain.IsAllowedFileType(contentType) {
+		log.Printf("[ERROR] Invalid content type: %s", contentType)
+		return domain.MultipartUpload{}, domain.ErrInvalidFileType
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	stagingName := stagingObjectName()
+	uploadID, err := s.core.NewMultipartUpload(ctx, s.bucketName, stagingName, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return domain.MultipartUpload{}, fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+
+	return domain.MultipartUpload{
+		Path:        stagingName,
+		UploadID:    uploadID,
+		ContentType: contentType,
+	}, nil
+}
+
+// UploadPart streams one part of a multipart upload. Retrying a part number
+// replaces the previous attempt.
+func (s *MinioStorage) UploadPart(path, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	if path == "" || uploadID == "" || partNumber <= 0 || reader == nil {
+		return common.ErrInvalidInput
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	if _, err := s.core.PutObjectPart(ctx, s.bucketName, path, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{}); err != nil {
+		return fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+
+	return nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts, checks the result
+// against metadata and promotes it to its content-addressed key
+func (s *MinioStorage) CompleteMultipartUpload(path, uploadID string, metadata domain.FileMetadata) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	var parts []minio.CompletePart
+	marker := 0
+	for {
+		result, err := s.core.ListObjectParts(ctx, s.bucketName, path, uploadID, marker, 1000)
+		if err != nil {
+			return "", fmt.Errorf("failed to list parts: %w", err)
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	if _, err := s.core.CompleteMultipartUpload(ctx, s.bucketName, path, uploadID, parts, minio.PutObjectOptions{
+		ContentType: metadata.ContentType,
+	}); err != nil {
+		return "", fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	defer s.removeStagingObject(path)
+
+	// Parts are stored before the session records them, so a part replaced
+	// by a concurrent retry may not match the saved hash state. Hash what was
+	// actually assembled; the key is derived from it.
+	obj, err := s.client.GetObject(ctx, s.bucketName, path, minio.GetObjectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to read assembled upload: %w", err)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, obj)
+	obj.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read assembled upload: %w", err)
+	}
+	if size != metadata.Size {
+		log.Printf("[ERROR] Assembled upload size mismatch - Path: %s, Expected: %d, Actual: %d",
+			path, metadata.Size, size)
+		return "", domain.ErrUploadSizeMismatch
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != strings.ToLower(metadata.Hash) {
+		log.Printf("[ERROR] Assembled upload hash mismatch - Path: %s, Expected: %s, Actual: %s",
+			path, metadata.Hash, actual)
+		return "", domain.ErrInvalidHash
+	}
+	metadata.Hash = strings.ToLower(metadata.Hash)
+
+	objectName, err := s.promoteStagedObject(ctx, path, "", metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to promote upload: %w", err)
+	}
+
+	return objectName, nil
+}
+
+// AbortMultipartUpload discards an unfinished multipart upload and its parts
+func (s *MinioStorage) AbortMultipartUpload(path, uploadID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	if err := s.core.AbortMultipartUpload(ctx, s.bucketName, path, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
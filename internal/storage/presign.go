@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"dawhub/internal/domain"
+	"dawhub/pkg/common"
+)
+
+// maxPresignExpiry is the longest validity S3 accepts for a presigned URL
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// PresignUpload issues a URL the client can PUT filename to directly, under a
+// fresh staging key, so the bytes never pass through the app
+func (s *MinioStorage) PresignUpload(filename string, expiry time.Duration) (domain.PresignedUpload, error) {
+	if filename == "" || expiry <= 0 {
+		return domain.PresignedUpload{}, common.ErrInvalidInput
+	}
+
+	contentType := determineContentType(filename)
+	if !domain.IsAllowedFileType(contentType) {
+		log.Printf("[ERROR] Invalid content type: %s", contentType)
+		return domain.PresignedUpload{}, domain.ErrInvalidFileType
+	}
+
+	if expiry > maxPresignExpiry {
+		expiry = maxPresignExpiry
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	stagingName := stagingObjectName()
+	presignedURL, err := s.client.PresignedPutObject(ctx, s.bucketName, stagingName, expiry)
+	if err != nil {
+		return domain.PresignedUpload{}, fmt.Errorf("failed to generate upload URL: %w", err)
+	}
+
+	return domain.PresignedUpload{
+		Path:        stagingName,
+		URL:         presignedURL.String(),
+		ContentType: contentType,
+		ExpiresAt:   time.Now().Add(expiry),
+	}, nil
+}
+
+// FinalizeUpload checks an object the client uploaded directly against the
+// expected size, content type and hash, then promotes it to its
+// content-addressed key. Objects failing a check are removed.
+func (s *MinioStorage) FinalizeUpload(path string, expected domain.FileMetadata) (string, error) {
+	if path == "" || expected.Hash == "" {
+		return "", common.ErrInvalidInput
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	info, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return "", domain.ErrFileNotFound
+		}
+		return "", fmt.Errorf("failed to stat upload: %w", err)
+	}
+	defer s.removeStagingObject(path)
+
+	switch {
+	case info.Size > domain.MaxFileSize:
+		log.Printf("[ERROR] File too large: %d bytes", info.Size)
+		return "", domain.ErrFileTooLarge
+	case info.Size != expected.Size:
+		log.Printf("[ERROR] Direct upload size mismatch - Path: %s, Expected: %d, Actual: %d",
+			path, expected.Size, info.Size)
+		return "", domain.ErrUploadSizeMismatch
+	case !domain.IsAllowedFileType(info.ContentType) || info.ContentType != expected.ContentType:
+		log.Printf("[ERROR] Invalid content type: %s", info.ContentType)
+		return "", domain.ErrInvalidFileType
+	}
+
+	// The bucket cannot hash for us, so read the object back once server-side.
+	// The client can still write to the key, so the read and the promotion
+	// are pinned to the version that was checked.
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetMatchETag(info.ETag); err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	obj, err := s.client.GetObject(ctx, s.bucketName, path, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	defer obj.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, &sizeLimitedReader{reader: obj, limit: domain.MaxFileSize}); err != nil {
+		if minio.ToErrorResponse(err).Code == "PreconditionFailed" {
+			log.Printf("[ERROR] Direct upload replaced while finalizing - Path: %s", path)
+			return "", domain.ErrInvalidHash
+		}
+		return "", fmt.Errorf("failed to hash upload: %w", err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != strings.ToLower(expected.Hash) {
+		log.Printf("[ERROR] Direct upload hash mismatch - Path: %s, Expected: %s, Actual: %s",
+			path, expected.Hash, actual)
+		return "", domain.ErrInvalidHash
+	}
+
+	metadata := expected
+	metadata.Hash = strings.ToLower(expected.Hash)
+	objectName, err := s.promoteStagedObject(ctx, path, info.ETag, metadata)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "PreconditionFailed" {
+			log.Printf("[ERROR] Direct upload replaced while finalizing - Path: %s", path)
+			return "", domain.ErrInvalidHash
+		}
+		return "", fmt.Errorf("failed to promote upload: %w", err)
+	}
+
+	log.Printf("[INFO] Direct upload finalized - Path: %s", objectName)
+	return objectName, nil
+}
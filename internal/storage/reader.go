@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"os"
+
+	"dawhub/internal/domain"
+)
+
+// sizeLimitedReader counts bytes as they pass and fails once more than limit
+// bytes have been read, so oversized uploads are rejected mid-stream
+type sizeLimitedReader struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		r.exceeded = true
+		return n, domain.ErrFileTooLarge
+	}
+	return n, err
+}
+
+// verifyChunkSize is how much of a file hashVerifiedReader reads at a time,
+// and so the most it holds back until the hash is checked
+const verifyChunkSize = 32 * 1024
+
+// hashVerifiedReader hashes a file as it is read. The last chunk is held back
+// until the whole file has been hashed; if the content does not match the
+// expected hash it is never returned and the read fails with
+// domain.ErrInvalidHash, so a damaged file can't be received in full.
+type hashVerifiedReader struct {
+	file     io.ReadCloser
+	name     string
+	hash     hash.Hash
+	expected string
+
+	bufs  [2][]byte
+	cur   int    // Index of the buffer holding back the latest chunk
+	held  int    // Bytes held back in bufs[cur]
+	ready []byte // Bytes released to the caller
+	tail  []byte // The final chunk, released once ready is drained
+	err   error  // Returned once everything released has been read
+}
+
+// newHashVerifiedReader verifies file against expected, a hex SHA-256. An
+// empty expected hash disables the check.
+func newHashVerifiedReader(file io.ReadCloser, name, expected string) *hashVerifiedReader {
+	return &hashVerifiedReader{file: file, name: name, hash: sha256.New(), expected: expected}
+}
+
+func (r *hashVerifiedReader) Read(p []byte) (int, error) {
+	for len(r.ready) == 0 && r.err == nil {
+		r.advance()
+	}
+	if len(r.ready) == 0 && len(r.tail) > 0 {
+		r.ready, r.tail = r.tail, nil
+	}
+	if len(r.ready) > 0 {
+		n := copy(p, r.ready)
+		r.ready = r.ready[n:]
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// advance reads the next chunk, releasing the one held back before it. At
+// the end of the file the hash decides whether the held chunks are released.
+func (r *hashVerifiedReader) advance() {
+	next := 1 - r.cur
+	if r.bufs[next] == nil {
+		r.bufs[next] = make([]byte, verifyChunkSize)
+	}
+	n, err := io.ReadFull(r.file, r.bufs[next])
+	r.hash.Write(r.bufs[next][:n])
+
+	switch err {
+	case nil:
+		r.ready = r.bufs[r.cur][:r.held]
+		r.cur, r.held = next, n
+	case io.EOF, io.ErrUnexpectedEOF:
+		if r.expected != "" && hex.EncodeToString(r.hash.Sum(nil)) != r.expected {
+			log.Printf("[ERROR] Stored file does not match its hash - Path: %s", r.name)
+			r.err = domain.ErrInvalidHash
+			return
+		}
+		r.ready = r.bufs[r.cur][:r.held]
+		r.tail = r.bufs[next][:n]
+		r.err = io.EOF
+	default:
+		r.err = err
+	}
+}
+
+func (r *hashVerifiedReader) Close() error {
+	return r.file.Close()
+}
+
+// verifyFile hashes an open file from the start and leaves it rewound, so
+// readers that need to seek can be handed a file known to be intact
+func verifyFile(file *os.File, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != expected {
+		log.Printf("[ERROR] Stored file does not match its hash - Path: %s", file.Name())
+		return domain.ErrInvalidHash
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return err
+}
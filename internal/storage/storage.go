@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+
+	"dawhub/internal/config"
+	"dawhub/internal/domain"
+)
+
+// New creates the storage backend selected by cfg.Storage.Backend. The local
+// backend also returns the handler serving its signed URLs, to be mounted at
+// LocalFilesPath; other backends serve their own.
+func New(cfg *config.Config) (domain.StorageService, http.Handler, error) {
+	switch cfg.Storage.Backend {
+	case "minio", "":
+		store, err := NewMinioStorage(cfg.Minio)
+		return store, nil, err
+	case "local":
+		store, err := NewLocalStorage(cfg.Storage.Local)
+		return store, store, err
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
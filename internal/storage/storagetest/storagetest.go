@@ -0,0 +1,601 @@
+// Package storagetest holds the behavioral contract every
+// domain.StorageService backend must meet. A backend's tests call
+// RunContractTests with a constructor for a fresh, empty store and a way to
+// damage what it stores:
+//
+//	func TestLocalStorage(t *testing.T) {
+//		storagetest.RunContractTests(t, func(t *testing.T) domain.StorageService {
+//			...
+//		}, corruptLocalObject)
+//	}
+//
+// URLs returned by GetDownloadURL and PresignUpload must be absolute so the
+// suite can fetch them.
+package storagetest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"dawhub/internal/domain"
+)
+
+// minPartSize is the smallest part S3-compatible stores accept for all but
+// the last part of a multipart upload
+const minPartSize = 5 * 1024 * 1024
+
+// Corrupt changes one byte of a stored object behind the store's back,
+// leaving its size and recorded hash alone, as damage on disk would
+type Corrupt func(t *testing.T, store domain.StorageService, path string)
+
+// RunContractTests runs the storage contract against stores made by newStorage
+func RunContractTests(t *testing.T, newStorage func(t *testing.T) domain.StorageService, corrupt Corrupt) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, store domain.StorageService)
+	}{
+		{"UploadIsContentAddressed", testUploadIsContentAddressed},
+		{"GetFile", testGetFile},
+		{"GetFileRange", testGetFileRange},
+		{"CorruptedFile", func(t *testing.T, store domain.StorageService) { testCorruptedFile(t, store, corrupt) }},
+		{"GetFileMetadata", testGetFileMetadata},
+		{"MissingFile", testMissingFile},
+		{"DownloadURL", testDownloadURL},
+		{"ListFiles", testListFiles},
+		{"DeleteFiles", testDeleteFiles},
+		{"ValidateFile", testValidateFile},
+		{"MultipartUpload", testMultipartUpload},
+		{"MultipartUploadSizeMismatch", testMultipartUploadSizeMismatch},
+		{"MultipartUploadHashMismatch", testMultipartUploadHashMismatch},
+		{"AbortMultipartUpload", testAbortMultipartUpload},
+		{"DirectUpload", testDirectUpload},
+		{"DirectUploadHashMismatch", testDirectUploadHashMismatch},
+		{"FinalizeMissingUpload", testFinalizeMissingUpload},
+		{"ImportFile", testImportFile},
+		{"ImportFileMismatch", testImportFileMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStorage(t))
+		})
+	}
+}
+
+func testUploadIsContentAddressed(t *testing.T, store domain.StorageService) {
+	content := []byte("kick drum")
+	info, path := upload(t, store, "kick.wav", content)
+
+	if info.Hash != hashOf(content) {
+		t.Errorf("hash = %q, want %q", info.Hash, hashOf(content))
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("size = %d, want %d", info.Size, len(content))
+	}
+	if info.ContentType != "audio/wav" {
+		t.Errorf("content type = %q, want audio/wav", info.ContentType)
+	}
+	if want := blobPath(content); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+
+	// The same content under another name is stored once
+	_, again := upload(t, store, "copy.wav", content)
+	if again != path {
+		t.Errorf("second upload path = %q, want %q", again, path)
+	}
+
+	// Nothing is left staged
+	staged, err := store.ListFiles("uploads/")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(staged) != 0 {
+		t.Errorf("staged objects left behind: %v", staged)
+	}
+}
+
+func testGetFile(t *testing.T, store domain.StorageService) {
+	content := []byte("snare hit")
+	_, path := upload(t, store, "snare.wav", content)
+
+	reader, info, err := store.GetFile(path)
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+	defer reader.Close()
+
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("content = %q, want %q", got, content)
+	}
+	if info.Size != int64(len(content)) || info.Hash != hashOf(content) || info.ContentType != "audio/wav" {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func testGetFileRange(t *testing.T, store domain.StorageService) {
+	content := []byte("0123456789")
+	_, path := upload(t, store, "digits.wav", content)
+
+	reader, err := store.GetFileRange(path, 2, 5)
+	if err != nil {
+		t.Fatalf("GetFileRange: %v", err)
+	}
+	defer reader.Close()
+
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "2345" {
+		t.Errorf("range = %q, want %q", got, "2345")
+	}
+
+	if _, err := store.GetFileRange(path, 5, 2); err == nil {
+		t.Error("inverted range: want an error")
+	}
+}
+
+func testCorruptedFile(t *testing.T, store domain.StorageService, corrupt Corrupt) {
+	// Several read chunks, so a reader that only checks at the end would
+	// have handed most of it over already
+	content := bytes.Repeat([]byte("hi-hat "), 20000)
+	_, path := upload(t, store, "hat.wav", content)
+	corrupt(t, store, path)
+
+	reader, _, err := store.GetFile(path)
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+	defer reader.Close()
+
+	got, err := io.ReadAll(reader)
+	if !errors.Is(err, domain.ErrInvalidHash) {
+		t.Fatalf("err = %v, want %v", err, domain.ErrInvalidHash)
+	}
+	if len(got) >= len(content) {
+		t.Errorf("read all %d bytes of a damaged file before failing", len(got))
+	}
+}
+
+func testGetFileMetadata(t *testing.T, store domain.StorageService) {
+	content := []byte("pad")
+	before := time.Now().Add(-time.Minute)
+	_, path := upload(t, store, "pad.ogg", content)
+
+	metadata, err := store.GetFileMetadata(path)
+	if err != nil {
+		t.Fatalf("GetFileMetadata: %v", err)
+	}
+	if metadata.Size != int64(len(content)) {
+		t.Errorf("size = %d, want %d", metadata.Size, len(content))
+	}
+	if metadata.Hash != hashOf(content) {
+		t.Errorf("hash = %q, want %q", metadata.Hash, hashOf(content))
+	}
+	if metadata.ContentType != "audio/ogg" {
+		t.Errorf("content type = %q, want audio/ogg", metadata.ContentType)
+	}
+	if metadata.UploadedAt.Before(before) {
+		t.Errorf("uploaded at = %v, want after %v", metadata.UploadedAt, before)
+	}
+}
+
+func testMissingFile(t *testing.T, store domain.StorageService) {
+	path := blobPath([]byte("never stored"))
+
+	if _, err := store.GetFileMetadata(path); err == nil {
+		t.Error("GetFileMetadata: want an error")
+	}
+	if _, err := store.GetDownloadURL(path); err == nil {
+		t.Error("GetDownloadURL: want an error")
+	}
+	if reader, _, err := store.GetFile(path); err == nil {
+		// Some stores only fail on the first read
+		if _, err := io.ReadAll(reader); err == nil {
+			t.Error("GetFile: want an error")
+		}
+		reader.Close()
+	}
+	if err := store.DeleteFile(path); err != nil {
+		t.Errorf("DeleteFile: %v, want no error", err)
+	}
+}
+
+func testDownloadURL(t *testing.T, store domain.StorageService) {
+	content := []byte("hi-hat")
+	_, path := upload(t, store, "hat.wav", content)
+
+	url, err := store.GetDownloadURL(path)
+	if err != nil {
+		t.Fatalf("GetDownloadURL: %v", err)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(got, content) {
+		t.Errorf("GET download URL = %d %q, want 200 %q", resp.StatusCode, got, content)
+	}
+
+	// The URL grants that file only
+	other := strings.Replace(url, path, blobPath([]byte("other")), 1)
+	resp, err = http.Get(other)
+	if err != nil {
+		t.Fatalf("GET %s: %v", other, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("download URL with another path: want a failure")
+	}
+}
+
+func testListFiles(t *testing.T, store domain.StorageService) {
+	first := []byte("first")
+	second := []byte("second one")
+	_, firstPath := upload(t, store, "first.wav", first)
+	_, secondPath := upload(t, store, "second.wav", second)
+
+	objects, err := store.ListFiles("blobs/")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	sizes := make(map[string]int64)
+	for _, obj := range objects {
+		sizes[obj.Path] = obj.Size
+		if obj.LastModified.IsZero() {
+			t.Errorf("%s: zero last modified", obj.Path)
+		}
+	}
+	if len(sizes) != 2 || sizes[firstPath] != int64(len(first)) || sizes[secondPath] != int64(len(second)) {
+		t.Errorf("listed %v", objects)
+	}
+
+	shard := firstPath[:len("blobs/xx/")]
+	objects, err = store.ListFiles(shard)
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	found := false
+	for _, obj := range objects {
+		found = found || obj.Path == firstPath
+		if !strings.HasPrefix(obj.Path, shard) {
+			t.Errorf("listed %s outside %s", obj.Path, shard)
+		}
+	}
+	if !found {
+		t.Errorf("%s not listed under %s", firstPath, shard)
+	}
+}
+
+func testDeleteFiles(t *testing.T, store domain.StorageService) {
+	_, first := upload(t, store, "a.wav", []byte("a"))
+	_, second := upload(t, store, "b.wav", []byte("b"))
+	_, kept := upload(t, store, "c.wav", []byte("c"))
+
+	if err := store.DeleteFile(first); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if err := store.DeleteFiles([]string{second}); err != nil {
+		t.Fatalf("DeleteFiles: %v", err)
+	}
+	if err := store.DeleteFiles(nil); err != nil {
+		t.Fatalf("DeleteFiles(nil): %v", err)
+	}
+
+	for _, path := range []string{first, second} {
+		if _, err := store.GetFileMetadata(path); err == nil {
+			t.Errorf("%s still stored", path)
+		}
+	}
+	if _, err := store.GetFileMetadata(kept); err != nil {
+		t.Errorf("%s: %v", kept, err)
+	}
+}
+
+func testValidateFile(t *testing.T, store domain.StorageService) {
+	content := []byte("validate me")
+	metadata, err := store.ValidateFile(bytes.NewReader(content), "song.mp3")
+	if err != nil {
+		t.Fatalf("ValidateFile: %v", err)
+	}
+	if metadata.Size != int64(len(content)) || metadata.Hash != hashOf(content) || metadata.ContentType != "audio/mpeg" {
+		t.Errorf("metadata = %+v", metadata)
+	}
+
+	// Validation stores nothing
+	objects, err := store.ListFiles("")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("stored %v", objects)
+	}
+}
+
+func testMultipartUpload(t *testing.T, store domain.StorageService) {
+	first := bytes.Repeat([]byte("a"), minPartSize)
+	second := []byte("tail")
+	content := append(append([]byte{}, first...), second...)
+
+	upload, err := store.StartMultipartUpload("take.wav")
+	if err != nil {
+		t.Fatalf("StartMultipartUpload: %v", err)
+	}
+	if upload.ContentType != "audio/wav" {
+		t.Errorf("content type = %q, want audio/wav", upload.ContentType)
+	}
+
+	if err := store.UploadPart(upload.Path, upload.UploadID, 1, bytes.NewReader(first), int64(len(first))); err != nil {
+		t.Fatalf("UploadPart 1: %v", err)
+	}
+	// A retried part replaces the earlier attempt
+	if err := store.UploadPart(upload.Path, upload.UploadID, 2, strings.NewReader("oops"), 4); err != nil {
+		t.Fatalf("UploadPart 2: %v", err)
+	}
+	if err := store.UploadPart(upload.Path, upload.UploadID, 2, bytes.NewReader(second), int64(len(second))); err != nil {
+		t.Fatalf("UploadPart 2 retry: %v", err)
+	}
+
+	path, err := store.CompleteMultipartUpload(upload.Path, upload.UploadID, metadataOf("take.wav", content))
+	if err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+	if want := blobPath(content); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	assertContent(t, store, path, content)
+}
+
+func testMultipartUploadSizeMismatch(t *testing.T, store domain.StorageService) {
+	content := []byte("short")
+	upload, err := store.StartMultipartUpload("take.wav")
+	if err != nil {
+		t.Fatalf("StartMultipartUpload: %v", err)
+	}
+	if err := store.UploadPart(upload.Path, upload.UploadID, 1, bytes.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+
+	metadata := metadataOf("take.wav", content)
+	metadata.Size++
+	if _, err := store.CompleteMultipartUpload(upload.Path, upload.UploadID, metadata); !errors.Is(err, domain.ErrUploadSizeMismatch) {
+		t.Errorf("err = %v, want %v", err, domain.ErrUploadSizeMismatch)
+	}
+	if _, err := store.GetFileMetadata(blobPath(content)); err == nil {
+		t.Error("mismatched upload was stored")
+	}
+}
+
+func testMultipartUploadHashMismatch(t *testing.T, store domain.StorageService) {
+	content := []byte("the take that was sent")
+	upload, err := store.StartMultipartUpload("take.wav")
+	if err != nil {
+		t.Fatalf("StartMultipartUpload: %v", err)
+	}
+	if err := store.UploadPart(upload.Path, upload.UploadID, 1, bytes.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+
+	// Same size, different content: the hash the caller recorded does not
+	// match what was assembled
+	claimed := []byte("the take that was kept")
+	metadata := metadataOf("take.wav", claimed)
+	if _, err := store.CompleteMultipartUpload(upload.Path, upload.UploadID, metadata); !errors.Is(err, domain.ErrInvalidHash) {
+		t.Errorf("err = %v, want %v", err, domain.ErrInvalidHash)
+	}
+	for _, path := range []string{blobPath(content), blobPath(claimed)} {
+		if _, err := store.GetFileMetadata(path); err == nil {
+			t.Errorf("mismatched upload was stored at %s", path)
+		}
+	}
+}
+
+func testAbortMultipartUpload(t *testing.T, store domain.StorageService) {
+	upload, err := store.StartMultipartUpload("take.wav")
+	if err != nil {
+		t.Fatalf("StartMultipartUpload: %v", err)
+	}
+	if err := store.UploadPart(upload.Path, upload.UploadID, 1, strings.NewReader("part"), 4); err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+
+	if err := store.AbortMultipartUpload(upload.Path, upload.UploadID); err != nil {
+		t.Fatalf("AbortMultipartUpload: %v", err)
+	}
+	if err := store.UploadPart(upload.Path, upload.UploadID, 2, strings.NewReader("late"), 4); err == nil {
+		t.Error("UploadPart after abort: want an error")
+	}
+}
+
+func testDirectUpload(t *testing.T, store domain.StorageService) {
+	content := []byte("direct upload")
+	presigned, err := store.PresignUpload("direct.wav", time.Hour)
+	if err != nil {
+		t.Fatalf("PresignUpload: %v", err)
+	}
+	if presigned.ContentType != "audio/wav" || !presigned.ExpiresAt.After(time.Now()) {
+		t.Errorf("presigned = %+v", presigned)
+	}
+
+	put(t, presigned, content)
+
+	path, err := store.FinalizeUpload(presigned.Path, metadataOf("direct.wav", content))
+	if err != nil {
+		t.Fatalf("FinalizeUpload: %v", err)
+	}
+	if want := blobPath(content); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	assertContent(t, store, path, content)
+
+	if _, err := store.GetFileMetadata(presigned.Path); err == nil {
+		t.Error("staged upload left behind")
+	}
+	objects, err := store.ListFiles("")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.Path, "blobs/") {
+			t.Errorf("working copy left behind: %s", obj.Path)
+		}
+	}
+}
+
+func testDirectUploadHashMismatch(t *testing.T, store domain.StorageService) {
+	content := []byte("direct upload")
+	presigned, err := store.PresignUpload("direct.wav", time.Hour)
+	if err != nil {
+		t.Fatalf("PresignUpload: %v", err)
+	}
+	put(t, presigned, content)
+
+	metadata := metadataOf("direct.wav", content)
+	metadata.Hash = hashOf([]byte("something else"))
+	if _, err := store.FinalizeUpload(presigned.Path, metadata); !errors.Is(err, domain.ErrInvalidHash) {
+		t.Errorf("err = %v, want %v", err, domain.ErrInvalidHash)
+	}
+	if _, err := store.GetFileMetadata(presigned.Path); err == nil {
+		t.Error("rejected upload left behind")
+	}
+}
+
+func testFinalizeMissingUpload(t *testing.T, store domain.StorageService) {
+	presigned, err := store.PresignUpload("direct.wav", time.Hour)
+	if err != nil {
+		t.Fatalf("PresignUpload: %v", err)
+	}
+
+	content := []byte("never sent")
+	if _, err := store.FinalizeUpload(presigned.Path, metadataOf("direct.wav", content)); !errors.Is(err, domain.ErrFileNotFound) {
+		t.Errorf("err = %v, want %v", err, domain.ErrFileNotFound)
+	}
+}
+
+func testImportFile(t *testing.T, store domain.StorageService) {
+	content := []byte("restored from a backup")
+	path, err := store.ImportFile(metadataOf("restored.wav", content), bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("ImportFile: %v", err)
+	}
+	if want := blobPath(content); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	assertContent(t, store, path, content)
+
+	metadata, err := store.GetFileMetadata(path)
+	if err != nil {
+		t.Fatalf("GetFileMetadata: %v", err)
+	}
+	if metadata.ContentType != "audio/wav" || metadata.Size != int64(len(content)) {
+		t.Errorf("metadata = %+v", metadata)
+	}
+
+	// Importing content that's already stored is a no-op
+	if again, err := store.ImportFile(metadataOf("restored.wav", content), bytes.NewReader(content)); err != nil || again != path {
+		t.Errorf("second import = %q, %v", again, err)
+	}
+}
+
+func testImportFileMismatch(t *testing.T, store domain.StorageService) {
+	content := []byte("restored from a backup")
+
+	metadata := metadataOf("restored.wav", content)
+	metadata.Hash = hashOf([]byte("something else"))
+	if _, err := store.ImportFile(metadata, bytes.NewReader(content)); !errors.Is(err, domain.ErrInvalidHash) {
+		t.Errorf("err = %v, want %v", err, domain.ErrInvalidHash)
+	}
+
+	metadata = metadataOf("restored.wav", content)
+	metadata.Size++
+	if _, err := store.ImportFile(metadata, bytes.NewReader(content)); !errors.Is(err, domain.ErrUploadSizeMismatch) {
+		t.Errorf("short content: err = %v, want %v", err, domain.ErrUploadSizeMismatch)
+	}
+
+	objects, err := store.ListFiles("")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("rejected imports left %d objects behind", len(objects))
+	}
+}
+
+func upload(t *testing.T, store domain.StorageService, filename string, content []byte) (domain.FileInfo, string) {
+	t.Helper()
+	info, path, err := store.UploadFile(filename, bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("UploadFile(%s): %v", filename, err)
+	}
+	return info, path
+}
+
+func put(t *testing.T, presigned domain.PresignedUpload, content []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, presigned.URL, bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", presigned.ContentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT %s: %v", presigned.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("PUT %s = %d %s", presigned.URL, resp.StatusCode, body)
+	}
+}
+
+func assertContent(t *testing.T, store domain.StorageService, path string, content []byte) {
+	t.Helper()
+	reader, _, err := store.GetFile(path)
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("content of %s differs: got %d bytes, want %d", path, len(got), len(content))
+	}
+}
+
+func metadataOf(filename string, content []byte) domain.FileMetadata {
+	return domain.FileMetadata{
+		Size:        int64(len(content)),
+		Filename:    filename,
+		ContentType: "audio/wav",
+		Hash:        hashOf(content),
+		UploadedAt:  time.Now(),
+	}
+}
+
+func hashOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func blobPath(content []byte) string {
+	hash := hashOf(content)
+	return fmt.Sprintf("blobs/%s/%s", hash[:2], hash)
+}
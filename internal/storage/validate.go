@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"dawhub/internal/domain"
+)
+
+// validateFile hashes and size-checks a file without keeping it in memory
+func validateFile(reader io.Reader, filename string) (domain.FileMetadata, error) {
+	contentType := determineContentType(filename)
+	if !domain.IsAllowedFileType(contentType) {
+		log.Printf("[ERROR] Invalid content type: %s", contentType)
+		return domain.FileMetadata{}, domain.ErrInvalidFileType
+	}
+
+	// Read file while calculating hash and size
+	hash := sha256.New()
+	body := &sizeLimitedReader{reader: reader, limit: domain.MaxFileSize}
+	if _, err := io.Copy(hash, body); err != nil {
+		if body.exceeded {
+			log.Printf("[ERROR] File too large: more than %d bytes", domain.MaxFileSize)
+			return domain.FileMetadata{}, domain.ErrFileTooLarge
+		}
+		log.Printf("[ERROR] Failed to read file: %v", err)
+		return domain.FileMetadata{}, fmt.Errorf("failed to process file: %w", err)
+	}
+
+	metadata := domain.FileMetadata{
+		Size:        body.read,
+		ContentType: contentType,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		UploadedAt:  time.Now(),
+	}
+
+	return metadata, nil
+}
+
+// validateFiles validates multiple files in parallel
+func validateFiles(files map[string]io.Reader) (map[string]domain.FileMetadata, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	results := make(map[string]domain.FileMetadata)
+	errors := make([]error, 0)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for filename, reader := range files {
+		wg.Add(1)
+		go func(fname string, r io.Reader) {
+			defer wg.Done()
+
+			metadata, err := validateFile(r, fname)
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				errors = append(errors, fmt.Errorf("failed to validate %s: %w", fname, err))
+				return
+			}
+
+			metadata.Filename = fname
+			results[fname] = metadata
+		}(filename, reader)
+	}
+
+	wg.Wait()
+
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("validation errors: %v", errors)
+	}
+
+	return results, nil
+}
@@ -35,15 +35,20 @@ func Render(c *gin.Context, data gin.H) {
 
 // RenderError renders an error response
 func RenderError(c *gin.Context, message string) {
+	RenderErrorStatus(c, http.StatusBadRequest, message)
+}
+
+// RenderErrorStatus renders an error response with the given status code
+func RenderErrorStatus(c *gin.Context, status int, message string) {
 	if IsHtmx(c) {
-		c.HTML(http.StatusBadRequest, "error", gin.H{
+		c.HTML(status, "error", gin.H{
 			"error": message,
 		})
 		return
 	}
 
 	// For non-HTMX requests
-	c.HTML(http.StatusBadRequest, "base", gin.H{
+	c.HTML(status, "base", gin.H{
 		"content": "error",
 		"error":   message,
 	})